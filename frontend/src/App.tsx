@@ -61,6 +61,7 @@ const modernTheme = {
 
 export default function App() {
   const [claims, setClaims] = useState<Claim[]>([]);
+  const [nextCursor, setNextCursor] = useState<string | undefined>();
   const [loading, setLoading] = useState(false);
 
   const refresh = async () => {
@@ -68,6 +69,19 @@ export default function App() {
     try {
       const data = await listClaims();
       setClaims(data.items ?? []);
+      setNextCursor(data.next_cursor);
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  const loadMore = async () => {
+    if (!nextCursor) return;
+    setLoading(true);
+    try {
+      const data = await listClaims(nextCursor);
+      setClaims(prev => [...prev, ...(data.items ?? [])]);
+      setNextCursor(data.next_cursor);
     } finally {
       setLoading(false);
     }
@@ -77,8 +91,8 @@ export default function App() {
 
   // Calculate statistics
   const totalClaims = claims.length;
-  const completeClaims = claims.filter(c => c.Status === 'COMPLETE').length;
-  const pendingClaims = claims.filter(c => c.Status === 'UPLOADING').length;
+  const completeClaims = claims.filter(c => c.status === 'COMPLETE').length;
+  const pendingClaims = claims.filter(c => c.status === 'UPLOADING').length;
 
   return (
     <ThemeProvider theme={modernTheme}>
@@ -359,7 +373,7 @@ export default function App() {
                 gap: '2rem'
               }}>
                 <UploadForm onUploaded={() => setTimeout(refresh, 700)} />
-                <ClaimList claims={claims} loading={loading} refresh={refresh} />
+                <ClaimList claims={claims} loading={loading} refresh={refresh} loadMore={nextCursor ? loadMore : undefined} />
               </div>
             </View>
           )}
@@ -42,35 +42,49 @@ async function fetchJson(input: RequestInfo, init?: RequestInit) {
 }
 
 /** ===== Types ===== */
+export type ClaimStatus = 'UPLOADING' | 'SCANNING' | 'COMPLETE' | 'FAILED' | 'QUARANTINED' | 'WITHDRAWN';
+
+/** A claim as the API returns it (models.ClaimView). */
 export type Claim = {
-  ClaimID: string;
-  UserID: string;
-  Filename: string;
-  S3Key: string;
-  Tags: string[];
-  Client: string;
-  Status: 'UPLOADING' | 'COMPLETE';
-  UploadedAt: string;
-  SizeBytes: number;
-  ETag: string;
+  claim_id: string;
+  filename: string;
+  content_type: string;
+  tags: string[] | null;
+  category?: string;
+  client: string;
+  status: ClaimStatus;
+  uploaded_at: string; // empty until the upload completes
+  size_bytes: number;
+  etag: string;
+  withdrawn_at?: string;
+  created_at?: string;
+  failure_reason?: string;
+  attachment_count?: number;
+  review_status?: string;
+  version: number; // send back as If-Match to modify the claim
 };
-export type ListResp = { user_id: string; items: Claim[] };
+export type ListResp = { user_id: string; items: Claim[]; next_cursor?: string };
 
 type PresignReq = { filename: string; tags: string[]; client: string; content_type?: string };
 export type PresignResp = {
   claim_id: string;
   s3_key: string;
+  upload_method: 'PUT' | 'POST';
   presigned_url: string;
   expires_in: number;
   content_type: string;
-  upload_headers: Record<string, string>;
+  max_bytes: number;
+  upload_headers?: Record<string, string>;
+  form_fields?: Record<string, string>;
 };
 
 /** ===== API calls ===== */
-export async function listClaims(): Promise<ListResp> {
+/** One page of the caller's claims, newest first; pass next_cursor to get the next page. */
+export async function listClaims(cursor?: string): Promise<ListResp> {
   const base = getApiBase();
   const token = await idToken();
-  return fetchJson(`${base}/claims`, {
+  const qs = cursor ? `?${new URLSearchParams({ cursor })}` : '';
+  return fetchJson(`${base}/claims${qs}`, {
     headers: { Authorization: `Bearer ${token}` },
   });
 }
@@ -84,7 +98,7 @@ export async function presignUpload(body: PresignReq): Promise<PresignResp> {
       Authorization: `Bearer ${token}`,
       'Content-Type': 'application/json',
     },
-    body: JSON.stringify({ ...body, content_type: body.content_type ?? 'text/plain' }),
+    body: JSON.stringify(body), // content_type is inferred from the extension if omitted
   });
 }
 
@@ -92,3 +106,18 @@ export async function putToS3(url: string, headers: Record<string, string>, file
   const r = await fetch(url, { method: 'PUT', headers, body: file });
   if (!r.ok) throw new Error(`S3 PUT failed: ${r.status}`);
 }
+
+export async function postToS3(url: string, fields: Record<string, string>, file: File) {
+  const form = new FormData();
+  for (const [k, v] of Object.entries(fields)) form.append(k, v);
+  form.append('file', file); // must be the last field
+  const r = await fetch(url, { method: 'POST', body: form });
+  if (!r.ok) throw new Error(`S3 POST failed: ${r.status}`);
+}
+
+/** Upload using whichever method the presign endpoint issued. */
+export async function uploadToS3(p: PresignResp, file: File) {
+  if (file.size > p.max_bytes) throw new Error(`File too large (max ${p.max_bytes} bytes)`);
+  if (p.upload_method === 'POST') return postToS3(p.presigned_url, p.form_fields ?? {}, file);
+  return putToS3(p.presigned_url, p.upload_headers ?? {}, file);
+}
@@ -2,10 +2,11 @@ import { useState } from 'react';
 import type { Claim } from '../api';
 import { RefreshCw, FileText, Building2, Clock, CheckCircle2, Tag, Search, Activity } from 'lucide-react';
 
-export default function ClaimList({ claims, refresh, loading }: {
+export default function ClaimList({ claims, refresh, loading, loadMore }: {
   claims: Claim[]; 
   refresh: () => void; 
   loading: boolean;
+  loadMore?: () => void; // set while the API has more pages
 }) {
   const [searchTerm, setSearchTerm] = useState('');
   const [filterStatus, setFilterStatus] = useState<string>('all');
@@ -13,10 +14,10 @@ export default function ClaimList({ claims, refresh, loading }: {
   // Filter claims based on search and status
   const filteredClaims = claims.filter(claim => {
     const matchesSearch = 
-      claim.Filename.toLowerCase().includes(searchTerm.toLowerCase()) ||
-      claim.Client.toLowerCase().includes(searchTerm.toLowerCase()) ||
-      claim.Tags.some(tag => tag.toLowerCase().includes(searchTerm.toLowerCase()));
-    const matchesStatus = filterStatus === 'all' || claim.Status === filterStatus;
+      claim.filename.toLowerCase().includes(searchTerm.toLowerCase()) ||
+      claim.client.toLowerCase().includes(searchTerm.toLowerCase()) ||
+      (claim.tags ?? []).some(tag => tag.toLowerCase().includes(searchTerm.toLowerCase()));
+    const matchesStatus = filterStatus === 'all' || claim.status === filterStatus;
     return matchesSearch && matchesStatus;
   });
 
@@ -124,6 +125,9 @@ export default function ClaimList({ claims, refresh, loading }: {
             <option value="all" style={{ background: '#1a1a2e' }}>All Status</option>
             <option value="COMPLETE" style={{ background: '#1a1a2e' }}>Complete</option>
             <option value="UPLOADING" style={{ background: '#1a1a2e' }}>Uploading</option>
+            <option value="SCANNING" style={{ background: '#1a1a2e' }}>Scanning</option>
+            <option value="FAILED" style={{ background: '#1a1a2e' }}>Failed</option>
+            <option value="QUARANTINED" style={{ background: '#1a1a2e' }}>Quarantined</option>
           </select>
           
           {/* Refresh Button */}
@@ -202,7 +206,7 @@ export default function ClaimList({ claims, refresh, loading }: {
         }}>
           {filteredClaims.map((claim, index) => (
             <div 
-              key={claim.ClaimID} 
+              key={claim.claim_id} 
               style={{
                 background: 'rgba(255, 255, 255, 0.03)',
                 border: '1px solid rgba(255, 255, 255, 0.1)',
@@ -253,7 +257,7 @@ export default function ClaimList({ claims, refresh, loading }: {
                     textOverflow: 'ellipsis',
                     whiteSpace: 'nowrap'
                   }}>
-                    {claim.Filename}
+                    {claim.filename}
                   </span>
                 </div>
                 
@@ -267,23 +271,23 @@ export default function ClaimList({ claims, refresh, loading }: {
                   fontSize: '0.75rem',
                   fontWeight: '500',
                   flexShrink: 0,
-                  background: claim.Status === 'COMPLETE' ? 
+                  background: claim.status === 'COMPLETE' ? 
                     'rgba(34, 197, 94, 0.1)' : 
                     'rgba(251, 191, 36, 0.1)',
-                  color: claim.Status === 'COMPLETE' ? 
+                  color: claim.status === 'COMPLETE' ? 
                     '#22c55e' : 
                     '#fbbf24',
                   border: `1px solid ${
-                    claim.Status === 'COMPLETE' ? 
+                    claim.status === 'COMPLETE' ? 
                     'rgba(34, 197, 94, 0.2)' : 
                     'rgba(251, 191, 36, 0.2)'
                   }`
                 }}>
-                  {claim.Status === 'COMPLETE' ? 
+                  {claim.status === 'COMPLETE' ? 
                     <CheckCircle2 size={14} /> : 
                     <Clock size={14} />
                   }
-                  {claim.Status}
+                  {claim.status}
                 </span>
               </div>
               
@@ -310,7 +314,7 @@ export default function ClaimList({ claims, refresh, loading }: {
                     textOverflow: 'ellipsis',
                     whiteSpace: 'nowrap'
                   }}>
-                    {claim.Client}
+                    {claim.client}
                   </span>
                 </div>
                 
@@ -328,7 +332,7 @@ export default function ClaimList({ claims, refresh, loading }: {
                     color: 'rgba(255, 255, 255, 0.8)', 
                     fontSize: '0.9rem' 
                   }}>
-                    {new Date(claim.UploadedAt).toLocaleDateString('en-US', {
+                    {new Date(claim.uploaded_at || claim.created_at || '').toLocaleDateString('en-US', {
                       month: 'short',
                       day: 'numeric',
                       year: 'numeric',
@@ -339,14 +343,14 @@ export default function ClaimList({ claims, refresh, loading }: {
                 </div>
                 
                 {/* Tags */}
-                {claim.Tags.length > 0 && (
+                {(claim.tags ?? []).length > 0 && (
                   <div style={{ 
                     display: 'flex', 
                     flexWrap: 'wrap', 
                     gap: '4px', 
                     marginTop: '4px' 
                   }}>
-                    {claim.Tags.map((tag, i) => (
+                    {(claim.tags ?? []).map((tag, i) => (
                       <span key={i} style={{
                         padding: '2px 6px',
                         background: 'rgba(102, 126, 234, 0.15)',
@@ -374,13 +378,36 @@ export default function ClaimList({ claims, refresh, loading }: {
                 fontSize: '0.7rem',
                 color: 'rgba(255, 255, 255, 0.3)'
               }}>
-                {(claim.SizeBytes / 1024).toFixed(1)} KB
+                {(claim.size_bytes / 1024).toFixed(1)} KB
               </div>
             </div>
           ))}
         </div>
       )}
 
+      {/* Pagination: filters above only apply to the pages loaded so far */}
+      {loadMore && (
+        <button 
+          onClick={loadMore} 
+          disabled={loading} 
+          style={{
+            alignSelf: 'center',
+            padding: '10px 20px',
+            background: 'rgba(102, 126, 234, 0.1)',
+            color: '#a5b4fc',
+            border: '1px solid rgba(102, 126, 234, 0.2)',
+            borderRadius: '10px',
+            fontSize: '0.9rem',
+            cursor: loading ? 'not-allowed' : 'pointer',
+            fontWeight: '500',
+            opacity: loading ? 0.6 : 1,
+            transition: 'all 0.3s ease'
+          }}
+        >
+          {loading ? 'Loading' : 'Load more'}
+        </button>
+      )}
+
       <style>{`
         @keyframes spin {
           from { transform: rotate(0deg); }
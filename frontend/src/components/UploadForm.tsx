@@ -1,7 +1,22 @@
 import React, { useState } from 'react';
-import { presignUpload, putToS3 } from '../api';
+import { presignUpload, uploadToS3 } from '../api';
 import { Upload, RefreshCw, FileText, Tag, Building2, CheckCircle2, AlertCircle } from 'lucide-react';
 
+// Mirrors the backend's default allowlist (validate.DefaultContentTypes).
+const CONTENT_TYPES: Record<string, string> = {
+  '.txt': 'text/plain',
+  '.pdf': 'application/pdf',
+  '.jpg': 'image/jpeg',
+  '.jpeg': 'image/jpeg',
+  '.png': 'image/png',
+  '.docx': 'application/vnd.openxmlformats-officedocument.wordprocessingml.document',
+};
+
+function contentTypeFor(name: string): string | undefined {
+  const dot = name.lastIndexOf('.');
+  return dot < 0 ? undefined : CONTENT_TYPES[name.slice(dot).toLowerCase()];
+}
+
 export default function UploadForm({ onUploaded }: { onUploaded: () => void }) {
   const [file, setFile] = useState<File | null>(null);
   const [tags, setTags] = useState('');
@@ -32,8 +47,9 @@ export default function UploadForm({ onUploaded }: { onUploaded: () => void }) {
   const submit = async (e: React.FormEvent) => {
     e.preventDefault();
     setMsg('');
-    if (!file) return setMsg('Choose a .txt file');
-    if (!file.name.toLowerCase().endsWith('.txt')) return setMsg('Only .txt files allowed');
+    if (!file) return setMsg('Choose a file');
+    const contentType = contentTypeFor(file.name);
+    if (!contentType) return setMsg('Only .txt, .pdf, .jpg, .png or .docx files allowed');
     if (!client.trim()) return setMsg('Client required');
 
     try {
@@ -43,9 +59,9 @@ export default function UploadForm({ onUploaded }: { onUploaded: () => void }) {
         filename: file.name,
         client: client.trim(),
         tags: tagList,
-        content_type: 'text/plain'
+        content_type: contentType
       });
-      await putToS3(p.presigned_url, p.upload_headers, file);
+      await uploadToS3(p, file);
       setMsg('Upload complete!');
       setFile(null);
       setTags('');
@@ -110,7 +126,7 @@ export default function UploadForm({ onUploaded }: { onUploaded: () => void }) {
       >
         <input 
           type="file" 
-          accept=".txt,text/plain" 
+          accept={Object.keys(CONTENT_TYPES).join(',')}
           onChange={(e) => setFile(e.target.files?.[0] ?? null)}
           style={{
             position: 'absolute',
@@ -153,13 +169,13 @@ export default function UploadForm({ onUploaded }: { onUploaded: () => void }) {
                 fontWeight: '500', 
                 color: 'rgba(255, 255, 255, 0.9)' 
               }}>
-                Drop your .txt file here or click to browse
+                Drop your claim document here or click to browse
               </span>
               <span style={{ 
                 fontSize: '0.875rem', 
                 color: 'rgba(255, 255, 255, 0.5)' 
               }}>
-                Only text files are accepted
+                Text, PDF, JPEG, PNG or Word (.docx) files
               </span>
             </>
           )}
@@ -53,6 +53,60 @@ resource "aws_api_gateway_resource" "presign" {
   path_part   = "presign"
 }
 
+resource "aws_api_gateway_resource" "search" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_resource.claims.id
+  path_part   = "search"
+}
+
+resource "aws_api_gateway_resource" "claim" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_resource.claims.id
+  path_part   = "{id}"
+}
+
+resource "aws_api_gateway_resource" "claim_history" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_resource.claim.id
+  path_part   = "history"
+}
+
+resource "aws_api_gateway_resource" "claim_download" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_resource.claim.id
+  path_part   = "download"
+}
+
+resource "aws_api_gateway_resource" "claim_status" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_resource.claim.id
+  path_part   = "status"
+}
+
+resource "aws_api_gateway_resource" "claim_vendors" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_resource.claim.id
+  path_part   = "vendors"
+}
+
+resource "aws_api_gateway_resource" "claim_attachments" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_resource.claim.id
+  path_part   = "attachments"
+}
+
+resource "aws_api_gateway_resource" "claim_multipart" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_resource.claim.id
+  path_part   = "multipart"
+}
+
+resource "aws_api_gateway_resource" "claim_multipart_complete" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_resource.claim_multipart.id
+  path_part   = "complete"
+}
+
 #
 # API Methods.
 #
@@ -66,6 +120,14 @@ resource "aws_api_gateway_method" "get_claims" {
   authorizer_id = aws_api_gateway_authorizer.cognito.id
 }
 
+resource "aws_api_gateway_method" "get_search" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.search.id
+  http_method   = "GET"
+  authorization = "COGNITO_USER_POOLS"
+  authorizer_id = aws_api_gateway_authorizer.cognito.id
+}
+
 resource "aws_api_gateway_method" "post_presign" {
   rest_api_id   = aws_api_gateway_rest_api.main.id
   resource_id   = aws_api_gateway_resource.presign.id
@@ -74,6 +136,78 @@ resource "aws_api_gateway_method" "post_presign" {
   authorizer_id = aws_api_gateway_authorizer.cognito.id
 }
 
+resource "aws_api_gateway_method" "get_claim" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.claim.id
+  http_method   = "GET"
+  authorization = "COGNITO_USER_POOLS"
+  authorizer_id = aws_api_gateway_authorizer.cognito.id
+}
+
+resource "aws_api_gateway_method" "delete_claim" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.claim.id
+  http_method   = "DELETE"
+  authorization = "COGNITO_USER_POOLS"
+  authorizer_id = aws_api_gateway_authorizer.cognito.id
+}
+
+resource "aws_api_gateway_method" "get_history" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.claim_history.id
+  http_method   = "GET"
+  authorization = "COGNITO_USER_POOLS"
+  authorizer_id = aws_api_gateway_authorizer.cognito.id
+}
+
+resource "aws_api_gateway_method" "get_download" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.claim_download.id
+  http_method   = "GET"
+  authorization = "COGNITO_USER_POOLS"
+  authorizer_id = aws_api_gateway_authorizer.cognito.id
+}
+
+resource "aws_api_gateway_method" "patch_status" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.claim_status.id
+  http_method   = "PATCH"
+  authorization = "COGNITO_USER_POOLS"
+  authorizer_id = aws_api_gateway_authorizer.cognito.id
+}
+
+resource "aws_api_gateway_method" "put_vendors" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.claim_vendors.id
+  http_method   = "PUT"
+  authorization = "COGNITO_USER_POOLS"
+  authorizer_id = aws_api_gateway_authorizer.cognito.id
+}
+
+resource "aws_api_gateway_method" "post_attachments" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.claim_attachments.id
+  http_method   = "POST"
+  authorization = "COGNITO_USER_POOLS"
+  authorizer_id = aws_api_gateway_authorizer.cognito.id
+}
+
+resource "aws_api_gateway_method" "post_multipart_complete" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.claim_multipart_complete.id
+  http_method   = "POST"
+  authorization = "COGNITO_USER_POOLS"
+  authorizer_id = aws_api_gateway_authorizer.cognito.id
+}
+
+resource "aws_api_gateway_method" "delete_multipart" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.claim_multipart.id
+  http_method   = "DELETE"
+  authorization = "COGNITO_USER_POOLS"
+  authorizer_id = aws_api_gateway_authorizer.cognito.id
+}
+
 #
 # Lambda Integrations.
 #
@@ -89,6 +223,15 @@ resource "aws_api_gateway_integration" "list" {
   uri                     = aws_lambda_function.api_list.invoke_arn
 }
 
+resource "aws_api_gateway_integration" "search" {
+  rest_api_id             = aws_api_gateway_rest_api.main.id
+  resource_id             = aws_api_gateway_resource.search.id
+  http_method             = aws_api_gateway_method.get_search.http_method
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = aws_lambda_function.api_search.invoke_arn
+}
+
 resource "aws_api_gateway_integration" "presign" {
   rest_api_id             = aws_api_gateway_rest_api.main.id
   resource_id             = aws_api_gateway_resource.presign.id
@@ -98,6 +241,87 @@ resource "aws_api_gateway_integration" "presign" {
   uri                     = aws_lambda_function.api_presign.invoke_arn
 }
 
+resource "aws_api_gateway_integration" "get" {
+  rest_api_id             = aws_api_gateway_rest_api.main.id
+  resource_id             = aws_api_gateway_resource.claim.id
+  http_method             = aws_api_gateway_method.get_claim.http_method
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = aws_lambda_function.api_get.invoke_arn
+}
+
+resource "aws_api_gateway_integration" "delete" {
+  rest_api_id             = aws_api_gateway_rest_api.main.id
+  resource_id             = aws_api_gateway_resource.claim.id
+  http_method             = aws_api_gateway_method.delete_claim.http_method
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = aws_lambda_function.api_delete.invoke_arn
+}
+
+resource "aws_api_gateway_integration" "history" {
+  rest_api_id             = aws_api_gateway_rest_api.main.id
+  resource_id             = aws_api_gateway_resource.claim_history.id
+  http_method             = aws_api_gateway_method.get_history.http_method
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = aws_lambda_function.api_get.invoke_arn
+}
+
+resource "aws_api_gateway_integration" "download" {
+  rest_api_id             = aws_api_gateway_rest_api.main.id
+  resource_id             = aws_api_gateway_resource.claim_download.id
+  http_method             = aws_api_gateway_method.get_download.http_method
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = aws_lambda_function.api_download.invoke_arn
+}
+
+resource "aws_api_gateway_integration" "status" {
+  rest_api_id             = aws_api_gateway_rest_api.main.id
+  resource_id             = aws_api_gateway_resource.claim_status.id
+  http_method             = aws_api_gateway_method.patch_status.http_method
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = aws_lambda_function.api_review.invoke_arn
+}
+
+resource "aws_api_gateway_integration" "vendors" {
+  rest_api_id             = aws_api_gateway_rest_api.main.id
+  resource_id             = aws_api_gateway_resource.claim_vendors.id
+  http_method             = aws_api_gateway_method.put_vendors.http_method
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = aws_lambda_function.api_review.invoke_arn
+}
+
+resource "aws_api_gateway_integration" "attachments" {
+  rest_api_id             = aws_api_gateway_rest_api.main.id
+  resource_id             = aws_api_gateway_resource.claim_attachments.id
+  http_method             = aws_api_gateway_method.post_attachments.http_method
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = aws_lambda_function.api_presign.invoke_arn
+}
+
+resource "aws_api_gateway_integration" "multipart_complete" {
+  rest_api_id             = aws_api_gateway_rest_api.main.id
+  resource_id             = aws_api_gateway_resource.claim_multipart_complete.id
+  http_method             = aws_api_gateway_method.post_multipart_complete.http_method
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = aws_lambda_function.api_multipart.invoke_arn
+}
+
+resource "aws_api_gateway_integration" "multipart_abort" {
+  rest_api_id             = aws_api_gateway_rest_api.main.id
+  resource_id             = aws_api_gateway_resource.claim_multipart.id
+  http_method             = aws_api_gateway_method.delete_multipart.http_method
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = aws_lambda_function.api_multipart.invoke_arn
+}
+
 #
 # CloudWatch Log Group for API Gateway access logs.
 #
@@ -121,12 +345,43 @@ resource "aws_api_gateway_deployment" "main" {
     redeployment = sha1(jsonencode([
       aws_api_gateway_resource.claims.id,
       aws_api_gateway_resource.presign.id,
+      aws_api_gateway_resource.search.id,
       aws_api_gateway_method.get_claims.id,
       aws_api_gateway_method.post_presign.id,
+      aws_api_gateway_method.get_search.id,
       aws_api_gateway_integration.list.id,
       aws_api_gateway_integration.presign.id,
+      aws_api_gateway_integration.search.id,
       aws_api_gateway_method.claims_options.id,
       aws_api_gateway_method.presign_options.id,
+      aws_api_gateway_method.search_options.id,
+      aws_api_gateway_resource.claim.id,
+      aws_api_gateway_resource.claim_history.id,
+      aws_api_gateway_resource.claim_download.id,
+      aws_api_gateway_resource.claim_status.id,
+      aws_api_gateway_resource.claim_vendors.id,
+      aws_api_gateway_resource.claim_attachments.id,
+      aws_api_gateway_resource.claim_multipart.id,
+      aws_api_gateway_resource.claim_multipart_complete.id,
+      aws_api_gateway_method.get_claim.id,
+      aws_api_gateway_method.delete_claim.id,
+      aws_api_gateway_method.get_history.id,
+      aws_api_gateway_method.get_download.id,
+      aws_api_gateway_method.patch_status.id,
+      aws_api_gateway_method.put_vendors.id,
+      aws_api_gateway_method.post_attachments.id,
+      aws_api_gateway_method.post_multipart_complete.id,
+      aws_api_gateway_method.delete_multipart.id,
+      aws_api_gateway_integration.get.id,
+      aws_api_gateway_integration.delete.id,
+      aws_api_gateway_integration.history.id,
+      aws_api_gateway_integration.download.id,
+      aws_api_gateway_integration.status.id,
+      aws_api_gateway_integration.vendors.id,
+      aws_api_gateway_integration.attachments.id,
+      aws_api_gateway_integration.multipart_complete.id,
+      aws_api_gateway_integration.multipart_abort.id,
+      values(aws_api_gateway_method.claim_options)[*].id,
     ]))
   }
 
@@ -139,8 +394,10 @@ resource "aws_api_gateway_deployment" "main" {
   depends_on = [
     aws_api_gateway_method.get_claims,
     aws_api_gateway_method.post_presign,
+    aws_api_gateway_method.get_search,
     aws_api_gateway_integration.list,
     aws_api_gateway_integration.presign,
+    aws_api_gateway_integration.search,
     aws_api_gateway_method.claims_options,
     aws_api_gateway_integration.claims_options,
     aws_api_gateway_method_response.claims_options,
@@ -149,6 +406,32 @@ resource "aws_api_gateway_deployment" "main" {
     aws_api_gateway_integration.presign_options,
     aws_api_gateway_method_response.presign_options,
     aws_api_gateway_integration_response.presign_options,
+    aws_api_gateway_method.search_options,
+    aws_api_gateway_integration.search_options,
+    aws_api_gateway_method_response.search_options,
+    aws_api_gateway_integration_response.search_options,
+    aws_api_gateway_method.get_claim,
+    aws_api_gateway_method.delete_claim,
+    aws_api_gateway_method.get_history,
+    aws_api_gateway_method.get_download,
+    aws_api_gateway_method.patch_status,
+    aws_api_gateway_method.put_vendors,
+    aws_api_gateway_method.post_attachments,
+    aws_api_gateway_method.post_multipart_complete,
+    aws_api_gateway_method.delete_multipart,
+    aws_api_gateway_integration.get,
+    aws_api_gateway_integration.delete,
+    aws_api_gateway_integration.history,
+    aws_api_gateway_integration.download,
+    aws_api_gateway_integration.status,
+    aws_api_gateway_integration.vendors,
+    aws_api_gateway_integration.attachments,
+    aws_api_gateway_integration.multipart_complete,
+    aws_api_gateway_integration.multipart_abort,
+    aws_api_gateway_method.claim_options,
+    aws_api_gateway_integration.claim_options,
+    aws_api_gateway_method_response.claim_options,
+    aws_api_gateway_integration_response.claim_options,
     aws_api_gateway_gateway_response.default_4xx,
     aws_api_gateway_gateway_response.default_5xx,
   ]
@@ -303,6 +586,119 @@ resource "aws_api_gateway_integration_response" "presign_options" {
   }
 }
 
+# CORS for `/claims/search`
+resource "aws_api_gateway_method" "search_options" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.search.id
+  http_method   = "OPTIONS"
+  authorization = "NONE"
+}
+
+resource "aws_api_gateway_integration" "search_options" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = aws_api_gateway_resource.search.id
+  http_method = aws_api_gateway_method.search_options.http_method
+  type        = "MOCK"
+
+  request_templates = {
+    "application/json" = "{\"statusCode\": 200}"
+  }
+}
+
+resource "aws_api_gateway_method_response" "search_options" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = aws_api_gateway_resource.search.id
+  http_method = aws_api_gateway_method.search_options.http_method
+  status_code = "200"
+
+  response_parameters = {
+    "method.response.header.Access-Control-Allow-Headers"     = true
+    "method.response.header.Access-Control-Allow-Methods"     = true
+    "method.response.header.Access-Control-Allow-Origin"      = true
+    "method.response.header.Access-Control-Allow-Credentials" = true
+  }
+}
+
+resource "aws_api_gateway_integration_response" "search_options" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = aws_api_gateway_resource.search.id
+  http_method = aws_api_gateway_method.search_options.http_method
+  status_code = aws_api_gateway_method_response.search_options.status_code
+
+  response_parameters = {
+    "method.response.header.Access-Control-Allow-Headers"     = "'Content-Type,X-Amz-Date,Authorization,X-Api-Key,X-Amz-Security-Token'"
+    "method.response.header.Access-Control-Allow-Methods"     = "'GET,OPTIONS'"
+    "method.response.header.Access-Control-Allow-Origin"      = "'${local.amplify_origin}'"
+    "method.response.header.Access-Control-Allow-Credentials" = "'true'"
+  }
+}
+
+# CORS for the per-claim resources under `/claims/{id}`. They all share one
+# shape, so they are generated from this map of resource to allowed methods.
+# If-Match is allowed because writes there are conditional on the claim version.
+locals {
+  claim_cors = {
+    claim                    = { resource_id = aws_api_gateway_resource.claim.id, methods = "GET,DELETE,OPTIONS" }
+    claim_history            = { resource_id = aws_api_gateway_resource.claim_history.id, methods = "GET,OPTIONS" }
+    claim_download           = { resource_id = aws_api_gateway_resource.claim_download.id, methods = "GET,OPTIONS" }
+    claim_status             = { resource_id = aws_api_gateway_resource.claim_status.id, methods = "PATCH,OPTIONS" }
+    claim_vendors            = { resource_id = aws_api_gateway_resource.claim_vendors.id, methods = "PUT,OPTIONS" }
+    claim_attachments        = { resource_id = aws_api_gateway_resource.claim_attachments.id, methods = "POST,OPTIONS" }
+    claim_multipart          = { resource_id = aws_api_gateway_resource.claim_multipart.id, methods = "DELETE,OPTIONS" }
+    claim_multipart_complete = { resource_id = aws_api_gateway_resource.claim_multipart_complete.id, methods = "POST,OPTIONS" }
+  }
+}
+
+resource "aws_api_gateway_method" "claim_options" {
+  for_each      = local.claim_cors
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = each.value.resource_id
+  http_method   = "OPTIONS"
+  authorization = "NONE"
+}
+
+resource "aws_api_gateway_integration" "claim_options" {
+  for_each    = local.claim_cors
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = each.value.resource_id
+  http_method = aws_api_gateway_method.claim_options[each.key].http_method
+  type        = "MOCK"
+
+  request_templates = {
+    "application/json" = "{\"statusCode\": 200}"
+  }
+}
+
+resource "aws_api_gateway_method_response" "claim_options" {
+  for_each    = local.claim_cors
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = each.value.resource_id
+  http_method = aws_api_gateway_method.claim_options[each.key].http_method
+  status_code = "200"
+
+  response_parameters = {
+    "method.response.header.Access-Control-Allow-Headers"     = true
+    "method.response.header.Access-Control-Allow-Methods"     = true
+    "method.response.header.Access-Control-Allow-Origin"      = true
+    "method.response.header.Access-Control-Allow-Credentials" = true
+  }
+}
+
+resource "aws_api_gateway_integration_response" "claim_options" {
+  for_each    = local.claim_cors
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = each.value.resource_id
+  http_method = aws_api_gateway_method.claim_options[each.key].http_method
+  status_code = aws_api_gateway_method_response.claim_options[each.key].status_code
+
+  response_parameters = {
+    "method.response.header.Access-Control-Allow-Headers"     = "'Content-Type,X-Amz-Date,Authorization,X-Api-Key,X-Amz-Security-Token,If-Match'"
+    "method.response.header.Access-Control-Allow-Methods"     = "'${each.value.methods}'"
+    "method.response.header.Access-Control-Allow-Origin"      = "'${local.amplify_origin}'"
+    "method.response.header.Access-Control-Allow-Credentials" = "'true'"
+  }
+}
+
 #
 # Default Gateway Responses.
 #
@@ -315,8 +711,8 @@ resource "aws_api_gateway_gateway_response" "default_4xx" {
 
   response_parameters = {
     "gatewayresponse.header.Access-Control-Allow-Origin"      = "'${local.amplify_origin}'"
-    "gatewayresponse.header.Access-Control-Allow-Headers"     = "'Content-Type,X-Amz-Date,Authorization,X-Api-Key,X-Amz-Security-Token'"
-    "gatewayresponse.header.Access-Control-Allow-Methods"     = "'GET,POST,OPTIONS'"
+    "gatewayresponse.header.Access-Control-Allow-Headers"     = "'Content-Type,X-Amz-Date,Authorization,X-Api-Key,X-Amz-Security-Token,If-Match'"
+    "gatewayresponse.header.Access-Control-Allow-Methods"     = "'GET,POST,PUT,PATCH,DELETE,OPTIONS'"
     "gatewayresponse.header.Access-Control-Allow-Credentials" = "'true'"
   }
 }
@@ -327,8 +723,8 @@ resource "aws_api_gateway_gateway_response" "default_5xx" {
 
   response_parameters = {
     "gatewayresponse.header.Access-Control-Allow-Origin"      = "'${local.amplify_origin}'"
-    "gatewayresponse.header.Access-Control-Allow-Headers"     = "'Content-Type,X-Amz-Date,Authorization,X-Api-Key,X-Amz-Security-Token'"
-    "gatewayresponse.header.Access-Control-Allow-Methods"     = "'GET,POST,OPTIONS'"
+    "gatewayresponse.header.Access-Control-Allow-Headers"     = "'Content-Type,X-Amz-Date,Authorization,X-Api-Key,X-Amz-Security-Token,If-Match'"
+    "gatewayresponse.header.Access-Control-Allow-Methods"     = "'GET,POST,PUT,PATCH,DELETE,OPTIONS'"
     "gatewayresponse.header.Access-Control-Allow-Credentials" = "'true'"
   }
 }
@@ -353,4 +749,52 @@ resource "aws_lambda_permission" "api_list" {
   function_name = aws_lambda_function.api_list.function_name
   principal     = "apigateway.amazonaws.com"
   source_arn    = "${aws_api_gateway_rest_api.main.execution_arn}/*/*"
+}
+
+resource "aws_lambda_permission" "api_search" {
+  statement_id  = "AllowAPIGatewayInvoke"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.api_search.function_name
+  principal     = "apigateway.amazonaws.com"
+  source_arn    = "${aws_api_gateway_rest_api.main.execution_arn}/*/*"
+}
+
+resource "aws_lambda_permission" "api_get" {
+  statement_id  = "AllowAPIGatewayInvoke"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.api_get.function_name
+  principal     = "apigateway.amazonaws.com"
+  source_arn    = "${aws_api_gateway_rest_api.main.execution_arn}/*/*"
+}
+
+resource "aws_lambda_permission" "api_download" {
+  statement_id  = "AllowAPIGatewayInvoke"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.api_download.function_name
+  principal     = "apigateway.amazonaws.com"
+  source_arn    = "${aws_api_gateway_rest_api.main.execution_arn}/*/*"
+}
+
+resource "aws_lambda_permission" "api_delete" {
+  statement_id  = "AllowAPIGatewayInvoke"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.api_delete.function_name
+  principal     = "apigateway.amazonaws.com"
+  source_arn    = "${aws_api_gateway_rest_api.main.execution_arn}/*/*"
+}
+
+resource "aws_lambda_permission" "api_review" {
+  statement_id  = "AllowAPIGatewayInvoke"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.api_review.function_name
+  principal     = "apigateway.amazonaws.com"
+  source_arn    = "${aws_api_gateway_rest_api.main.execution_arn}/*/*"
+}
+
+resource "aws_lambda_permission" "api_multipart" {
+  statement_id  = "AllowAPIGatewayInvoke"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.api_multipart.function_name
+  principal     = "apigateway.amazonaws.com"
+  source_arn    = "${aws_api_gateway_rest_api.main.execution_arn}/*/*"
 }
\ No newline at end of file
@@ -73,6 +73,32 @@ resource "aws_cognito_user_pool_domain" "this" {
   user_pool_id = aws_cognito_user_pool.this.id
 }
 
+#
+# Cognito groups for the claims team.
+#
+# Membership surfaces in the `cognito:groups` token claim, which the backend
+# maps to roles: `adjuster` can read and update any claim, `admin` can also
+# withdraw any claim, `vendor` (third parties) can only download redacted
+# copies. Users in no group are plain claimants.
+#
+resource "aws_cognito_user_group" "adjuster" {
+  name         = "adjuster"
+  user_pool_id = aws_cognito_user_pool.this.id
+  description  = "Claims adjusters: read and update claims across users"
+}
+
+resource "aws_cognito_user_group" "admin" {
+  name         = "admin"
+  user_pool_id = aws_cognito_user_pool.this.id
+  description  = "Administrators: full claim management"
+}
+
+resource "aws_cognito_user_group" "vendor" {
+  name         = "vendor"
+  user_pool_id = aws_cognito_user_pool.this.id
+  description  = "Third-party vendors: download PII-redacted claim copies only"
+}
+
 #
 # Generates a random string to ensure a unique Cognito domain name.
 #
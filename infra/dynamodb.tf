@@ -31,6 +31,30 @@ resource "aws_dynamodb_table" "claims" {
     type = "S" # String
   }
 
+  attribute {
+    name = "status"
+    type = "S"
+  }
+
+  attribute {
+    name = "created_at"
+    type = "S" # ISO8601, lexically sortable
+  }
+
+  #
+  # Status / creation-time index.
+  #
+  # Lets the reaper find claims stuck in `UPLOADING` past their presign window
+  # without scanning the table. Only keys are projected; the reaper updates
+  # items by primary key.
+  #
+  global_secondary_index {
+    name            = "status-created_at-index"
+    hash_key        = "status"
+    range_key       = "created_at"
+    projection_type = "KEYS_ONLY"
+  }
+
   #
   # Point-in-Time Recovery (PITR).
   #
@@ -51,4 +75,40 @@ resource "aws_dynamodb_table" "claims" {
     enabled     = true
     kms_key_arn = aws_kms_key.ddb.arn
   }
-}
\ No newline at end of file
+}
+
+#
+# Creates the `audit` DynamoDB table.
+#
+# An append-only history of every change to a claim and its attachments,
+# written in the same transaction as the change itself. Events are keyed by
+# `claim_ref` (`<user_id>#<claim_id>`) and a ULID `event_id`, so a claim's
+# history reads back in order with one query. No Lambda is granted
+# UpdateItem or DeleteItem on this table.
+#
+resource "aws_dynamodb_table" "audit" {
+  name         = local.table_audit
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "claim_ref"
+  range_key    = "event_id"
+  tags         = local.tags
+
+  attribute {
+    name = "claim_ref"
+    type = "S"
+  }
+
+  attribute {
+    name = "event_id"
+    type = "S" # ULID, time-ordered
+  }
+
+  point_in_time_recovery {
+    enabled = true
+  }
+
+  server_side_encryption {
+    enabled     = true
+    kms_key_arn = aws_kms_key.ddb.arn
+  }
+}
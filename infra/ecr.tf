@@ -50,6 +50,132 @@ resource "aws_ecr_repository" "api_list" {
   }
 }
 
+#
+# ECR Repository for the `api-search` service.
+#
+# This repository stores the container image for the Lambda function that
+# serves full-text search over claim letters.
+#
+resource "aws_ecr_repository" "api_search" {
+  name         = "${local.name}-api-search"
+  force_delete = true # NOTE: This allows the repository to be deleted even if it contains images.
+
+  tags = local.tags
+
+  image_scanning_configuration {
+    scan_on_push = true
+  }
+
+  encryption_configuration {
+    encryption_type = "KMS"
+  }
+}
+
+#
+# ECR Repository for the `api-get` service.
+#
+# This repository stores the container image for the Lambda function that
+# returns a single claim and its audit history.
+#
+resource "aws_ecr_repository" "api_get" {
+  name         = "${local.name}-api-get"
+  force_delete = true # NOTE: This allows the repository to be deleted even if it contains images.
+
+  tags = local.tags
+
+  image_scanning_configuration {
+    scan_on_push = true
+  }
+
+  encryption_configuration {
+    encryption_type = "KMS"
+  }
+}
+
+#
+# ECR Repository for the `api-download` service.
+#
+# This repository stores the container image for the Lambda function that
+# issues presigned download URLs for claim documents.
+#
+resource "aws_ecr_repository" "api_download" {
+  name         = "${local.name}-api-download"
+  force_delete = true # NOTE: This allows the repository to be deleted even if it contains images.
+
+  tags = local.tags
+
+  image_scanning_configuration {
+    scan_on_push = true
+  }
+
+  encryption_configuration {
+    encryption_type = "KMS"
+  }
+}
+
+#
+# ECR Repository for the `api-delete` service.
+#
+# This repository stores the container image for the Lambda function that
+# withdraws claims.
+#
+resource "aws_ecr_repository" "api_delete" {
+  name         = "${local.name}-api-delete"
+  force_delete = true # NOTE: This allows the repository to be deleted even if it contains images.
+
+  tags = local.tags
+
+  image_scanning_configuration {
+    scan_on_push = true
+  }
+
+  encryption_configuration {
+    encryption_type = "KMS"
+  }
+}
+
+#
+# ECR Repository for the `api-review` service.
+#
+# This repository stores the container image for the Lambda function that
+# records review decisions and vendor assignments.
+#
+resource "aws_ecr_repository" "api_review" {
+  name         = "${local.name}-api-review"
+  force_delete = true # NOTE: This allows the repository to be deleted even if it contains images.
+
+  tags = local.tags
+
+  image_scanning_configuration {
+    scan_on_push = true
+  }
+
+  encryption_configuration {
+    encryption_type = "KMS"
+  }
+}
+
+#
+# ECR Repository for the `api-multipart` service.
+#
+# This repository stores the container image for the Lambda function that
+# completes and aborts multipart uploads.
+#
+resource "aws_ecr_repository" "api_multipart" {
+  name         = "${local.name}-api-multipart"
+  force_delete = true # NOTE: This allows the repository to be deleted even if it contains images.
+
+  tags = local.tags
+
+  image_scanning_configuration {
+    scan_on_push = true
+  }
+
+  encryption_configuration {
+    encryption_type = "KMS"
+  }
+}
+
 #
 # ECR Repository for the `indexer` service.
 #
@@ -67,6 +193,27 @@ resource "aws_ecr_repository" "indexer" {
     scan_on_push = true
   }
 
+  encryption_configuration {
+    encryption_type = "KMS"
+  }
+}
+
+#
+# ECR Repository for the `reaper` service.
+#
+# This repository stores the container image for the scheduled Lambda function
+# that expires claims whose uploads never arrived.
+#
+resource "aws_ecr_repository" "reaper" {
+  name         = "${local.name}-reaper"
+  force_delete = true # NOTE: This allows the repository to be deleted even if it contains images.
+
+  tags = local.tags
+
+  image_scanning_configuration {
+    scan_on_push = true
+  }
+
   encryption_configuration {
     encryption_type = "KMS"
   }
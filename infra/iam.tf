@@ -49,6 +49,72 @@ resource "aws_iam_role" "lambda_list" {
   tags               = local.tags
 }
 
+#
+# IAM Role for the `search` Lambda function.
+#
+# This role is for the function that reads the search index.
+#
+resource "aws_iam_role" "lambda_search" {
+  name               = "${local.name}-lambda-search"
+  assume_role_policy = data.aws_iam_policy_document.assume_lambda.json
+  tags               = local.tags
+}
+
+#
+# IAM Role for the `get` Lambda function.
+#
+# This role is for the function that reads single claims and their history.
+#
+resource "aws_iam_role" "lambda_get" {
+  name               = "${local.name}-lambda-get"
+  assume_role_policy = data.aws_iam_policy_document.assume_lambda.json
+  tags               = local.tags
+}
+
+#
+# IAM Role for the `download` Lambda function.
+#
+# This role is for the function that presigns document downloads.
+#
+resource "aws_iam_role" "lambda_download" {
+  name               = "${local.name}-lambda-download"
+  assume_role_policy = data.aws_iam_policy_document.assume_lambda.json
+  tags               = local.tags
+}
+
+#
+# IAM Role for the `delete` Lambda function.
+#
+# This role is for the function that withdraws claims.
+#
+resource "aws_iam_role" "lambda_delete" {
+  name               = "${local.name}-lambda-delete"
+  assume_role_policy = data.aws_iam_policy_document.assume_lambda.json
+  tags               = local.tags
+}
+
+#
+# IAM Role for the `review` Lambda function.
+#
+# This role is for the function that records review decisions.
+#
+resource "aws_iam_role" "lambda_review" {
+  name               = "${local.name}-lambda-review"
+  assume_role_policy = data.aws_iam_policy_document.assume_lambda.json
+  tags               = local.tags
+}
+
+#
+# IAM Role for the `multipart` Lambda function.
+#
+# This role is for the function that completes and aborts multipart uploads.
+#
+resource "aws_iam_role" "lambda_multipart" {
+  name               = "${local.name}-lambda-multipart"
+  assume_role_policy = data.aws_iam_policy_document.assume_lambda.json
+  tags               = local.tags
+}
+
 #
 # IAM Role for the `indexer` Lambda function.
 #
@@ -60,6 +126,17 @@ resource "aws_iam_role" "lambda_indexer" {
   tags               = local.tags
 }
 
+#
+# IAM Role for the `reaper` Lambda function.
+#
+# This role is for the scheduled function that expires stale uploads.
+#
+resource "aws_iam_role" "lambda_reaper" {
+  name               = "${local.name}-lambda-reaper"
+  assume_role_policy = data.aws_iam_policy_document.assume_lambda.json
+  tags               = local.tags
+}
+
 ##################################
 # Managed Policy Attachments
 ##################################
@@ -80,11 +157,46 @@ resource "aws_iam_role_policy_attachment" "vpc_list" {
   policy_arn = "arn:${data.aws_partition.current.partition}:iam::aws:policy/service-role/AWSLambdaVPCAccessExecutionRole"
 }
 
+resource "aws_iam_role_policy_attachment" "vpc_search" {
+  role       = aws_iam_role.lambda_search.name
+  policy_arn = "arn:${data.aws_partition.current.partition}:iam::aws:policy/service-role/AWSLambdaVPCAccessExecutionRole"
+}
+
+resource "aws_iam_role_policy_attachment" "vpc_get" {
+  role       = aws_iam_role.lambda_get.name
+  policy_arn = "arn:${data.aws_partition.current.partition}:iam::aws:policy/service-role/AWSLambdaVPCAccessExecutionRole"
+}
+
+resource "aws_iam_role_policy_attachment" "vpc_download" {
+  role       = aws_iam_role.lambda_download.name
+  policy_arn = "arn:${data.aws_partition.current.partition}:iam::aws:policy/service-role/AWSLambdaVPCAccessExecutionRole"
+}
+
+resource "aws_iam_role_policy_attachment" "vpc_delete" {
+  role       = aws_iam_role.lambda_delete.name
+  policy_arn = "arn:${data.aws_partition.current.partition}:iam::aws:policy/service-role/AWSLambdaVPCAccessExecutionRole"
+}
+
+resource "aws_iam_role_policy_attachment" "vpc_review" {
+  role       = aws_iam_role.lambda_review.name
+  policy_arn = "arn:${data.aws_partition.current.partition}:iam::aws:policy/service-role/AWSLambdaVPCAccessExecutionRole"
+}
+
+resource "aws_iam_role_policy_attachment" "vpc_multipart" {
+  role       = aws_iam_role.lambda_multipart.name
+  policy_arn = "arn:${data.aws_partition.current.partition}:iam::aws:policy/service-role/AWSLambdaVPCAccessExecutionRole"
+}
+
 resource "aws_iam_role_policy_attachment" "vpc_indexer" {
   role       = aws_iam_role.lambda_indexer.name
   policy_arn = "arn:${data.aws_partition.current.partition}:iam::aws:policy/service-role/AWSLambdaVPCAccessExecutionRole"
 }
 
+resource "aws_iam_role_policy_attachment" "vpc_reaper" {
+  role       = aws_iam_role.lambda_reaper.name
+  policy_arn = "arn:${data.aws_partition.current.partition}:iam::aws:policy/service-role/AWSLambdaVPCAccessExecutionRole"
+}
+
 resource "aws_iam_role_policy_attachment" "xray_presign" {
   count      = var.enable_xray ? 1 : 0
   role       = aws_iam_role.lambda_presign.name
@@ -97,12 +209,54 @@ resource "aws_iam_role_policy_attachment" "xray_list" {
   policy_arn = "arn:${data.aws_partition.current.partition}:iam::aws:policy/AWSXRayDaemonWriteAccess"
 }
 
+resource "aws_iam_role_policy_attachment" "xray_search" {
+  count      = var.enable_xray ? 1 : 0
+  role       = aws_iam_role.lambda_search.name
+  policy_arn = "arn:${data.aws_partition.current.partition}:iam::aws:policy/AWSXRayDaemonWriteAccess"
+}
+
+resource "aws_iam_role_policy_attachment" "xray_get" {
+  count      = var.enable_xray ? 1 : 0
+  role       = aws_iam_role.lambda_get.name
+  policy_arn = "arn:${data.aws_partition.current.partition}:iam::aws:policy/AWSXRayDaemonWriteAccess"
+}
+
+resource "aws_iam_role_policy_attachment" "xray_download" {
+  count      = var.enable_xray ? 1 : 0
+  role       = aws_iam_role.lambda_download.name
+  policy_arn = "arn:${data.aws_partition.current.partition}:iam::aws:policy/AWSXRayDaemonWriteAccess"
+}
+
+resource "aws_iam_role_policy_attachment" "xray_delete" {
+  count      = var.enable_xray ? 1 : 0
+  role       = aws_iam_role.lambda_delete.name
+  policy_arn = "arn:${data.aws_partition.current.partition}:iam::aws:policy/AWSXRayDaemonWriteAccess"
+}
+
+resource "aws_iam_role_policy_attachment" "xray_review" {
+  count      = var.enable_xray ? 1 : 0
+  role       = aws_iam_role.lambda_review.name
+  policy_arn = "arn:${data.aws_partition.current.partition}:iam::aws:policy/AWSXRayDaemonWriteAccess"
+}
+
+resource "aws_iam_role_policy_attachment" "xray_multipart" {
+  count      = var.enable_xray ? 1 : 0
+  role       = aws_iam_role.lambda_multipart.name
+  policy_arn = "arn:${data.aws_partition.current.partition}:iam::aws:policy/AWSXRayDaemonWriteAccess"
+}
+
 resource "aws_iam_role_policy_attachment" "xray_indexer" {
   count      = var.enable_xray ? 1 : 0
   role       = aws_iam_role.lambda_indexer.name
   policy_arn = "arn:${data.aws_partition.current.partition}:iam::aws:policy/AWSXRayDaemonWriteAccess"
 }
 
+resource "aws_iam_role_policy_attachment" "xray_reaper" {
+  count      = var.enable_xray ? 1 : 0
+  role       = aws_iam_role.lambda_reaper.name
+  policy_arn = "arn:${data.aws_partition.current.partition}:iam::aws:policy/AWSXRayDaemonWriteAccess"
+}
+
 ##################################
 # Fine-Grained Inline Policies
 ##################################
@@ -110,19 +264,27 @@ resource "aws_iam_role_policy_attachment" "xray_indexer" {
 #
 # Data source for the `presign` Lambda's policy document.
 #
-# This policy grants permissions to write to DynamoDB, upload objects to a
-# specific S3 path, and use KMS keys for encryption.
+# This policy grants permissions to write to DynamoDB, append to the audit
+# table, upload objects to a specific S3 path (s3:PutObject also covers
+# multipart create/part/complete), abort multipart uploads it started, and use
+# KMS keys for encryption.
 #
 data "aws_iam_policy_document" "presign" {
   statement {
     sid       = "DDBWrite"
-    actions   = ["dynamodb:PutItem", "dynamodb:UpdateItem"]
+    actions   = ["dynamodb:PutItem", "dynamodb:UpdateItem", "dynamodb:GetItem"]
     resources = [aws_dynamodb_table.claims.arn]
   }
 
+  statement {
+    sid       = "DDBAuditAppend"
+    actions   = ["dynamodb:PutItem"]
+    resources = [aws_dynamodb_table.audit.arn]
+  }
+
   statement {
     sid       = "S3PutForPresign"
-    actions   = ["s3:PutObject", "s3:PutObjectTagging"]
+    actions   = ["s3:PutObject", "s3:PutObjectTagging", "s3:AbortMultipartUpload"]
     resources = ["${aws_s3_bucket.claims.arn}/user/*/*"]
   }
 
@@ -155,6 +317,12 @@ data "aws_iam_policy_document" "list" {
     resources = [aws_dynamodb_table.claims.arn, "${aws_dynamodb_table.claims.arn}/index/*"]
   }
 
+  statement {
+    sid       = "DDBAuditRead"
+    actions   = ["dynamodb:Query"]
+    resources = [aws_dynamodb_table.audit.arn]
+  }
+
   statement {
     sid       = "KmsDecrypt"
     actions   = ["kms:Decrypt"]
@@ -171,6 +339,251 @@ resource "aws_iam_role_policy" "list" {
   policy = data.aws_iam_policy_document.list.json
 }
 
+#
+# Data source for the `search` Lambda's policy document.
+#
+# This policy grants read-only access to the search index shards and to the
+# claims it points at, along with decryption permissions for the KMS keys.
+#
+data "aws_iam_policy_document" "search" {
+  statement {
+    sid       = "DDBRead"
+    actions   = ["dynamodb:GetItem"]
+    resources = [aws_dynamodb_table.claims.arn]
+  }
+
+  statement {
+    sid       = "S3SearchRead"
+    actions   = ["s3:GetObject"]
+    resources = ["${aws_s3_bucket.claims.arn}/search/*"]
+  }
+
+  # Without ListBucket, S3 answers a missing shard (a user with nothing indexed
+  # yet) with 403 rather than 404. An s3:prefix condition would not help: a
+  # GetObject request carries no prefix, so the check would still fail.
+  statement {
+    sid       = "S3SearchList"
+    actions   = ["s3:ListBucket"]
+    resources = [aws_s3_bucket.claims.arn]
+  }
+
+  statement {
+    sid       = "KmsDecrypt"
+    actions   = ["kms:Decrypt"]
+    resources = [aws_kms_key.ddb.arn, aws_kms_key.s3.arn]
+  }
+}
+
+#
+# Attaches the `search` policy to its IAM role.
+#
+resource "aws_iam_role_policy" "search" {
+  role   = aws_iam_role.lambda_search.id
+  name   = "${local.name}-search-inline"
+  policy = data.aws_iam_policy_document.search.json
+}
+
+#
+# Data source for the `get` Lambda's policy document.
+#
+# This policy grants read-only access to a claim, its attachments and its
+# audit trail, along with decryption permissions for the KMS key.
+#
+data "aws_iam_policy_document" "get" {
+  statement {
+    sid       = "DDBRead"
+    actions   = ["dynamodb:GetItem", "dynamodb:Query"]
+    resources = [aws_dynamodb_table.claims.arn]
+  }
+
+  statement {
+    sid       = "DDBAuditRead"
+    actions   = ["dynamodb:Query"]
+    resources = [aws_dynamodb_table.audit.arn]
+  }
+
+  statement {
+    sid       = "KmsDecrypt"
+    actions   = ["kms:Decrypt"]
+    resources = [aws_kms_key.ddb.arn]
+  }
+}
+
+#
+# Attaches the `get` policy to its IAM role.
+#
+resource "aws_iam_role_policy" "get" {
+  role   = aws_iam_role.lambda_get.id
+  name   = "${local.name}-get-inline"
+  policy = data.aws_iam_policy_document.get.json
+}
+
+#
+# Data source for the `download` Lambda's policy document.
+#
+# Presigned GET URLs carry this role's permissions, so it may read claim
+# documents, attachments and redacted copies (but not quarantined objects).
+#
+data "aws_iam_policy_document" "download" {
+  statement {
+    sid       = "DDBRead"
+    actions   = ["dynamodb:GetItem"]
+    resources = [aws_dynamodb_table.claims.arn]
+  }
+
+  statement {
+    sid       = "S3Read"
+    actions   = ["s3:GetObject"]
+    resources = ["${aws_s3_bucket.claims.arn}/user/*/*", "${aws_s3_bucket.claims.arn}/redacted/*"]
+  }
+
+  statement {
+    sid       = "KmsDecrypt"
+    actions   = ["kms:Decrypt"]
+    resources = [aws_kms_key.s3.arn, aws_kms_key.ddb.arn]
+  }
+}
+
+#
+# Attaches the `download` policy to its IAM role.
+#
+resource "aws_iam_role_policy" "download" {
+  role   = aws_iam_role.lambda_download.id
+  name   = "${local.name}-download-inline"
+  policy = data.aws_iam_policy_document.download.json
+}
+
+#
+# Data source for the `delete` Lambda's policy document.
+#
+# This policy grants permissions to withdraw a claim (with its audit event),
+# delete its objects, and rewrite the owner's search index shard.
+#
+data "aws_iam_policy_document" "delete" {
+  statement {
+    sid       = "DDBWrite"
+    actions   = ["dynamodb:UpdateItem", "dynamodb:GetItem", "dynamodb:Query"]
+    resources = [aws_dynamodb_table.claims.arn]
+  }
+
+  statement {
+    sid       = "DDBAuditAppend"
+    actions   = ["dynamodb:PutItem"]
+    resources = [aws_dynamodb_table.audit.arn]
+  }
+
+  statement {
+    sid       = "S3Delete"
+    actions   = ["s3:DeleteObject"]
+    resources = ["${aws_s3_bucket.claims.arn}/user/*/*", "${aws_s3_bucket.claims.arn}/redacted/*"]
+  }
+
+  statement {
+    sid       = "S3Search"
+    actions   = ["s3:GetObject", "s3:PutObject"]
+    resources = ["${aws_s3_bucket.claims.arn}/search/*"]
+  }
+
+  # Without ListBucket, S3 answers a missing shard with 403 rather than 404.
+  statement {
+    sid       = "S3SearchList"
+    actions   = ["s3:ListBucket"]
+    resources = [aws_s3_bucket.claims.arn]
+  }
+
+  statement {
+    sid       = "KmsOperations"
+    actions   = ["kms:Decrypt", "kms:GenerateDataKey"]
+    resources = [aws_kms_key.s3.arn, aws_kms_key.ddb.arn]
+  }
+}
+
+#
+# Attaches the `delete` policy to its IAM role.
+#
+resource "aws_iam_role_policy" "delete" {
+  role   = aws_iam_role.lambda_delete.id
+  name   = "${local.name}-delete-inline"
+  policy = data.aws_iam_policy_document.delete.json
+}
+
+#
+# Data source for the `review` Lambda's policy document.
+#
+# This policy grants permissions to update a claim's review fields and append
+# the matching audit events.
+#
+data "aws_iam_policy_document" "review" {
+  statement {
+    sid       = "DDBWrite"
+    actions   = ["dynamodb:UpdateItem", "dynamodb:GetItem"]
+    resources = [aws_dynamodb_table.claims.arn]
+  }
+
+  statement {
+    sid       = "DDBAuditAppend"
+    actions   = ["dynamodb:PutItem"]
+    resources = [aws_dynamodb_table.audit.arn]
+  }
+
+  statement {
+    sid       = "KmsOperations"
+    actions   = ["kms:Decrypt", "kms:GenerateDataKey"]
+    resources = [aws_kms_key.ddb.arn]
+  }
+}
+
+#
+# Attaches the `review` policy to its IAM role.
+#
+resource "aws_iam_role_policy" "review" {
+  role   = aws_iam_role.lambda_review.id
+  name   = "${local.name}-review-inline"
+  policy = data.aws_iam_policy_document.review.json
+}
+
+#
+# Data source for the `multipart` Lambda's policy document.
+#
+# This policy grants permissions to complete or abort the multipart uploads
+# `presign` started (s3:PutObject covers CompleteMultipartUpload) and to mark
+# an aborted claim FAILED.
+#
+data "aws_iam_policy_document" "multipart" {
+  statement {
+    sid       = "DDBWrite"
+    actions   = ["dynamodb:UpdateItem", "dynamodb:GetItem"]
+    resources = [aws_dynamodb_table.claims.arn]
+  }
+
+  statement {
+    sid       = "DDBAuditAppend"
+    actions   = ["dynamodb:PutItem"]
+    resources = [aws_dynamodb_table.audit.arn]
+  }
+
+  statement {
+    sid       = "S3Multipart"
+    actions   = ["s3:PutObject", "s3:AbortMultipartUpload"]
+    resources = ["${aws_s3_bucket.claims.arn}/user/*/*"]
+  }
+
+  statement {
+    sid       = "KmsOperations"
+    actions   = ["kms:Decrypt", "kms:GenerateDataKey"]
+    resources = [aws_kms_key.s3.arn, aws_kms_key.ddb.arn]
+  }
+}
+
+#
+# Attaches the `multipart` policy to its IAM role.
+#
+resource "aws_iam_role_policy" "multipart" {
+  role   = aws_iam_role.lambda_multipart.id
+  name   = "${local.name}-multipart-inline"
+  policy = data.aws_iam_policy_document.multipart.json
+}
+
 #
 # Data source for the `indexer` Lambda's policy document.
 #
@@ -180,16 +593,55 @@ resource "aws_iam_role_policy" "list" {
 data "aws_iam_policy_document" "indexer" {
   statement {
     sid       = "DDBWrite"
-    actions   = ["dynamodb:UpdateItem", "dynamodb:PutItem"]
+    actions   = ["dynamodb:UpdateItem", "dynamodb:PutItem", "dynamodb:Query", "dynamodb:GetItem"]
     resources = [aws_dynamodb_table.claims.arn]
   }
 
+  statement {
+    sid       = "DDBAuditAppend"
+    actions   = ["dynamodb:PutItem"]
+    resources = [aws_dynamodb_table.audit.arn]
+  }
+
   statement {
     sid       = "S3Read"
     actions   = ["s3:GetObject", "s3:HeadObject", "s3:GetObjectTagging"]
     resources = ["${aws_s3_bucket.claims.arn}/user/*/*"]
   }
 
+  statement {
+    sid       = "S3Quarantine"
+    actions   = ["s3:PutObject", "s3:DeleteObject"]
+    resources = ["${aws_s3_bucket.claims.arn}/quarantine/*", "${aws_s3_bucket.claims.arn}/user/*/*"]
+  }
+
+  statement {
+    sid       = "SQSConsume"
+    actions   = ["sqs:ReceiveMessage", "sqs:DeleteMessage", "sqs:GetQueueAttributes", "sqs:ChangeMessageVisibility"]
+    resources = [aws_sqs_queue.indexer.arn]
+  }
+
+  statement {
+    sid       = "S3Redacted"
+    actions   = ["s3:PutObject"]
+    resources = ["${aws_s3_bucket.claims.arn}/redacted/*"]
+  }
+
+  statement {
+    sid       = "S3Search"
+    actions   = ["s3:GetObject", "s3:PutObject"]
+    resources = ["${aws_s3_bucket.claims.arn}/search/*"]
+  }
+
+  # Without ListBucket, S3 answers a missing shard (a user with nothing indexed
+  # yet) with 403 rather than 404. An s3:prefix condition would not help: a
+  # GetObject request carries no prefix, so the check would still fail.
+  statement {
+    sid       = "S3SearchList"
+    actions   = ["s3:ListBucket"]
+    resources = [aws_s3_bucket.claims.arn]
+  }
+
   statement {
     sid       = "KmsOperations"
     actions   = ["kms:Decrypt", "kms:GenerateDataKey"]
@@ -206,6 +658,41 @@ resource "aws_iam_role_policy" "indexer" {
   policy = data.aws_iam_policy_document.indexer.json
 }
 
+#
+# Data source for the `reaper` Lambda's policy document.
+#
+# This policy grants permissions to find stale uploads through the status
+# index, mark them FAILED with an audit event, and roll up attachment status.
+#
+data "aws_iam_policy_document" "reaper" {
+  statement {
+    sid       = "DDBWrite"
+    actions   = ["dynamodb:Query", "dynamodb:UpdateItem", "dynamodb:GetItem"]
+    resources = [aws_dynamodb_table.claims.arn, "${aws_dynamodb_table.claims.arn}/index/*"]
+  }
+
+  statement {
+    sid       = "DDBAuditAppend"
+    actions   = ["dynamodb:PutItem"]
+    resources = [aws_dynamodb_table.audit.arn]
+  }
+
+  statement {
+    sid       = "KmsOperations"
+    actions   = ["kms:Decrypt", "kms:GenerateDataKey"]
+    resources = [aws_kms_key.ddb.arn]
+  }
+}
+
+#
+# Attaches the `reaper` policy to its IAM role.
+#
+resource "aws_iam_role_policy" "reaper" {
+  role   = aws_iam_role.lambda_reaper.id
+  name   = "${local.name}-reaper-inline"
+  policy = data.aws_iam_policy_document.reaper.json
+}
+
 ##################################
 # API Gateway CloudWatch Logs Role
 ##################################
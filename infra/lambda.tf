@@ -14,9 +14,16 @@
 # the image tag. This is a robust pattern for managing container images.
 #
 locals {
-  presign_image_uri = var.presign_image_digest != "" ? "${aws_ecr_repository.api_presign.repository_url}@${var.presign_image_digest}" : "${aws_ecr_repository.api_presign.repository_url}:${var.image_tag_api_presign}"
-  list_image_uri    = var.list_image_digest != "" ? "${aws_ecr_repository.api_list.repository_url}@${var.list_image_digest}" : "${aws_ecr_repository.api_list.repository_url}:${var.image_tag_api_list}"
-  indexer_image_uri = var.indexer_image_digest != "" ? "${aws_ecr_repository.indexer.repository_url}@${var.indexer_image_digest}" : "${aws_ecr_repository.indexer.repository_url}:${var.image_tag_indexer}"
+  presign_image_uri   = var.presign_image_digest != "" ? "${aws_ecr_repository.api_presign.repository_url}@${var.presign_image_digest}" : "${aws_ecr_repository.api_presign.repository_url}:${var.image_tag_api_presign}"
+  list_image_uri      = var.list_image_digest != "" ? "${aws_ecr_repository.api_list.repository_url}@${var.list_image_digest}" : "${aws_ecr_repository.api_list.repository_url}:${var.image_tag_api_list}"
+  search_image_uri    = var.search_image_digest != "" ? "${aws_ecr_repository.api_search.repository_url}@${var.search_image_digest}" : "${aws_ecr_repository.api_search.repository_url}:${var.image_tag_api_search}"
+  get_image_uri       = var.get_image_digest != "" ? "${aws_ecr_repository.api_get.repository_url}@${var.get_image_digest}" : "${aws_ecr_repository.api_get.repository_url}:${var.image_tag_api_get}"
+  download_image_uri  = var.download_image_digest != "" ? "${aws_ecr_repository.api_download.repository_url}@${var.download_image_digest}" : "${aws_ecr_repository.api_download.repository_url}:${var.image_tag_api_download}"
+  delete_image_uri    = var.delete_image_digest != "" ? "${aws_ecr_repository.api_delete.repository_url}@${var.delete_image_digest}" : "${aws_ecr_repository.api_delete.repository_url}:${var.image_tag_api_delete}"
+  review_image_uri    = var.review_image_digest != "" ? "${aws_ecr_repository.api_review.repository_url}@${var.review_image_digest}" : "${aws_ecr_repository.api_review.repository_url}:${var.image_tag_api_review}"
+  multipart_image_uri = var.multipart_image_digest != "" ? "${aws_ecr_repository.api_multipart.repository_url}@${var.multipart_image_digest}" : "${aws_ecr_repository.api_multipart.repository_url}:${var.image_tag_api_multipart}"
+  indexer_image_uri   = var.indexer_image_digest != "" ? "${aws_ecr_repository.indexer.repository_url}@${var.indexer_image_digest}" : "${aws_ecr_repository.indexer.repository_url}:${var.image_tag_indexer}"
+  reaper_image_uri    = var.reaper_image_digest != "" ? "${aws_ecr_repository.reaper.repository_url}@${var.reaper_image_digest}" : "${aws_ecr_repository.reaper.repository_url}:${var.image_tag_reaper}"
 }
 
 ##################################
@@ -51,10 +58,16 @@ resource "aws_lambda_function" "api_presign" {
 
   environment {
     variables = {
-      DDB_TABLE       = aws_dynamodb_table.claims.name
-      S3_BUCKET       = aws_s3_bucket.claims.bucket
-      KMS_KEY         = aws_kms_key.s3.arn
-      FRONTEND_ORIGIN = local.amplify_origin
+      DDB_TABLE             = aws_dynamodb_table.claims.name
+      AUDIT_TABLE           = aws_dynamodb_table.audit.name
+      S3_BUCKET             = aws_s3_bucket.claims.bucket
+      KMS_KEY               = aws_kms_key.s3.arn
+      FRONTEND_ORIGIN       = local.amplify_origin
+      COGNITO_USER_POOL_ID  = aws_cognito_user_pool.this.id
+      COGNITO_CLIENT_ID     = aws_cognito_user_pool_client.this.id
+      UPLOAD_MODE           = "post"
+      MAX_UPLOAD_BYTES      = var.max_upload_bytes
+      ALLOWED_CONTENT_TYPES = join(",", var.allowed_content_types)
     }
   }
 }
@@ -87,9 +100,233 @@ resource "aws_lambda_function" "api_list" {
   environment {
     variables = {
       DDB_TABLE       = aws_dynamodb_table.claims.name
+      AUDIT_TABLE     = aws_dynamodb_table.audit.name
       S3_BUCKET       = aws_s3_bucket.claims.bucket
-      KMS_KEY         = aws_kms_key.ddb.arn
-      FRONTEND_ORIGIN = local.amplify_origin
+      KMS_KEY              = aws_kms_key.ddb.arn
+      FRONTEND_ORIGIN      = local.amplify_origin
+      CURSOR_SECRET        = random_password.cursor_secret.result
+      COGNITO_USER_POOL_ID = aws_cognito_user_pool.this.id
+      COGNITO_CLIENT_ID    = aws_cognito_user_pool_client.this.id
+    }
+  }
+}
+
+#
+# Lambda function for the `search` API endpoint.
+#
+# This function answers full-text queries from the per-user index shards under
+# `search/` in the claims bucket, then re-reads each hit from DynamoDB so
+# withdrawn claims are dropped.
+#
+resource "aws_lambda_function" "api_search" {
+  function_name = "${local.name}-api-search"
+  package_type  = "Image"
+  image_uri     = local.search_image_uri
+  role          = aws_iam_role.lambda_search.arn
+  timeout       = 10
+  memory_size   = 256
+  tags          = local.tags
+
+  tracing_config {
+    mode = var.enable_xray ? "Active" : "PassThrough"
+  }
+
+  vpc_config {
+    subnet_ids         = [aws_subnet.private_a.id, aws_subnet.private_b.id]
+    security_group_ids = [aws_security_group.lambda_search.id]
+  }
+
+  environment {
+    variables = {
+      DDB_TABLE            = aws_dynamodb_table.claims.name
+      AUDIT_TABLE          = aws_dynamodb_table.audit.name
+      S3_BUCKET            = aws_s3_bucket.claims.bucket
+      KMS_KEY              = aws_kms_key.ddb.arn
+      FRONTEND_ORIGIN      = local.amplify_origin
+      COGNITO_USER_POOL_ID = aws_cognito_user_pool.this.id
+      COGNITO_CLIENT_ID    = aws_cognito_user_pool_client.this.id
+    }
+  }
+}
+
+#
+# Lambda function for the `get` API endpoints.
+#
+# This function returns a single claim with its attachments
+# (`GET /claims/{id}`) and the claim's audit trail (`GET /claims/{id}/history`).
+#
+resource "aws_lambda_function" "api_get" {
+  function_name = "${local.name}-api-get"
+  package_type  = "Image"
+  image_uri     = local.get_image_uri
+  role          = aws_iam_role.lambda_get.arn
+  timeout       = 10
+  memory_size   = 256
+  tags          = local.tags
+
+  tracing_config {
+    mode = var.enable_xray ? "Active" : "PassThrough"
+  }
+
+  vpc_config {
+    subnet_ids         = [aws_subnet.private_a.id, aws_subnet.private_b.id]
+    security_group_ids = [aws_security_group.lambda_get.id]
+  }
+
+  environment {
+    variables = {
+      DDB_TABLE            = aws_dynamodb_table.claims.name
+      AUDIT_TABLE          = aws_dynamodb_table.audit.name
+      S3_BUCKET            = aws_s3_bucket.claims.bucket
+      FRONTEND_ORIGIN      = local.amplify_origin
+      COGNITO_USER_POOL_ID = aws_cognito_user_pool.this.id
+      COGNITO_CLIENT_ID    = aws_cognito_user_pool_client.this.id
+    }
+  }
+}
+
+#
+# Lambda function for the `download` API endpoint.
+#
+# This function presigns short-lived GET URLs for a complete claim, one of its
+# attachments, or the claim's PII-redacted copy. The URL is signed with this
+# function's role, so the role needs read access to the objects themselves.
+#
+resource "aws_lambda_function" "api_download" {
+  function_name = "${local.name}-api-download"
+  package_type  = "Image"
+  image_uri     = local.download_image_uri
+  role          = aws_iam_role.lambda_download.arn
+  timeout       = 10
+  memory_size   = 256
+  tags          = local.tags
+
+  tracing_config {
+    mode = var.enable_xray ? "Active" : "PassThrough"
+  }
+
+  vpc_config {
+    subnet_ids         = [aws_subnet.private_a.id, aws_subnet.private_b.id]
+    security_group_ids = [aws_security_group.lambda_download.id]
+  }
+
+  environment {
+    variables = {
+      DDB_TABLE            = aws_dynamodb_table.claims.name
+      AUDIT_TABLE          = aws_dynamodb_table.audit.name
+      S3_BUCKET            = aws_s3_bucket.claims.bucket
+      FRONTEND_ORIGIN      = local.amplify_origin
+      COGNITO_USER_POOL_ID = aws_cognito_user_pool.this.id
+      COGNITO_CLIENT_ID    = aws_cognito_user_pool_client.this.id
+      DOWNLOAD_TTL_SECONDS = 60
+    }
+  }
+}
+
+#
+# Lambda function for the `delete` API endpoint.
+#
+# This function withdraws a claim, deletes its objects (document, redacted copy
+# and attachments) and removes it from the search index.
+#
+resource "aws_lambda_function" "api_delete" {
+  function_name = "${local.name}-api-delete"
+  package_type  = "Image"
+  image_uri     = local.delete_image_uri
+  role          = aws_iam_role.lambda_delete.arn
+  timeout       = 10
+  memory_size   = 256
+  tags          = local.tags
+
+  tracing_config {
+    mode = var.enable_xray ? "Active" : "PassThrough"
+  }
+
+  vpc_config {
+    subnet_ids         = [aws_subnet.private_a.id, aws_subnet.private_b.id]
+    security_group_ids = [aws_security_group.lambda_delete.id]
+  }
+
+  environment {
+    variables = {
+      DDB_TABLE            = aws_dynamodb_table.claims.name
+      AUDIT_TABLE          = aws_dynamodb_table.audit.name
+      S3_BUCKET            = aws_s3_bucket.claims.bucket
+      FRONTEND_ORIGIN      = local.amplify_origin
+      COGNITO_USER_POOL_ID = aws_cognito_user_pool.this.id
+      COGNITO_CLIENT_ID    = aws_cognito_user_pool_client.this.id
+    }
+  }
+}
+
+#
+# Lambda function for the `review` API endpoints.
+#
+# This function records review decisions (`PATCH /claims/{id}/status`) and
+# vendor assignments (`PUT /claims/{id}/vendors`) for the claims team.
+#
+resource "aws_lambda_function" "api_review" {
+  function_name = "${local.name}-api-review"
+  package_type  = "Image"
+  image_uri     = local.review_image_uri
+  role          = aws_iam_role.lambda_review.arn
+  timeout       = 10
+  memory_size   = 256
+  tags          = local.tags
+
+  tracing_config {
+    mode = var.enable_xray ? "Active" : "PassThrough"
+  }
+
+  vpc_config {
+    subnet_ids         = [aws_subnet.private_a.id, aws_subnet.private_b.id]
+    security_group_ids = [aws_security_group.lambda_review.id]
+  }
+
+  environment {
+    variables = {
+      DDB_TABLE            = aws_dynamodb_table.claims.name
+      AUDIT_TABLE          = aws_dynamodb_table.audit.name
+      S3_BUCKET            = aws_s3_bucket.claims.bucket
+      FRONTEND_ORIGIN      = local.amplify_origin
+      COGNITO_USER_POOL_ID = aws_cognito_user_pool.this.id
+      COGNITO_CLIENT_ID    = aws_cognito_user_pool_client.this.id
+    }
+  }
+}
+
+#
+# Lambda function for the `multipart` API endpoints.
+#
+# This function completes (`POST /claims/{id}/multipart/complete`) and aborts
+# (`DELETE /claims/{id}/multipart`) multipart uploads started by `presign`.
+#
+resource "aws_lambda_function" "api_multipart" {
+  function_name = "${local.name}-api-multipart"
+  package_type  = "Image"
+  image_uri     = local.multipart_image_uri
+  role          = aws_iam_role.lambda_multipart.arn
+  timeout       = 10
+  memory_size   = 256
+  tags          = local.tags
+
+  tracing_config {
+    mode = var.enable_xray ? "Active" : "PassThrough"
+  }
+
+  vpc_config {
+    subnet_ids         = [aws_subnet.private_a.id, aws_subnet.private_b.id]
+    security_group_ids = [aws_security_group.lambda_multipart.id]
+  }
+
+  environment {
+    variables = {
+      DDB_TABLE            = aws_dynamodb_table.claims.name
+      AUDIT_TABLE          = aws_dynamodb_table.audit.name
+      S3_BUCKET            = aws_s3_bucket.claims.bucket
+      FRONTEND_ORIGIN      = local.amplify_origin
+      COGNITO_USER_POOL_ID = aws_cognito_user_pool.this.id
+      COGNITO_CLIENT_ID    = aws_cognito_user_pool_client.this.id
     }
   }
 }
@@ -97,15 +334,16 @@ resource "aws_lambda_function" "api_list" {
 #
 # Lambda function for the `indexer` service.
 #
-# This function is triggered by S3 object creation events. It processes the
-# uploaded file and indexes its contents in DynamoDB.
+# This function consumes S3 object creation events from SQS. It verifies and
+# malware-scans the uploaded file and indexes it in DynamoDB; flagged files
+# are moved under `quarantine/`.
 #
 resource "aws_lambda_function" "indexer" {
   function_name = "${local.name}-indexer"
   package_type  = "Image"
   image_uri     = local.indexer_image_uri
   role          = aws_iam_role.lambda_indexer.arn
-  timeout       = 20
+  timeout       = 120
   memory_size   = 256
   tags          = local.tags
 
@@ -120,12 +358,64 @@ resource "aws_lambda_function" "indexer" {
 
   environment {
     variables = {
-      DDB_TABLE = aws_dynamodb_table.claims.name
-      S3_BUCKET = aws_s3_bucket.claims.bucket
+      DDB_TABLE             = aws_dynamodb_table.claims.name
+      AUDIT_TABLE           = aws_dynamodb_table.audit.name
+      S3_BUCKET             = aws_s3_bucket.claims.bucket
+      MAX_UPLOAD_BYTES      = var.max_upload_bytes
+      ALLOWED_CONTENT_TYPES = join(",", var.allowed_content_types)
+      SCANNER               = "clamav"
+      CLAMAV_ADDR           = var.clamav_addr
+      SCAN_TIMEOUT_SECONDS  = 90
+      SCAN_MAX_BYTES        = var.scan_max_bytes
     }
   }
 }
 
+#
+# Lambda function for the `reaper` service.
+#
+# This function runs on a schedule (see the EventBridge rule below) and marks
+# claims still UPLOADING well past their presign TTL as FAILED.
+#
+resource "aws_lambda_function" "reaper" {
+  function_name = "${local.name}-reaper"
+  package_type  = "Image"
+  image_uri     = local.reaper_image_uri
+  role          = aws_iam_role.lambda_reaper.arn
+  timeout       = 60
+  memory_size   = 256
+  tags          = local.tags
+
+  tracing_config {
+    mode = var.enable_xray ? "Active" : "PassThrough"
+  }
+
+  vpc_config {
+    subnet_ids         = [aws_subnet.private_a.id, aws_subnet.private_b.id]
+    security_group_ids = [aws_security_group.lambda_reaper.id]
+  }
+
+  environment {
+    variables = {
+      DDB_TABLE            = aws_dynamodb_table.claims.name
+      AUDIT_TABLE          = aws_dynamodb_table.audit.name
+      S3_BUCKET            = aws_s3_bucket.claims.bucket
+      REAPER_GRACE_SECONDS = 900
+    }
+  }
+}
+
+#
+# HMAC key for signing `list` pagination cursors.
+#
+# Cursors are opaque to clients; rotating this value simply invalidates any
+# outstanding `next_cursor` tokens.
+#
+resource "random_password" "cursor_secret" {
+  length  = 48
+  special = false
+}
+
 ##################################
 # CloudWatch Log Groups
 ##################################
@@ -146,47 +436,97 @@ resource "aws_cloudwatch_log_group" "lg_list" {
   retention_in_days = 30
 }
 
+resource "aws_cloudwatch_log_group" "lg_search" {
+  name              = "/aws/lambda/${aws_lambda_function.api_search.function_name}"
+  retention_in_days = 30
+}
+
+resource "aws_cloudwatch_log_group" "lg_get" {
+  name              = "/aws/lambda/${aws_lambda_function.api_get.function_name}"
+  retention_in_days = 30
+}
+
+resource "aws_cloudwatch_log_group" "lg_download" {
+  name              = "/aws/lambda/${aws_lambda_function.api_download.function_name}"
+  retention_in_days = 30
+}
+
+resource "aws_cloudwatch_log_group" "lg_delete" {
+  name              = "/aws/lambda/${aws_lambda_function.api_delete.function_name}"
+  retention_in_days = 30
+}
+
+resource "aws_cloudwatch_log_group" "lg_review" {
+  name              = "/aws/lambda/${aws_lambda_function.api_review.function_name}"
+  retention_in_days = 30
+}
+
+resource "aws_cloudwatch_log_group" "lg_multipart" {
+  name              = "/aws/lambda/${aws_lambda_function.api_multipart.function_name}"
+  retention_in_days = 30
+}
+
 resource "aws_cloudwatch_log_group" "lg_indexer" {
   name              = "/aws/lambda/${aws_lambda_function.indexer.function_name}"
   retention_in_days = 30
 }
 
+resource "aws_cloudwatch_log_group" "lg_reaper" {
+  name              = "/aws/lambda/${aws_lambda_function.reaper.function_name}"
+  retention_in_days = 30
+}
+
 ##################################
-# S3 Event Trigger for Indexer
+# Schedule for Reaper
 ##################################
 
 #
-# Lambda permission for S3 to invoke the indexer function.
+# EventBridge rule that runs the reaper every 15 minutes.
 #
-# This permission is a critical security step, allowing the S3 bucket to
-# trigger the `indexer` Lambda function when a new object is created.
+# Each run only expires claims older than the presign TTL plus
+# REAPER_GRACE_SECONDS, so the rate only bounds how long a stale claim lingers.
 #
-resource "aws_lambda_permission" "allow_s3_invoke" {
-  statement_id  = "AllowS3Invoke"
+resource "aws_cloudwatch_event_rule" "reaper" {
+  name                = "${local.name}-reaper"
+  description         = "Expire claims whose uploads never arrived."
+  schedule_expression = "rate(15 minutes)"
+  tags                = local.tags
+}
+
+resource "aws_cloudwatch_event_target" "reaper" {
+  rule = aws_cloudwatch_event_rule.reaper.name
+  arn  = aws_lambda_function.reaper.arn
+}
+
+resource "aws_lambda_permission" "reaper_schedule" {
+  statement_id  = "AllowEventBridgeInvoke"
   action        = "lambda:InvokeFunction"
-  function_name = aws_lambda_function.indexer.function_name
-  principal     = "s3.amazonaws.com"
-  source_arn    = aws_s3_bucket.claims.arn
+  function_name = aws_lambda_function.reaper.function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.reaper.arn
 }
 
+##################################
+# S3 Event Trigger for Indexer
+##################################
+
 #
 # S3 bucket notification configuration.
 #
-# This resource configures the S3 bucket to send an event to the `indexer`
-# Lambda function whenever an object is created in the `user/` prefix with
-# a `.txt` suffix.
+# This resource configures the S3 bucket to send an event to the indexer
+# queue (see sqs.tf) whenever an object is created in the `user/` prefix. The
+# indexer checks the extension and content against the allowlist itself.
 #
 resource "aws_s3_bucket_notification" "claims" {
   bucket = aws_s3_bucket.claims.id
 
-  lambda_function {
-    lambda_function_arn = aws_lambda_function.indexer.arn
-    events              = ["s3:ObjectCreated:*"]
-    filter_prefix       = "user/"
-    filter_suffix       = ".txt"
+  queue {
+    queue_arn     = aws_sqs_queue.indexer.arn
+    events        = ["s3:ObjectCreated:*"]
+    filter_prefix = "user/"
   }
 
-  # Explicit dependency to ensure the permission is in place before the
-  # notification is configured.
-  depends_on = [aws_lambda_permission.allow_s3_invoke]
+  # The queue policy must allow S3 to publish before the notification is
+  # configured, or S3 rejects the configuration.
+  depends_on = [aws_sqs_queue_policy.indexer]
 }
\ No newline at end of file
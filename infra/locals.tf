@@ -18,6 +18,7 @@ locals {
   # Resource-specific names, following the naming convention.
   bucket_claims = "${var.project}-artifacts-${var.env}"
   table_claims  = "claims_${var.env}"
+  table_audit   = "claims_audit_${var.env}"
 
   # Standard tags applied to all resources.
   tags = {
@@ -21,8 +21,17 @@ output "api_base_url" {
 
 output "api_endpoints" {
   value = {
-    list_claims    = "${aws_api_gateway_stage.prod.invoke_url}/claims"
-    presign_upload = "${aws_api_gateway_stage.prod.invoke_url}/claims/presign"
+    list_claims        = "${aws_api_gateway_stage.prod.invoke_url}/claims"
+    presign_upload     = "${aws_api_gateway_stage.prod.invoke_url}/claims/presign"
+    search_claims      = "${aws_api_gateway_stage.prod.invoke_url}/claims/search"
+    get_claim          = "${aws_api_gateway_stage.prod.invoke_url}/claims/{id}"
+    claim_history      = "${aws_api_gateway_stage.prod.invoke_url}/claims/{id}/history"
+    download_claim     = "${aws_api_gateway_stage.prod.invoke_url}/claims/{id}/download"
+    review_status      = "${aws_api_gateway_stage.prod.invoke_url}/claims/{id}/status"
+    assign_vendors     = "${aws_api_gateway_stage.prod.invoke_url}/claims/{id}/vendors"
+    presign_attachment = "${aws_api_gateway_stage.prod.invoke_url}/claims/{id}/attachments"
+    complete_multipart = "${aws_api_gateway_stage.prod.invoke_url}/claims/{id}/multipart/complete"
+    abort_multipart    = "${aws_api_gateway_stage.prod.invoke_url}/claims/{id}/multipart"
   }
   description = "The specific URLs for API endpoints."
 }
@@ -89,4 +98,19 @@ output "s3_bucket" {
 output "ddb_table" {
   value       = aws_dynamodb_table.claims.name
   description = "The name of the DynamoDB table."
-}
\ No newline at end of file
+}
+#
+# Indexer Queue Outputs.
+#
+# The indexer's event queue and its dead-letter queue, for monitoring and for
+# inspecting or replaying events that repeatedly failed.
+#
+output "indexer_queue_url" {
+  value       = aws_sqs_queue.indexer.id
+  description = "The URL of the SQS queue feeding the indexer."
+}
+
+output "indexer_dlq_url" {
+  value       = aws_sqs_queue.indexer_dlq.id
+  description = "The URL of the indexer's dead-letter queue."
+}
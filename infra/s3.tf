@@ -70,6 +70,30 @@ resource "aws_s3_bucket_server_side_encryption_configuration" "claims" {
   }
 }
 
+#
+# S3 Bucket Lifecycle Configuration.
+#
+# Multipart uploads that are never completed or aborted keep their parts (and
+# storage cost) indefinitely. This rule discards them after a day, well past
+# the presign TTL plus the reaper grace period.
+#
+resource "aws_s3_bucket_lifecycle_configuration" "claims" {
+  bucket = aws_s3_bucket.claims.id
+
+  rule {
+    id     = "abort-incomplete-multipart"
+    status = "Enabled"
+
+    filter {
+      prefix = "user/"
+    }
+
+    abort_incomplete_multipart_upload {
+      days_after_initiation = 1
+    }
+  }
+}
+
 #
 # S3 Bucket CORS (Cross-Origin Resource Sharing) Configuration.
 #
@@ -81,7 +105,7 @@ resource "aws_s3_bucket_cors_configuration" "claims" {
   bucket = aws_s3_bucket.claims.id
 
   cors_rule {
-    allowed_methods = ["PUT", "HEAD", "GET"]
+    allowed_methods = ["PUT", "POST", "HEAD", "GET"]
     allowed_origins = [local.amplify_origin]
     allowed_headers = ["*"]
     expose_headers  = ["ETag", "x-amz-checksum-crc64nvme", "x-amz-server-side-encryption", "x-amz-version-id"]
@@ -98,7 +122,15 @@ resource "aws_s3_bucket_cors_configuration" "claims" {
 #    non-HTTPS connections.
 # 2. **Enforce KMS:** Denies `s3:PutObject` requests unless the `aws:kms`
 #    server-side encryption header is present, enforcing client-side
-#    encryption or an explicit decision to use the KMS key.
+#    encryption or an explicit decision to use the KMS key. This also covers
+#    CreateMultipartUpload, which `presign` always sends with the header.
+#    `UploadPart` and `CompleteMultipartUpload` are authorized as
+#    `s3:PutObject` too but never carry the header (encryption is fixed when the
+#    upload is created), and a bucket policy cannot tell them apart from a
+#    plain PutObject. So a missing header is tolerated only from the two roles
+#    that issue them: `presign`, which signs the part URLs (its single-part PUT
+#    URLs and POST policies always sign the header), and `multipart`, which
+#    completes uploads. A header naming any other algorithm is always denied.
 #
 data "aws_iam_policy_document" "claims_bucket_policy" {
   statement {
@@ -131,11 +163,35 @@ data "aws_iam_policy_document" "claims_bucket_policy" {
     }
 
     condition {
-      test     = "StringNotEquals"
+      test     = "StringNotEqualsIfExists"
       variable = "s3:x-amz-server-side-encryption"
       values   = ["aws:kms"]
     }
   }
+
+  statement {
+    sid       = "RequireKmsHeader"
+    effect    = "Deny"
+    actions   = ["s3:PutObject"]
+    resources = ["${aws_s3_bucket.claims.arn}/*"]
+
+    principals {
+      type        = "*"
+      identifiers = ["*"]
+    }
+
+    condition {
+      test     = "Null"
+      variable = "s3:x-amz-server-side-encryption"
+      values   = ["true"]
+    }
+
+    condition {
+      test     = "ArnNotEquals"
+      variable = "aws:PrincipalArn"
+      values   = [aws_iam_role.lambda_presign.arn, aws_iam_role.lambda_multipart.arn]
+    }
+  }
 }
 
 resource "aws_s3_bucket_policy" "claims" {
@@ -54,6 +54,28 @@ resource "aws_security_group" "lambda_list" {
   tags = merge(local.tags, { Name = "${local.name}-lambda-list-sg" })
 }
 
+#
+# Security Group for the `search` Lambda function.
+#
+# Like the `list` Lambda, it only needs outbound HTTPS to reach S3 and
+# DynamoDB.
+#
+resource "aws_security_group" "lambda_search" {
+  name        = "${local.name}-lambda-search-sg"
+  description = "Security group for search Lambda."
+  vpc_id      = aws_vpc.this.id
+
+  egress {
+    from_port   = 443
+    to_port     = 443
+    protocol    = "tcp"
+    cidr_blocks = ["0.0.0.0/0"]
+    description = "Allows outbound HTTPS traffic to AWS services via VPC endpoints."
+  }
+
+  tags = merge(local.tags, { Name = "${local.name}-lambda-search-sg" })
+}
+
 #
 # Security Group for the `indexer` Lambda function.
 #
@@ -74,9 +96,143 @@ resource "aws_security_group" "lambda_indexer" {
     description = "Allows outbound HTTPS traffic to AWS services via VPC endpoints."
   }
 
+  egress {
+    from_port   = 3310
+    to_port     = 3310
+    protocol    = "tcp"
+    cidr_blocks = [var.cidr_private_a, var.cidr_private_b]
+    description = "Allows streaming uploads to the clamd malware scanner in the private subnets."
+  }
+
   tags = merge(local.tags, { Name = "${local.name}-lambda-indexer-sg" })
 }
 
+#
+# Security Group for the `get` Lambda function.
+#
+# Like the `list` Lambda, it only needs outbound HTTPS to reach DynamoDB.
+#
+resource "aws_security_group" "lambda_get" {
+  name        = "${local.name}-lambda-get-sg"
+  description = "Security group for get Lambda."
+  vpc_id      = aws_vpc.this.id
+
+  egress {
+    from_port   = 443
+    to_port     = 443
+    protocol    = "tcp"
+    cidr_blocks = ["0.0.0.0/0"]
+    description = "Allows outbound HTTPS traffic to AWS services via VPC endpoints."
+  }
+
+  tags = merge(local.tags, { Name = "${local.name}-lambda-get-sg" })
+}
+
+#
+# Security Group for the `download` Lambda function.
+#
+# It only needs outbound HTTPS to reach DynamoDB; presigning is local.
+#
+resource "aws_security_group" "lambda_download" {
+  name        = "${local.name}-lambda-download-sg"
+  description = "Security group for download Lambda."
+  vpc_id      = aws_vpc.this.id
+
+  egress {
+    from_port   = 443
+    to_port     = 443
+    protocol    = "tcp"
+    cidr_blocks = ["0.0.0.0/0"]
+    description = "Allows outbound HTTPS traffic to AWS services via VPC endpoints."
+  }
+
+  tags = merge(local.tags, { Name = "${local.name}-lambda-download-sg" })
+}
+
+#
+# Security Group for the `delete` Lambda function.
+#
+# It needs outbound HTTPS to reach DynamoDB and S3.
+#
+resource "aws_security_group" "lambda_delete" {
+  name        = "${local.name}-lambda-delete-sg"
+  description = "Security group for delete Lambda."
+  vpc_id      = aws_vpc.this.id
+
+  egress {
+    from_port   = 443
+    to_port     = 443
+    protocol    = "tcp"
+    cidr_blocks = ["0.0.0.0/0"]
+    description = "Allows outbound HTTPS traffic to AWS services via VPC endpoints."
+  }
+
+  tags = merge(local.tags, { Name = "${local.name}-lambda-delete-sg" })
+}
+
+#
+# Security Group for the `review` Lambda function.
+#
+# It only needs outbound HTTPS to reach DynamoDB.
+#
+resource "aws_security_group" "lambda_review" {
+  name        = "${local.name}-lambda-review-sg"
+  description = "Security group for review Lambda."
+  vpc_id      = aws_vpc.this.id
+
+  egress {
+    from_port   = 443
+    to_port     = 443
+    protocol    = "tcp"
+    cidr_blocks = ["0.0.0.0/0"]
+    description = "Allows outbound HTTPS traffic to AWS services via VPC endpoints."
+  }
+
+  tags = merge(local.tags, { Name = "${local.name}-lambda-review-sg" })
+}
+
+#
+# Security Group for the `multipart` Lambda function.
+#
+# It needs outbound HTTPS to reach DynamoDB and S3.
+#
+resource "aws_security_group" "lambda_multipart" {
+  name        = "${local.name}-lambda-multipart-sg"
+  description = "Security group for multipart Lambda."
+  vpc_id      = aws_vpc.this.id
+
+  egress {
+    from_port   = 443
+    to_port     = 443
+    protocol    = "tcp"
+    cidr_blocks = ["0.0.0.0/0"]
+    description = "Allows outbound HTTPS traffic to AWS services via VPC endpoints."
+  }
+
+  tags = merge(local.tags, { Name = "${local.name}-lambda-multipart-sg" })
+}
+
+#
+# Security Group for the `reaper` Lambda function.
+#
+# The scheduled reaper only needs outbound HTTPS to reach DynamoDB.
+#
+resource "aws_security_group" "lambda_reaper" {
+  name        = "${local.name}-lambda-reaper-sg"
+  description = "Security group for reaper Lambda."
+  vpc_id      = aws_vpc.this.id
+
+  egress {
+    from_port   = 443
+    to_port     = 443
+    protocol    = "tcp"
+    cidr_blocks = ["0.0.0.0/0"]
+    description = "Allows outbound HTTPS traffic to AWS services via VPC endpoints."
+  }
+
+  tags = merge(local.tags, { Name = "${local.name}-lambda-reaper-sg" })
+}
+
 #
 # Security Group for VPC Interface Endpoints.
 #
@@ -98,7 +254,14 @@ resource "aws_security_group" "vpc_endpoints" {
     security_groups = [
       aws_security_group.lambda_presign.id,
       aws_security_group.lambda_list.id,
-      aws_security_group.lambda_indexer.id
+      aws_security_group.lambda_search.id,
+      aws_security_group.lambda_get.id,
+      aws_security_group.lambda_download.id,
+      aws_security_group.lambda_delete.id,
+      aws_security_group.lambda_review.id,
+      aws_security_group.lambda_multipart.id,
+      aws_security_group.lambda_indexer.id,
+      aws_security_group.lambda_reaper.id
     ]
     description = "Allows inbound HTTPS traffic from Lambda functions."
   }
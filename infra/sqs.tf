@@ -0,0 +1,81 @@
+################################################################################
+# Indexer Event Queue
+#
+# S3 `ObjectCreated` events are delivered to the indexer through SQS rather
+# than invoking the Lambda directly. The queue buffers bursts, lets the indexer
+# report per-message failures for redelivery, and parks messages that keep
+# failing in a dead-letter queue instead of losing them.
+################################################################################
+
+#
+# Dead-letter queue for indexer events.
+#
+# Messages land here after `maxReceiveCount` failed deliveries. Retention is
+# the SQS maximum so failures can be investigated and replayed.
+#
+resource "aws_sqs_queue" "indexer_dlq" {
+  name                      = "${local.name}-indexer-dlq"
+  message_retention_seconds = 1209600 # 14 days
+  sqs_managed_sse_enabled   = true
+  tags                      = local.tags
+}
+
+#
+# Main indexer queue.
+#
+# The visibility timeout is six times the indexer's Lambda timeout, as AWS
+# recommends for SQS event sources, so in-flight batches are not redelivered
+# while still being processed.
+#
+resource "aws_sqs_queue" "indexer" {
+  name                       = "${local.name}-indexer"
+  visibility_timeout_seconds = 6 * aws_lambda_function.indexer.timeout
+  message_retention_seconds  = 345600 # 4 days
+  sqs_managed_sse_enabled    = true
+  tags                       = local.tags
+
+  redrive_policy = jsonencode({
+    deadLetterTargetArn = aws_sqs_queue.indexer_dlq.arn
+    maxReceiveCount     = 5
+  })
+}
+
+#
+# Queue policy allowing the claims bucket to publish events.
+#
+data "aws_iam_policy_document" "indexer_queue" {
+  statement {
+    sid       = "AllowS3Publish"
+    actions   = ["sqs:SendMessage"]
+    resources = [aws_sqs_queue.indexer.arn]
+
+    principals {
+      type        = "Service"
+      identifiers = ["s3.amazonaws.com"]
+    }
+
+    condition {
+      test     = "ArnEquals"
+      variable = "aws:SourceArn"
+      values   = [aws_s3_bucket.claims.arn]
+    }
+  }
+}
+
+resource "aws_sqs_queue_policy" "indexer" {
+  queue_url = aws_sqs_queue.indexer.id
+  policy    = data.aws_iam_policy_document.indexer_queue.json
+}
+
+#
+# Feeds the queue to the indexer.
+#
+# `ReportBatchItemFailures` makes Lambda delete only the messages the indexer
+# did not list in `batchItemFailures`; the rest become visible again.
+#
+resource "aws_lambda_event_source_mapping" "indexer" {
+  event_source_arn        = aws_sqs_queue.indexer.arn
+  function_name           = aws_lambda_function.indexer.arn
+  batch_size              = 10
+  function_response_types = ["ReportBatchItemFailures"]
+}
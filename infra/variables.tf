@@ -97,12 +97,54 @@ variable "image_tag_api_list" {
   default     = "dev"
 }
 
+variable "image_tag_api_search" {
+  description = "The ECR image tag for the API search Lambda."
+  type        = string
+  default     = "dev"
+}
+
+variable "image_tag_api_get" {
+  description = "The ECR image tag for the API get Lambda."
+  type        = string
+  default     = "dev"
+}
+
+variable "image_tag_api_download" {
+  description = "The ECR image tag for the API download Lambda."
+  type        = string
+  default     = "dev"
+}
+
+variable "image_tag_api_delete" {
+  description = "The ECR image tag for the API delete Lambda."
+  type        = string
+  default     = "dev"
+}
+
+variable "image_tag_api_review" {
+  description = "The ECR image tag for the API review Lambda."
+  type        = string
+  default     = "dev"
+}
+
+variable "image_tag_api_multipart" {
+  description = "The ECR image tag for the API multipart Lambda."
+  type        = string
+  default     = "dev"
+}
+
 variable "image_tag_indexer" {
   description = "The ECR image tag for the indexer Lambda."
   type        = string
   default     = "dev"
 }
 
+variable "image_tag_reaper" {
+  description = "The ECR image tag for the reaper Lambda."
+  type        = string
+  default     = "dev"
+}
+
 variable "presign_image_digest" {
   description = "Optional immutable digest for the API presign Lambda image. Overrides image_tag if provided."
   type        = string
@@ -115,12 +157,54 @@ variable "list_image_digest" {
   default     = ""
 }
 
+variable "search_image_digest" {
+  description = "Optional immutable digest for the API search Lambda image. Overrides image_tag if provided."
+  type        = string
+  default     = ""
+}
+
+variable "get_image_digest" {
+  description = "Optional immutable digest for the API get Lambda image. Overrides image_tag if provided."
+  type        = string
+  default     = ""
+}
+
+variable "download_image_digest" {
+  description = "Optional immutable digest for the API download Lambda image. Overrides image_tag if provided."
+  type        = string
+  default     = ""
+}
+
+variable "delete_image_digest" {
+  description = "Optional immutable digest for the API delete Lambda image. Overrides image_tag if provided."
+  type        = string
+  default     = ""
+}
+
+variable "review_image_digest" {
+  description = "Optional immutable digest for the API review Lambda image. Overrides image_tag if provided."
+  type        = string
+  default     = ""
+}
+
+variable "multipart_image_digest" {
+  description = "Optional immutable digest for the API multipart Lambda image. Overrides image_tag if provided."
+  type        = string
+  default     = ""
+}
+
 variable "indexer_image_digest" {
   description = "Optional immutable digest for the indexer Lambda image. Overrides image_tag if provided."
   type        = string
   default     = ""
 }
 
+variable "reaper_image_digest" {
+  description = "Optional immutable digest for the reaper Lambda image. Overrides image_tag if provided."
+  type        = string
+  default     = ""
+}
+
 #
 # Feature Flags.
 #
@@ -131,4 +215,46 @@ variable "enable_xray" {
   description = "Set to true to enable X-Ray tracing for supported resources."
   type        = bool
   default     = true
-}
\ No newline at end of file
+}
+#
+# Upload Limits.
+#
+# Maximum accepted claim document size. Enforced by the presigned POST policy
+# (`content-length-range`) and re-checked by the indexer.
+#
+variable "max_upload_bytes" {
+  description = "Maximum size in bytes of an uploaded claim document."
+  type        = number
+  default     = 10485760
+}
+
+#
+# Malware Scanning.
+#
+# The indexer streams every verified upload to a clamd daemon (run separately in
+# the private subnets) before marking it COMPLETE. Objects larger than
+# scan_max_bytes are marked FAILED (scan_too_large) instead of being scanned; keep it
+# equal to clamd's StreamMaxLength.
+#
+variable "clamav_addr" {
+  description = "host:port of the clamd service the indexer scans uploads with."
+  type        = string
+}
+
+variable "scan_max_bytes" {
+  description = "Largest object in bytes the indexer sends to clamd (clamd's StreamMaxLength)."
+  type        = number
+  default     = 26214400
+}
+
+variable "allowed_content_types" {
+  description = "MIME types accepted for claim documents (subset of text/plain, application/pdf, image/jpeg, image/png and DOCX)."
+  type        = list(string)
+  default = [
+    "text/plain",
+    "application/pdf",
+    "image/jpeg",
+    "image/png",
+    "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+  ]
+}
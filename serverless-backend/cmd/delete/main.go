@@ -0,0 +1,154 @@
+// Package main powers DELETE /claims/{id}: withdraws a claim, removes its uploaded objects,
+// including attachments and the redacted copy, and drops it from the search index.
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/audit"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/authz"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/awsutil"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/config"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/ddb"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/httpx"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/models"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/s3io"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/search"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/validate"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// App holds the application state, including configuration and AWS clients.
+type App struct {
+	env      config.Env
+	verifier *authz.Verifier
+	s3c      s3io.Deleter
+	ddbRepo  ddb.ClaimStore
+	index    search.Index // nil when SEARCH_BACKEND=none
+}
+
+// main initializes the app and starts the Lambda handler.
+func main() {
+	env := config.MustLoad()
+	if err := env.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	cfg, endpoint, err := awsutil.Load(context.Background(), env.Region)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	s3c := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if endpoint != "" {
+			o.UsePathStyle = true
+		}
+	})
+
+	index, err := search.New(env.SearchBackend, env.SearchDir, s3c, env.Bucket)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	verifier, err := authz.NewVerifier(env.Region, env.UserPoolID, env.UserPoolClientID, env.JWKSFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	app := &App{
+		env:      env,
+		verifier: verifier,
+		s3c:      s3c,
+		ddbRepo:  &ddb.Repo{DB: dynamodb.NewFromConfig(cfg), Table: env.Table, AuditTable: env.AuditTable},
+		index:    index,
+	}
+	lambda.Start(app.handler)
+}
+
+// --- handler ---
+
+// handler processes DELETE /claims/{id}. Admins may add ?user_id=<owner>.
+//
+// The record is flipped to WITHDRAWN first so it disappears from listings even if
+// the object delete fails; both steps are idempotent, so clients can simply retry.
+// Dropping the letter from the search index is best effort: search skips withdrawn
+// claims anyway.
+// With If-Match, the claim is only withdrawn if it is still at that version (else 412).
+func (a *App) handler(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	user, err := authz.FromAPIGWv1(ctx, req, a.env.DevBypassAuth, a.verifier)
+	if err != nil {
+		return httpx.ErrorV1(http.StatusUnauthorized, "missing or invalid user")
+	}
+	ctx = audit.WithActor(ctx, audit.Actor{Sub: user.Sub, RequestID: req.RequestContext.RequestID})
+
+	claimID := req.PathParameters["id"]
+	if err := validate.ClaimID(claimID); err != nil {
+		return httpx.ErrorV1(http.StatusBadRequest, err.Error())
+	}
+
+	owner := authz.TargetUser(user, req.QueryStringParameters)
+	if !authz.Can(user, authz.ActionDelete, models.Claim{UserID: owner}) {
+		return httpx.ErrorV1(http.StatusForbidden, "forbidden")
+	}
+
+	expected, conditional, err := httpx.IfMatch(req.Headers)
+	if err != nil {
+		return httpx.ErrorV1(http.StatusBadRequest, err.Error())
+	}
+	if !conditional {
+		expected = ddb.AnyVersion
+	}
+
+	claim, err := a.ddbRepo.Withdraw(ctx, owner, claimID, expected, ddb.NowISO())
+	if errors.Is(err, ddb.ErrNotFound) {
+		return httpx.ErrorV1(http.StatusNotFound, "claim not found")
+	}
+	if errors.Is(err, ddb.ErrConflict) {
+		if conditional {
+			return httpx.ErrorV1(http.StatusPreconditionFailed, "claim changed; reload and retry")
+		}
+		return httpx.ErrorV1(http.StatusConflict, "claim changed; retry")
+	}
+	if err != nil {
+		log.Printf("withdraw ddb error: %v", err)
+		return httpx.ErrorV1(http.StatusInternalServerError, "db error")
+	}
+
+	key := claim.S3Key
+	if key == "" {
+		key = s3io.BuildKey(owner, claimID, s3io.ExtText)
+	}
+	keys := []string{key}
+	if claim.RedactedKey != "" {
+		keys = append(keys, claim.RedactedKey)
+	}
+	atts, err := a.ddbRepo.ListAttachments(ctx, owner, claimID)
+	if err != nil {
+		log.Printf("withdraw list attachments: %v", err)
+		return httpx.ErrorV1(http.StatusInternalServerError, "db error")
+	}
+	for _, att := range atts {
+		keys = append(keys, att.S3Key)
+	}
+	for _, k := range keys {
+		if err := s3io.Delete(ctx, a.s3c, a.env.Bucket, k); err != nil {
+			log.Printf("withdraw s3 delete %s: %v", k, err)
+			return httpx.ErrorV1(http.StatusInternalServerError, "storage error")
+		}
+	}
+
+	if a.index != nil {
+		if err := a.index.Remove(ctx, owner, claimID); err != nil {
+			log.Printf("withdraw search remove %s/%s: %v", owner, claimID, err)
+		}
+	}
+
+	log.Printf("withdrew %s/%s by %s", owner, claimID, user.Sub)
+	return httpx.TaggedJSONV1(http.StatusOK, claim.Version, authz.ClaimView(user, claim))
+}
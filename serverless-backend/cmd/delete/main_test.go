@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/config"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/ddb"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/httpx"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/models"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/s3io"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/search"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/oklog/ulid/v2"
+)
+
+const testBucket = "claims-bucket"
+
+// newTestApp returns an App over MemStore, a fake bucket and a directory index with the
+// dev auth bypass on, and the ID of an uploaded, indexed claim owned by u-1 (at version 1).
+func newTestApp(t *testing.T) (*App, *s3io.Fake, *ddb.MemStore, string) {
+	t.Helper()
+	objects, store := &s3io.Fake{}, &ddb.MemStore{}
+	claimID := ulid.Make().String()
+	key := s3io.BuildKey("u-1", claimID, s3io.ExtText)
+	if err := store.PutPending(context.Background(), models.Claim{UserID: "u-1", ClaimID: claimID, S3Key: key, Status: models.StatusUploading}); err != nil {
+		t.Fatal(err)
+	}
+	text := "Dear Claims Adjuster, my car was hit in Austin, TX."
+	objects.Put(testBucket, key, s3io.ContentTypeText, []byte(text), nil)
+	index := search.NewDir(t.TempDir())
+	if err := index.Add(context.Background(), search.Doc{UserID: "u-1", ClaimID: claimID, Text: text}); err != nil {
+		t.Fatal(err)
+	}
+	a := &App{env: config.Env{Bucket: testBucket, DevBypassAuth: true}, s3c: objects, ddbRepo: store, index: index}
+	return a, objects, store, claimID
+}
+
+// withdraw runs DELETE /claims/{id} as u-1, with an If-Match header when ifMatch is not empty.
+func withdraw(t *testing.T, a *App, claimID, ifMatch string) events.APIGatewayProxyResponse {
+	t.Helper()
+	headers := map[string]string{"x-user-sub": "u-1"}
+	if ifMatch != "" {
+		headers["If-Match"] = ifMatch
+	}
+	resp, err := a.handler(context.Background(), events.APIGatewayProxyRequest{
+		Path:           "/claims/" + claimID,
+		Headers:        headers,
+		PathParameters: map[string]string{"id": claimID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestDeleteIfMatch(t *testing.T) {
+	t.Run("stale version", func(t *testing.T) {
+		a, objects, store, id := newTestApp(t)
+		if err := store.UpsertComplete(context.Background(), "u-1", id, 1, s3io.BuildKey("u-1", id, s3io.ExtText), 10, "etag", "", ddb.NowISO()); err != nil {
+			t.Fatal(err)
+		}
+		resp := withdraw(t, a, id, httpx.ETag(1))
+		if resp.StatusCode != http.StatusPreconditionFailed {
+			t.Fatalf("status = %d, want 412 (%s)", resp.StatusCode, resp.Body)
+		}
+		c, err := store.GetClaim(context.Background(), "u-1", id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Status == models.StatusWithdrawn {
+			t.Error("claim withdrawn despite a stale If-Match")
+		}
+		if _, ok := objects.Object(testBucket, c.S3Key); !ok {
+			t.Error("object deleted despite a stale If-Match")
+		}
+	})
+
+	t.Run("current version", func(t *testing.T) {
+		a, objects, store, id := newTestApp(t)
+		resp := withdraw(t, a, id, httpx.ETag(1))
+		if resp.StatusCode != http.StatusOK || resp.Headers["ETag"] != httpx.ETag(2) {
+			t.Fatalf("status = %d, ETag %s, want 200 with %s (%s)", resp.StatusCode, resp.Headers["ETag"], httpx.ETag(2), resp.Body)
+		}
+		c, err := store.GetClaim(context.Background(), "u-1", id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Status != models.StatusWithdrawn {
+			t.Errorf("status = %s, want WITHDRAWN", c.Status)
+		}
+		if keys := objects.Keys(testBucket); len(keys) != 0 {
+			t.Errorf("objects left behind: %v", keys)
+		}
+	})
+
+	t.Run("without If-Match", func(t *testing.T) {
+		// Unconditional deletes are last-writer-wins, and retrying one is harmless.
+		a, _, _, id := newTestApp(t)
+		for i := 0; i < 2; i++ {
+			if resp := withdraw(t, a, id, ""); resp.StatusCode != http.StatusOK {
+				t.Fatalf("attempt %d: status = %d (%s)", i+1, resp.StatusCode, resp.Body)
+			}
+		}
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		a, _, _, id := newTestApp(t)
+		if resp := withdraw(t, a, id, "W/\"1\""); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("status = %d, want 400 (%s)", resp.StatusCode, resp.Body)
+		}
+	})
+}
+
+func TestDeleteRemovesFromSearch(t *testing.T) {
+	ctx := context.Background()
+	a, _, _, id := newTestApp(t)
+	if hits, err := a.index.Search(ctx, "u-1", "austin", 10); err != nil || len(hits) != 1 {
+		t.Fatalf("before delete: hits %v, err %v; want the claim", hits, err)
+	}
+
+	if resp := withdraw(t, a, id, httpx.ETag(2)); resp.StatusCode != http.StatusPreconditionFailed {
+		t.Fatalf("mismatched If-Match: status = %d (%s)", resp.StatusCode, resp.Body)
+	}
+	if hits, _ := a.index.Search(ctx, "u-1", "austin", 10); len(hits) != 1 {
+		t.Error("refused delete removed the claim from the index")
+	}
+
+	if resp := withdraw(t, a, id, ""); resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d (%s)", resp.StatusCode, resp.Body)
+	}
+	hits, err := a.index.Search(ctx, "u-1", "austin", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 0 {
+		t.Errorf("withdrawn claim still searchable: %v", hits)
+	}
+}
+
+func TestDeleteWithoutSearch(t *testing.T) {
+	a, _, _, id := newTestApp(t)
+	a.index = nil // SEARCH_BACKEND=none
+	if resp := withdraw(t, a, id, ""); resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d (%s)", resp.StatusCode, resp.Body)
+	}
+}
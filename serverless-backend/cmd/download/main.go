@@ -0,0 +1,177 @@
+// Package main powers GET /claims/{id}/download, returning a short-lived presigned GET URL.
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/authz"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/awsutil"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/config"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/ddb"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/httpx"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/models"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/s3io"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/validate"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// --------- response payload ---------
+
+type downloadResponse struct {
+	ClaimID      string `json:"claim_id"`
+	AttachmentID string `json:"attachment_id,omitempty"`
+	Variant      string `json:"variant"` // "original" or "redacted"
+	Filename     string `json:"filename"`
+	DownloadURL  string `json:"download_url"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// Download variants selected with ?variant=.
+const (
+	variantOriginal = "original"
+	variantRedacted = "redacted"
+)
+
+// --------- app ---------
+
+// App holds the application state, including configuration and AWS clients.
+type App struct {
+	env      config.Env
+	verifier *authz.Verifier
+	s3p      s3io.GetPresigner
+	ddbRepo  ddb.ClaimStore
+}
+
+// main initializes the app and starts the Lambda handler.
+func main() {
+	env := config.MustLoad()
+	if err := env.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	cfg, endpoint, err := awsutil.Load(context.Background(), env.Region)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	s3c := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if endpoint != "" {
+			o.UsePathStyle = true
+		}
+	})
+
+	verifier, err := authz.NewVerifier(env.Region, env.UserPoolID, env.UserPoolClientID, env.JWKSFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	app := &App{
+		env:      env,
+		verifier: verifier,
+		s3p:      s3.NewPresignClient(s3c),
+		ddbRepo:  &ddb.Repo{DB: dynamodb.NewFromConfig(cfg), Table: env.Table, AuditTable: env.AuditTable},
+	}
+	lambda.Start(app.handler)
+}
+
+// --------- handler ---------
+
+// handler processes GET /claims/{id}/download. Adjusters/admins/vendors may add ?user_id=<owner>;
+// ?attachment_id=<id> downloads one of the claim's attachments instead of its document, and
+// ?variant=redacted the PII-redacted copy of a text letter. Vendors only ever get redacted
+// copies, and only of claims assigned to them (PUT /claims/{id}/vendors).
+func (a *App) handler(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	user, err := authz.FromAPIGWv1(ctx, req, a.env.DevBypassAuth, a.verifier)
+	if err != nil {
+		return httpx.ErrorV1(http.StatusUnauthorized, "missing or invalid user")
+	}
+
+	claimID := req.PathParameters["id"]
+	if err := validate.ClaimID(claimID); err != nil {
+		return httpx.ErrorV1(http.StatusBadRequest, err.Error())
+	}
+
+	owner := authz.TargetUser(user, req.QueryStringParameters)
+	// A vendor's access depends on the claim's assignment, checked once it is loaded.
+	vendor := user.HasRole(models.RoleVendor)
+	if !vendor && !authz.Can(user, authz.ActionReadRedacted, models.Claim{UserID: owner}) {
+		return httpx.ErrorV1(http.StatusForbidden, "forbidden")
+	}
+	variant := req.QueryStringParameters["variant"]
+	if variant != "" && variant != variantOriginal && variant != variantRedacted {
+		return httpx.ErrorV1(http.StatusBadRequest, "variant must be original or redacted")
+	}
+
+	claim, err := a.ddbRepo.GetClaim(ctx, owner, claimID)
+	if errors.Is(err, ddb.ErrNotFound) {
+		return httpx.ErrorV1(http.StatusNotFound, "claim not found")
+	}
+	if err != nil {
+		log.Printf("download ddb error: %v", err)
+		return httpx.ErrorV1(http.StatusInternalServerError, "db error")
+	}
+	if !authz.Can(user, authz.ActionReadRedacted, claim) {
+		return httpx.ErrorV1(http.StatusNotFound, "claim not found") // unassigned vendor: do not confirm it exists
+	}
+	switch {
+	case authz.RedactedOnly(user, claim):
+		if variant == variantOriginal {
+			return httpx.ErrorV1(http.StatusForbidden, "only redacted copies are available")
+		}
+		variant = variantRedacted
+	case variant == "":
+		variant = variantOriginal
+	}
+
+	resp := downloadResponse{ClaimID: claim.ClaimID, Variant: variant, Filename: claim.Filename}
+	key, status := claim.S3Key, claim.Status
+	if variant == variantRedacted {
+		key = claim.RedactedKey
+	}
+	if attID := req.QueryStringParameters["attachment_id"]; attID != "" {
+		if variant == variantRedacted { // only claim letters get redacted copies
+			return httpx.ErrorV1(http.StatusConflict, "no redacted copy available")
+		}
+		if validate.ClaimID(attID) != nil { // attachment IDs are ULIDs too
+			return httpx.ErrorV1(http.StatusBadRequest, "invalid attachment id")
+		}
+		if claim.Status == models.StatusWithdrawn {
+			return httpx.ErrorV1(http.StatusConflict, "claim is withdrawn")
+		}
+		att, err := a.ddbRepo.GetAttachment(ctx, owner, claimID, attID)
+		if errors.Is(err, ddb.ErrNotFound) {
+			return httpx.ErrorV1(http.StatusNotFound, "attachment not found")
+		}
+		if err != nil {
+			log.Printf("download ddb error: %v", err)
+			return httpx.ErrorV1(http.StatusInternalServerError, "db error")
+		}
+		resp.AttachmentID, resp.Filename = att.AttachmentID, att.Filename
+		key, status = att.S3Key, att.Status
+	}
+	if status != models.StatusComplete {
+		return httpx.ErrorV1(http.StatusConflict, "upload not complete")
+	}
+	if key == "" {
+		return httpx.ErrorV1(http.StatusConflict, "no redacted copy available")
+	}
+	if variant == variantRedacted {
+		resp.Filename = "redacted-" + resp.Filename
+	}
+
+	url, ttl, err := s3io.PresignGet(ctx, a.s3p, a.env.Bucket, key, resp.Filename, a.env.DownloadTTL)
+	if err != nil {
+		log.Printf("presign get err: %v", err)
+		return httpx.ErrorV1(http.StatusInternalServerError, "presign error")
+	}
+
+	resp.DownloadURL = url
+	resp.ExpiresIn = int(ttl.Seconds())
+	return httpx.JSONV1(http.StatusOK, resp)
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/config"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/ddb"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/models"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/s3io"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/oklog/ulid/v2"
+)
+
+const testBucket = "claims-bucket"
+
+// fixture is a COMPLETE claim owned by u-1 with a redacted copy, assigned to vendor v-1,
+// with one COMPLETE attachment, plus an UPLOADING claim.
+type fixture struct {
+	claimID, attachmentID, uploadingID string
+	key, redactedKey, attachmentKey    string
+}
+
+// newTestApp returns an App over MemStore and a fake presigner with the dev auth bypass on.
+func newTestApp(t *testing.T) (*App, fixture) {
+	t.Helper()
+	ctx := context.Background()
+	store := &ddb.MemStore{}
+	f := fixture{claimID: ulid.Make().String(), attachmentID: ulid.Make().String(), uploadingID: ulid.Make().String()}
+	f.key = s3io.BuildKey("u-1", f.claimID, s3io.ExtText)
+	f.redactedKey = s3io.RedactedKey(f.key)
+	f.attachmentKey = s3io.AttachmentKey("u-1", f.claimID, f.attachmentID, ".png")
+
+	for _, id := range []string{f.claimID, f.uploadingID} {
+		c := models.Claim{UserID: "u-1", ClaimID: id, Filename: "letter.txt", S3Key: s3io.BuildKey("u-1", id, s3io.ExtText), Status: models.StatusUploading}
+		if err := store.PutPending(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.UpsertComplete(ctx, "u-1", f.claimID, ddb.AnyVersion, f.key, 10, "etag", "", ddb.NowISO()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.PutAnalysis(ctx, "u-1", f.claimID, ddb.AnyVersion, ddb.Analysis{Category: "auto", RedactedKey: f.redactedKey}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.SetVendors(ctx, "u-1", f.claimID, ddb.AnyVersion, []string{"v-1"}); err != nil {
+		t.Fatal(err)
+	}
+	att := models.Attachment{UserID: "u-1", ClaimID: f.claimID, AttachmentID: f.attachmentID, Filename: "photo.png", S3Key: f.attachmentKey}
+	if err := store.PutPendingAttachment(ctx, att); err != nil {
+		t.Fatal(err)
+	}
+	attItem := ddb.AttachmentItemID(f.claimID, f.attachmentID)
+	if err := store.UpsertComplete(ctx, "u-1", attItem, ddb.AnyVersion, f.attachmentKey, 10, "etag-a", "", ddb.NowISO()); err != nil {
+		t.Fatal(err)
+	}
+
+	a := &App{
+		env:     config.Env{Bucket: testBucket, DownloadTTL: time.Minute, DevBypassAuth: true},
+		s3p:     &s3io.Fake{},
+		ddbRepo: store,
+	}
+	return a, f
+}
+
+// call runs GET /claims/{id}/download as sub (with comma-separated groups) and decodes a
+// successful response.
+func call(t *testing.T, a *App, sub, groups, claimID string, query map[string]string) (int, downloadResponse) {
+	t.Helper()
+	headers := map[string]string{}
+	if sub != "" {
+		headers["x-user-sub"] = sub
+	}
+	if groups != "" {
+		headers["x-user-groups"] = groups
+	}
+	resp, err := a.handler(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/claims/" + claimID + "/download",
+		Headers:               headers,
+		PathParameters:        map[string]string{"id": claimID},
+		QueryStringParameters: query,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out downloadResponse
+	if resp.StatusCode == http.StatusOK {
+		if err := json.Unmarshal([]byte(resp.Body), &out); err != nil {
+			t.Fatalf("decode %q: %v", resp.Body, err)
+		}
+	}
+	return resp.StatusCode, out
+}
+
+func TestDownloadVariants(t *testing.T) {
+	a, f := newTestApp(t)
+	tests := []struct {
+		name     string
+		sub      string
+		groups   string
+		query    map[string]string
+		variant  string
+		key      string
+		filename string
+	}{
+		{"owner gets the original", "u-1", "", nil, variantOriginal, f.key, "letter.txt"},
+		{"owner may ask for the redacted copy", "u-1", "", map[string]string{"variant": "redacted"}, variantRedacted, f.redactedKey, "redacted-letter.txt"},
+		{"adjuster gets the original", "adj-1", "adjuster", map[string]string{"user_id": "u-1"}, variantOriginal, f.key, "letter.txt"},
+		{"admin may ask for the redacted copy", "admin-1", "admin", map[string]string{"user_id": "u-1", "variant": "redacted"}, variantRedacted, f.redactedKey, "redacted-letter.txt"},
+		{"assigned vendor gets the redacted copy", "v-1", "vendor", map[string]string{"user_id": "u-1"}, variantRedacted, f.redactedKey, "redacted-letter.txt"},
+		{"owner downloads an attachment", "u-1", "", map[string]string{"attachment_id": f.attachmentID}, variantOriginal, f.attachmentKey, "photo.png"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, out := call(t, a, tt.sub, tt.groups, f.claimID, tt.query)
+			if code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", code)
+			}
+			if out.Variant != tt.variant || out.Filename != tt.filename {
+				t.Errorf("variant %q filename %q, want %q %q", out.Variant, out.Filename, tt.variant, tt.filename)
+			}
+			// The fake presigner puts the key in the URL.
+			if !strings.Contains(out.DownloadURL, "/"+tt.key+"?get") {
+				t.Errorf("download_url = %s, want a presigned GET of %s", out.DownloadURL, tt.key)
+			}
+			if out.ExpiresIn != 60 {
+				t.Errorf("expires_in = %d, want 60", out.ExpiresIn)
+			}
+		})
+	}
+}
+
+func TestDownloadErrors(t *testing.T) {
+	a, f := newTestApp(t)
+	tests := []struct {
+		name    string
+		sub     string
+		groups  string
+		claimID string
+		query   map[string]string
+		want    int
+	}{
+		{"no user", "", "", f.claimID, nil, http.StatusUnauthorized},
+		{"invalid claim id", "u-1", "", "not-a-ulid", nil, http.StatusBadRequest},
+		{"invalid variant", "u-1", "", f.claimID, map[string]string{"variant": "raw"}, http.StatusBadRequest},
+		{"another user's claim", "u-2", "", f.claimID, map[string]string{"user_id": "u-1"}, http.StatusForbidden},
+		{"vendor asks for the original", "v-1", "vendor", f.claimID, map[string]string{"user_id": "u-1", "variant": "original"}, http.StatusForbidden},
+		{"unassigned vendor", "v-2", "vendor", f.claimID, map[string]string{"user_id": "u-1"}, http.StatusNotFound},
+		{"missing claim", "u-1", "", ulid.Make().String(), nil, http.StatusNotFound},
+		{"missing attachment", "u-1", "", f.claimID, map[string]string{"attachment_id": ulid.Make().String()}, http.StatusNotFound},
+		{"invalid attachment id", "u-1", "", f.claimID, map[string]string{"attachment_id": "a-1"}, http.StatusBadRequest},
+		{"redacted attachment", "u-1", "", f.claimID, map[string]string{"attachment_id": f.attachmentID, "variant": "redacted"}, http.StatusConflict},
+		{"upload not complete", "u-1", "", f.uploadingID, nil, http.StatusConflict},
+		{"no redacted copy", "u-1", "", f.uploadingID, map[string]string{"variant": "redacted"}, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code, _ := call(t, a, tt.sub, tt.groups, tt.claimID, tt.query); code != tt.want {
+				t.Errorf("status = %d, want %d", code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+// Package main powers GET /claims/{id}, returning a single claim's sanitized view with its attachments,
+// and GET /claims/{id}/history, returning the claim's audit trail.
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/authz"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/awsutil"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/config"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/ddb"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/httpx"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/models"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/validate"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// App holds the application state, including configuration and AWS clients.
+type App struct {
+	env      config.Env
+	verifier *authz.Verifier
+	ddbRepo  ddb.ClaimStore
+}
+
+// main initializes the app and starts the Lambda handler.
+func main() {
+	env := config.MustLoad()
+	if err := env.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	cfg, _, err := awsutil.Load(context.Background(), env.Region)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	verifier, err := authz.NewVerifier(env.Region, env.UserPoolID, env.UserPoolClientID, env.JWKSFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	app := &App{
+		env:      env,
+		verifier: verifier,
+		ddbRepo:  &ddb.Repo{DB: dynamodb.NewFromConfig(cfg), Table: env.Table, AuditTable: env.AuditTable},
+	}
+	lambda.Start(app.handler)
+}
+
+// --- handler ---
+
+// handler processes GET /claims/{id}. Adjusters/admins may add ?user_id=<owner>.
+//
+// A claimant can only address their own partition, so another user's claim ID
+// yields 404 rather than 403 and does not reveal that the claim exists. 403 is
+// reserved for asking for another user's claims without the role to do so.
+//
+// The ETag header carries the claim's version; send it back as If-Match when modifying it.
+func (a *App) handler(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	user, err := authz.FromAPIGWv1(ctx, req, a.env.DevBypassAuth, a.verifier)
+	if err != nil {
+		return httpx.ErrorV1(http.StatusUnauthorized, "missing or invalid user")
+	}
+
+	claimID := req.PathParameters["id"]
+	if err := validate.ClaimID(claimID); err != nil {
+		return httpx.ErrorV1(http.StatusBadRequest, err.Error())
+	}
+
+	owner := authz.TargetUser(user, req.QueryStringParameters)
+	if !authz.Can(user, authz.ActionRead, models.Claim{UserID: owner}) {
+		return httpx.ErrorV1(http.StatusForbidden, "forbidden")
+	}
+
+	if strings.HasSuffix(req.Path, "/history") {
+		return a.history(ctx, owner, claimID)
+	}
+
+	claim, err := a.ddbRepo.GetClaim(ctx, owner, claimID)
+	if errors.Is(err, ddb.ErrNotFound) {
+		return httpx.ErrorV1(http.StatusNotFound, "claim not found")
+	}
+	if err != nil {
+		log.Printf("get ddb error: %v", err)
+		return httpx.ErrorV1(http.StatusInternalServerError, "db error")
+	}
+
+	view := authz.ClaimView(user, claim)
+	if claim.AttachmentCount > 0 {
+		atts, err := a.ddbRepo.ListAttachments(ctx, owner, claimID)
+		if err != nil {
+			log.Printf("get attachments ddb error: %v", err)
+			return httpx.ErrorV1(http.StatusInternalServerError, "db error")
+		}
+		for _, att := range atts {
+			view.Attachments = append(view.Attachments, att.View())
+		}
+	}
+	return httpx.TaggedJSONV1(http.StatusOK, claim.Version, view)
+}
+
+// historyResponse is the GET /claims/{id}/history body.
+type historyResponse struct {
+	ClaimID string              `json:"claim_id"`
+	Events  []models.AuditEvent `json:"events"`
+}
+
+// history returns the audit trail of a claim and its attachments, oldest first. The claim
+// is read first so a missing (or someone else's) claim is a 404 like GET /claims/{id}.
+func (a *App) history(ctx context.Context, owner, claimID string) (events.APIGatewayProxyResponse, error) {
+	if _, err := a.ddbRepo.GetClaim(ctx, owner, claimID); errors.Is(err, ddb.ErrNotFound) {
+		return httpx.ErrorV1(http.StatusNotFound, "claim not found")
+	} else if err != nil {
+		log.Printf("history ddb error: %v", err)
+		return httpx.ErrorV1(http.StatusInternalServerError, "db error")
+	}
+
+	evs, err := a.ddbRepo.ListHistory(ctx, owner, claimID)
+	if err != nil {
+		log.Printf("history ddb error: %v", err)
+		return httpx.ErrorV1(http.StatusInternalServerError, "db error")
+	}
+	if evs == nil {
+		evs = []models.AuditEvent{}
+	}
+	return httpx.JSONV1(http.StatusOK, historyResponse{ClaimID: claimID, Events: evs})
+}
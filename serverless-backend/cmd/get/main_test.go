@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/config"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/ddb"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/httpx"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/models"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/oklog/ulid/v2"
+)
+
+// newTestApp returns an App over MemStore with the dev auth bypass on, and the ID of a
+// COMPLETE claim owned by u-1 with one pending attachment.
+func newTestApp(t *testing.T) (*App, string) {
+	t.Helper()
+	ctx := context.Background()
+	store := &ddb.MemStore{}
+	claimID, attachmentID := ulid.Make().String(), ulid.Make().String()
+	c := models.Claim{UserID: "u-1", ClaimID: claimID, Filename: "letter.txt", S3Key: "user/u-1/" + claimID + ".txt", Status: models.StatusUploading}
+	if err := store.PutPending(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpsertComplete(ctx, "u-1", claimID, ddb.AnyVersion, c.S3Key, 10, "etag", "", ddb.NowISO()); err != nil {
+		t.Fatal(err)
+	}
+	att := models.Attachment{UserID: "u-1", ClaimID: claimID, AttachmentID: attachmentID, Filename: "photo.png", S3Key: "user/u-1/" + claimID + "/" + attachmentID + ".png"}
+	if err := store.PutPendingAttachment(ctx, att); err != nil {
+		t.Fatal(err)
+	}
+	return &App{env: config.Env{DevBypassAuth: true}, ddbRepo: store}, claimID
+}
+
+// call runs GET path for claimID as sub (with comma-separated groups).
+func call(t *testing.T, a *App, sub, groups, path, claimID string, query map[string]string) events.APIGatewayProxyResponse {
+	t.Helper()
+	headers := map[string]string{"x-user-sub": sub}
+	if groups != "" {
+		headers["x-user-groups"] = groups
+	}
+	resp, err := a.handler(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod:            http.MethodGet,
+		Path:                  path,
+		Headers:               headers,
+		PathParameters:        map[string]string{"id": claimID},
+		QueryStringParameters: query,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestGetAccess(t *testing.T) {
+	a, claimID := newTestApp(t)
+	owner := map[string]string{"user_id": "u-1"}
+	tests := []struct {
+		name    string
+		sub     string
+		groups  string
+		claimID string
+		query   map[string]string
+		want    int
+	}{
+		{"owner", "u-1", "", claimID, nil, http.StatusOK},
+		{"adjuster", "adj-1", "adjuster", claimID, owner, http.StatusOK},
+		{"admin", "admin-1", "admin", claimID, owner, http.StatusOK},
+		// Without ?user_id staff read their own partition, where the claim is not.
+		{"adjuster without user_id", "adj-1", "adjuster", claimID, nil, http.StatusNotFound},
+		{"another claimant naming the owner", "u-2", "", claimID, owner, http.StatusForbidden},
+		{"vendor naming the owner", "v-1", "vendor", claimID, owner, http.StatusForbidden},
+		// A claimant only sees their own partition, so someone else's claim is not found.
+		{"another claimant", "u-2", "", claimID, nil, http.StatusNotFound},
+		{"missing claim", "u-1", "", ulid.Make().String(), nil, http.StatusNotFound},
+		{"invalid claim id", "u-1", "", "not-a-ulid", nil, http.StatusBadRequest},
+		{"no user", "", "", claimID, nil, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, path := range []string{"/claims/" + tt.claimID, "/claims/" + tt.claimID + "/history"} {
+				if resp := call(t, a, tt.sub, tt.groups, path, tt.claimID, tt.query); resp.StatusCode != tt.want {
+					t.Errorf("%s: status = %d, want %d (%s)", path, resp.StatusCode, tt.want, resp.Body)
+				}
+			}
+		})
+	}
+}
+
+func TestGetClaim(t *testing.T) {
+	a, claimID := newTestApp(t)
+	c, err := a.ddbRepo.GetClaim(context.Background(), "u-1", claimID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := call(t, a, "u-1", "", "/claims/"+claimID, claimID, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", resp.StatusCode, resp.Body)
+	}
+	if got := resp.Headers["ETag"]; got != httpx.ETag(c.Version) {
+		t.Errorf("ETag = %s, want %s", got, httpx.ETag(c.Version))
+	}
+	var view models.ClaimView
+	if err := json.Unmarshal([]byte(resp.Body), &view); err != nil {
+		t.Fatal(err)
+	}
+	if view.ClaimID != claimID || view.Status != string(models.StatusComplete) {
+		t.Errorf("view = %s %s, want %s COMPLETE", view.ClaimID, view.Status, claimID)
+	}
+	if len(view.Attachments) != 1 || view.Attachments[0].Filename != "photo.png" {
+		t.Errorf("attachments = %+v, want photo.png", view.Attachments)
+	}
+}
+
+func TestGetVendorsStaffOnly(t *testing.T) {
+	a, claimID := newTestApp(t)
+	if _, err := a.ddbRepo.SetVendors(context.Background(), "u-1", claimID, ddb.AnyVersion, []string{"v-1"}); err != nil {
+		t.Fatal(err)
+	}
+	owner := map[string]string{"user_id": "u-1"}
+	tests := []struct {
+		name, sub, groups string
+		query             map[string]string
+		want              []string
+	}{
+		{"owner", "u-1", "", nil, nil},
+		{"adjuster", "adj-1", "adjuster", owner, []string{"v-1"}},
+		{"admin", "admin-1", "admin", owner, []string{"v-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := call(t, a, tt.sub, tt.groups, "/claims/"+claimID, claimID, tt.query)
+			var view models.ClaimView
+			if err := json.Unmarshal([]byte(resp.Body), &view); err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(view.Vendors, tt.want) {
+				t.Errorf("vendors = %v, want %v", view.Vendors, tt.want)
+			}
+		})
+	}
+}
+
+func TestHistory(t *testing.T) {
+	a, claimID := newTestApp(t)
+	for _, tt := range []struct {
+		name, sub, groups string
+		query             map[string]string
+	}{
+		{"owner", "u-1", "", nil},
+		{"adjuster", "adj-1", "adjuster", map[string]string{"user_id": "u-1"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := call(t, a, tt.sub, tt.groups, "/claims/"+claimID+"/history", claimID, tt.query)
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d, want 200 (%s)", resp.StatusCode, resp.Body)
+			}
+			var out historyResponse
+			if err := json.Unmarshal([]byte(resp.Body), &out); err != nil {
+				t.Fatal(err)
+			}
+			if out.ClaimID != claimID {
+				t.Errorf("claim_id = %s, want %s", out.ClaimID, claimID)
+			}
+			// Oldest first, with the attachment's events filed under the claim.
+			var actions []string
+			for _, ev := range out.Events {
+				actions = append(actions, ev.Action)
+			}
+			want := []string{models.AuditCreate, models.AuditComplete, models.AuditAttach}
+			if !slices.Equal(actions, want) {
+				t.Fatalf("actions = %v, want %v", actions, want)
+			}
+			if last := out.Events[len(out.Events)-1]; last.ItemID == claimID {
+				t.Errorf("attach event item_id = %s, want the attachment item", last.ItemID)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/config"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/ddb"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/indexer"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/models"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/s3io"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/scan"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/search"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+const testBucket = "claims-bucket"
+
+// newTestApp returns an App whose Processor works on an empty fake bucket and MemStore.
+func newTestApp(t *testing.T) (*App, *s3io.Fake, *ddb.MemStore) {
+	t.Helper()
+	objects, store := &s3io.Fake{}, &ddb.MemStore{}
+	proc, err := indexer.New(config.Env{
+		Bucket:            testBucket,
+		MaxUploadBytes:    1 << 20,
+		MaxMultipartBytes: 1 << 30,
+		Scanner:           scan.BackendNone,
+		SearchBackend:     search.BackendNone,
+	}, objects, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &App{proc: proc}, objects, store
+}
+
+// upload records a pending claim, puts body at its key and returns the key.
+func upload(t *testing.T, objects *s3io.Fake, store *ddb.MemStore, userID, claimID, body string) string {
+	t.Helper()
+	key := s3io.BuildKey(userID, claimID, s3io.ExtText)
+	if err := store.PutPending(context.Background(), models.Claim{UserID: userID, ClaimID: claimID, S3Key: key, Status: models.StatusUploading}); err != nil {
+		t.Fatal(err)
+	}
+	objects.Put(testBucket, key, s3io.ContentTypeText, []byte(body), map[string]string{"user_id": userID, "claim_id": claimID})
+	return key
+}
+
+// sqsMessage wraps an ObjectCreated event for keys in an SQS message.
+func sqsMessage(t *testing.T, id string, keys ...string) events.SQSMessage {
+	t.Helper()
+	var ev events.S3Event
+	for _, k := range keys {
+		ev.Records = append(ev.Records, events.S3EventRecord{
+			EventName: "ObjectCreated:Put",
+			S3: events.S3Entity{
+				Bucket: events.S3Bucket{Name: testBucket},
+				Object: events.S3Object{Key: k},
+			},
+		})
+	}
+	b, err := json.Marshal(ev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return events.SQSMessage{MessageId: id, Body: string(b)}
+}
+
+// status returns a claim's current status.
+func status(t *testing.T, store *ddb.MemStore, userID, claimID string) models.ClaimStatus {
+	t.Helper()
+	c, err := store.GetClaim(context.Background(), userID, claimID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c.Status
+}
+
+func TestHandlerFinalizesBatch(t *testing.T) {
+	a, objects, store := newTestApp(t)
+	k1 := upload(t, objects, store, "u-1", "c-1", "Dear Claims Adjuster, my car was hit.")
+	k2 := upload(t, objects, store, "u-2", "c-2", "Dear Claims Adjuster, my roof leaks.")
+
+	resp, err := a.handler(context.Background(), events.SQSEvent{Records: []events.SQSMessage{
+		sqsMessage(t, "m-1", k1),
+		sqsMessage(t, "m-2", k2),
+		{MessageId: "m-3", Body: "not json"}, // dropped, not retried
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.BatchItemFailures) != 0 {
+		t.Errorf("batch item failures = %v, want none", resp.BatchItemFailures)
+	}
+	for _, c := range []struct{ user, claim string }{{"u-1", "c-1"}, {"u-2", "c-2"}} {
+		if s := status(t, store, c.user, c.claim); s != models.StatusComplete {
+			t.Errorf("%s/%s status = %s, want COMPLETE", c.user, c.claim, s)
+		}
+	}
+
+	history, err := store.ListHistory(context.Background(), "u-1", "c-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := history[len(history)-1]
+	if last.Action != models.AuditComplete || last.RequestID != "m-1" {
+		t.Errorf("last audit event = %s by request %q, want %s by m-1", last.Action, last.RequestID, models.AuditComplete)
+	}
+}
+
+func TestHandlerReportsOnlyFailingMessages(t *testing.T) {
+	a, objects, store := newTestApp(t)
+	ok := upload(t, objects, store, "u-1", "c-1", "Dear Claims Adjuster, my car was hit.")
+	throttled := upload(t, objects, store, "u-1", "c-2", "Dear Claims Adjuster, my roof leaks.")
+	alsoOK := upload(t, objects, store, "u-2", "c-3", "Dear Claims Adjuster, my bike was stolen.")
+	gone := s3io.BuildKey("u-3", "c-4", s3io.ExtText) // no object, no record: dropped
+	objects.Errs = map[string]error{throttled: errors.New("SlowDown: please reduce your request rate")}
+
+	resp, err := a.handler(context.Background(), events.SQSEvent{Records: []events.SQSMessage{
+		sqsMessage(t, "m-ok", ok),
+		sqsMessage(t, "m-throttled", throttled),
+		sqsMessage(t, "m-gone", gone),
+		sqsMessage(t, "m-mixed", alsoOK, throttled),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var failed []string
+	for _, f := range resp.BatchItemFailures {
+		failed = append(failed, f.ItemIdentifier)
+	}
+	if len(failed) != 2 || failed[0] != "m-throttled" || failed[1] != "m-mixed" {
+		t.Errorf("batch item failures = %v, want [m-throttled m-mixed]", failed)
+	}
+	if s := status(t, store, "u-1", "c-2"); s != models.StatusUploading {
+		t.Errorf("throttled claim status = %s, want UPLOADING for the retry", s)
+	}
+	for _, c := range []struct{ user, claim string }{{"u-1", "c-1"}, {"u-2", "c-3"}} {
+		if s := status(t, store, c.user, c.claim); s != models.StatusComplete {
+			t.Errorf("%s/%s status = %s, want COMPLETE", c.user, c.claim, s)
+		}
+	}
+
+	// The retry of the failed messages finishes the job without redoing the rest.
+	before, err := store.ListHistory(context.Background(), "u-2", "c-3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	objects.Errs = nil
+	resp, err = a.handler(context.Background(), events.SQSEvent{Records: []events.SQSMessage{
+		sqsMessage(t, "m-throttled", throttled),
+		sqsMessage(t, "m-mixed", alsoOK, throttled),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.BatchItemFailures) != 0 {
+		t.Errorf("retry batch item failures = %v, want none", resp.BatchItemFailures)
+	}
+	if s := status(t, store, "u-1", "c-2"); s != models.StatusComplete {
+		t.Errorf("throttled claim status after retry = %s, want COMPLETE", s)
+	}
+	after, err := store.ListHistory(context.Background(), "u-2", "c-3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after) != len(before) {
+		t.Errorf("retry rewrote the claim that had already finalized (%d new audit events)", len(after)-len(before))
+	}
+}
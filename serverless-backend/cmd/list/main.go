@@ -3,6 +3,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
 	"net/http"
 
@@ -11,6 +12,8 @@ import (
 	"github.com/kylejryan/insurance-claim-upload-portal/internal/config"
 	"github.com/kylejryan/insurance-claim-upload-portal/internal/ddb"
 	"github.com/kylejryan/insurance-claim-upload-portal/internal/httpx"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/models"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/validate"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
@@ -19,8 +22,9 @@ import (
 
 // App holds the application state, including configuration and AWS clients.
 type App struct {
-	env     config.Env
-	ddbRepo *ddb.Repo
+	env      config.Env
+	verifier *authz.Verifier
+	ddbRepo  ddb.ClaimStore
 }
 
 // main initializes the app and starts the Lambda handler.
@@ -29,13 +33,22 @@ func main() {
 	if err := env.Validate(); err != nil { // <- ensure env present
 		log.Fatal(err)
 	}
+	if env.CursorSecret == "" {
+		log.Fatal("missing env CURSOR_SECRET")
+	}
 	cfg, _, err := awsutil.Load(context.Background(), env.Region)
 	if err != nil {
 		log.Fatal(err)
 	}
+	verifier, err := authz.NewVerifier(env.Region, env.UserPoolID, env.UserPoolClientID, env.JWKSFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	app := &App{
-		env:     env,
-		ddbRepo: &ddb.Repo{DB: dynamodb.NewFromConfig(cfg), Table: env.Table},
+		env:      env,
+		verifier: verifier,
+		ddbRepo:  &ddb.Repo{DB: dynamodb.NewFromConfig(cfg), Table: env.Table, AuditTable: env.AuditTable, CursorSecret: []byte(env.CursorSecret)},
 	}
 	lambda.Start(app.handler)
 }
@@ -43,19 +56,87 @@ func main() {
 // --- handler ---
 
 // handler processes the GET /claims request for the authenticated user.
+// Supports ?limit=1..100 and ?cursor=<next_cursor from a previous page>.
+// Adjusters and admins may pass ?user_id=<sub> to list another user's claims;
+// admins may pass ?include_withdrawn=true to see withdrawn claims.
+// Filters: ?tag=, ?client=, ?status= and ?from=/?to= (YYYY-MM-DD, inclusive, by creation
+// date). Filtered pages may be short; keep following next_cursor with the same filters.
 func (a *App) handler(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	sub, err := authz.FromAPIGWv1(req, a.env.DevBypassAuth)
+	user, err := authz.FromAPIGWv1(ctx, req, a.env.DevBypassAuth, a.verifier)
 	if err != nil {
 		return httpx.ErrorV1(http.StatusUnauthorized, "missing or invalid user")
 	}
 
-	items, err := a.ddbRepo.ListByUser(ctx, sub, 100)
+	sub := authz.TargetUser(user, req.QueryStringParameters)
+	if !authz.Can(user, authz.ActionRead, models.Claim{UserID: sub}) {
+		return httpx.ErrorV1(http.StatusForbidden, "forbidden")
+	}
+
+	limit, err := validate.PageLimit(req.QueryStringParameters["limit"])
+	if err != nil {
+		return httpx.ErrorV1(http.StatusBadRequest, err.Error())
+	}
+	includeWithdrawn := req.QueryStringParameters["include_withdrawn"] == "true"
+	if includeWithdrawn && !user.HasRole(models.RoleAdmin) {
+		return httpx.ErrorV1(http.StatusForbidden, "include_withdrawn requires admin")
+	}
+
+	opts, err := listFilters(req.QueryStringParameters)
+	if err != nil {
+		return httpx.ErrorV1(http.StatusBadRequest, err.Error())
+	}
+	if opts.Status == models.StatusWithdrawn && !user.HasRole(models.RoleAdmin) {
+		return httpx.ErrorV1(http.StatusForbidden, "status=WITHDRAWN requires admin")
+	}
+	opts.Limit = limit
+	opts.Cursor = req.QueryStringParameters["cursor"]
+	opts.IncludeWithdrawn = includeWithdrawn
+
+	items, next, err := a.ddbRepo.ListByUser(ctx, sub, opts)
+	if errors.Is(err, ddb.ErrBadCursor) {
+		return httpx.ErrorV1(http.StatusBadRequest, "invalid cursor")
+	}
 	if err != nil {
 		log.Printf("list ddb error: %v", err)
 		return httpx.ErrorV1(http.StatusInternalServerError, "db error")
 	}
+	views := make([]models.ClaimView, 0, len(items))
+	for _, c := range items {
+		views = append(views, authz.ClaimView(user, c))
+	}
 	return httpx.JSONV1(http.StatusOK, map[string]any{
-		"user_id": sub,
-		"items":   items,
+		"user_id":     sub,
+		"items":       views,
+		"next_cursor": next,
 	})
 }
+
+// listFilters reads the ?tag=, ?client=, ?status=, ?from= and ?to= filters.
+func listFilters(q map[string]string) (ddb.ListOptions, error) {
+	var opts ddb.ListOptions
+	if tag := q["tag"]; tag != "" {
+		if err := validate.Tag(tag); err != nil {
+			return opts, err
+		}
+		opts.Tag = tag
+	}
+	if client := q["client"]; client != "" {
+		if err := validate.ClientFilter(client); err != nil {
+			return opts, err
+		}
+		opts.Client = client
+	}
+	if raw := q["status"]; raw != "" {
+		status, err := validate.ClaimStatus(raw)
+		if err != nil {
+			return opts, err
+		}
+		opts.Status = status
+	}
+	from, to, err := validate.DateRange(q["from"], q["to"])
+	if err != nil {
+		return opts, err
+	}
+	opts.From, opts.To = from, to
+	return opts, nil
+}
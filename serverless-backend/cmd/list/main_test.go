@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/config"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/ddb"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/models"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/oklog/ulid/v2"
+)
+
+// listResponse is the GET /claims body.
+type listResponse struct {
+	UserID     string           `json:"user_id"`
+	Items      []map[string]any `json:"items"`
+	NextCursor string           `json:"next_cursor"`
+}
+
+// newTestApp returns an App over MemStore with the dev auth bypass on, and u-1's claims:
+// a tagged auto claim, a home claim and a withdrawn one, in that order.
+func newTestApp(t *testing.T) (*App, []string) {
+	t.Helper()
+	ctx := context.Background()
+	store := &ddb.MemStore{CursorSecret: []byte("test-secret")}
+	var ids []string
+	for _, c := range []models.Claim{
+		{Tags: []string{"auto"}, Client: "Acme"},
+		{Tags: []string{"home"}, Client: "Globex"},
+		{Tags: []string{"auto"}, Client: "Acme"},
+	} {
+		c.UserID, c.ClaimID, c.Status = "u-1", ulid.Make().String(), models.StatusUploading
+		c.S3Key = "user/u-1/" + c.ClaimID + ".txt"
+		if err := store.PutPending(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, c.ClaimID)
+	}
+	if _, err := store.Withdraw(ctx, "u-1", ids[2], ddb.AnyVersion, ddb.NowISO()); err != nil {
+		t.Fatal(err)
+	}
+	return &App{env: config.Env{DevBypassAuth: true}, ddbRepo: store}, ids
+}
+
+// list runs GET /claims as sub with groups and query, and decodes the response.
+func list(t *testing.T, a *App, sub, groups string, query map[string]string) (int, listResponse) {
+	t.Helper()
+	resp, err := a.handler(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/claims",
+		Headers:               map[string]string{"x-user-sub": sub, "x-user-groups": groups},
+		QueryStringParameters: query,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out listResponse
+	if err := json.Unmarshal([]byte(resp.Body), &out); err != nil {
+		t.Fatalf("decode %q: %v", resp.Body, err)
+	}
+	return resp.StatusCode, out
+}
+
+// claimIDs returns the claim_id of each item.
+func claimIDs(items []map[string]any) []any {
+	var ids []any
+	for _, it := range items {
+		ids = append(ids, it["claim_id"])
+	}
+	return ids
+}
+
+func TestListOwnClaims(t *testing.T) {
+	a, ids := newTestApp(t)
+	code, out := list(t, a, "u-1", "", nil)
+	if code != http.StatusOK {
+		t.Fatalf("status = %d", code)
+	}
+	if len(out.Items) != 2 || out.Items[0]["claim_id"] != ids[1] || out.Items[1]["claim_id"] != ids[0] {
+		t.Fatalf("items = %v, want %v newest first without the withdrawn claim", claimIDs(out.Items), ids[:2])
+	}
+	for _, it := range out.Items {
+		if _, ok := it["s3_key"]; ok {
+			t.Errorf("item %v is not a ClaimView: it exposes s3_key", it["claim_id"])
+		}
+		if it["version"] != float64(1) {
+			t.Errorf("item version = %v, want 1", it["version"])
+		}
+	}
+}
+
+func TestListFilters(t *testing.T) {
+	a, ids := newTestApp(t)
+	tests := []struct {
+		name   string
+		groups string
+		query  map[string]string
+		want   []any
+	}{
+		{"tag", "", map[string]string{"tag": "auto"}, []any{ids[0]}},
+		{"client", "", map[string]string{"client": "Globex"}, []any{ids[1]}},
+		{"withdrawn for admin", "admin", map[string]string{"include_withdrawn": "true", "tag": "auto"}, []any{ids[2], ids[0]}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			code, out := list(t, a, "u-1", tc.groups, tc.query)
+			if code != http.StatusOK {
+				t.Fatalf("status = %d", code)
+			}
+			got := claimIDs(out.Items)
+			if len(got) != len(tc.want) {
+				t.Fatalf("items = %v, want %v", got, tc.want)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Fatalf("items = %v, want %v", got, tc.want)
+				}
+			}
+		})
+	}
+}
+
+func TestListAccess(t *testing.T) {
+	a, _ := newTestApp(t)
+	tests := []struct {
+		name   string
+		sub    string
+		groups string
+		query  map[string]string
+		want   int
+	}{
+		{"anonymous", "", "", nil, http.StatusUnauthorized},
+		{"claimant lists another user", "u-2", "", map[string]string{"user_id": "u-1"}, http.StatusForbidden},
+		{"adjuster lists another user", "u-2", "adjuster", map[string]string{"user_id": "u-1"}, http.StatusOK},
+		{"withdrawn needs admin", "u-1", "", map[string]string{"include_withdrawn": "true"}, http.StatusForbidden},
+		{"bad limit", "u-1", "", map[string]string{"limit": "0"}, http.StatusBadRequest},
+		{"bad cursor", "u-1", "", map[string]string{"cursor": "forged"}, http.StatusBadRequest},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if code, _ := list(t, a, tc.sub, tc.groups, tc.query); code != tc.want {
+				t.Errorf("status = %d, want %d", code, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+// Package main completes or aborts multipart claim uploads started by presign:
+// POST /claims/{id}/multipart/complete and DELETE /claims/{id}/multipart.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/audit"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/authz"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/awsutil"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/config"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/ddb"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/httpx"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/models"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/s3io"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/validate"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+)
+
+// --------- request/response payloads ---------
+
+type completeRequest struct {
+	Parts []s3io.Part `json:"parts"`
+}
+
+type multipartResponse struct {
+	ClaimID string `json:"claim_id"`
+	Status  string `json:"status"`
+}
+
+// --------- app ---------
+
+// App holds the application state, including configuration and AWS clients.
+type App struct {
+	env      config.Env
+	verifier *authz.Verifier
+	s3c      s3io.MultipartAPI
+	ddbRepo  ddb.ClaimStore
+}
+
+// main initializes the app and starts the Lambda handler.
+func main() {
+	env := config.MustLoad()
+	if err := env.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	cfg, endpoint, err := awsutil.Load(context.Background(), env.Region)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	s3c := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if endpoint != "" {
+			o.UsePathStyle = true
+		}
+	})
+
+	verifier, err := authz.NewVerifier(env.Region, env.UserPoolID, env.UserPoolClientID, env.JWKSFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	app := &App{
+		env:      env,
+		verifier: verifier,
+		s3c:      s3c,
+		ddbRepo:  &ddb.Repo{DB: dynamodb.NewFromConfig(cfg), Table: env.Table, AuditTable: env.AuditTable},
+	}
+	lambda.Start(app.handler)
+}
+
+// --------- handler ---------
+
+// handler loads the caller's in-progress multipart claim and dispatches on method.
+// With If-Match, the claim must still be at that version (else 412).
+func (a *App) handler(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	user, err := authz.FromAPIGWv1(ctx, req, a.env.DevBypassAuth, a.verifier)
+	if err != nil {
+		return httpx.ErrorV1(http.StatusUnauthorized, "missing or invalid user")
+	}
+	ctx = audit.WithActor(ctx, audit.Actor{Sub: user.Sub, RequestID: req.RequestContext.RequestID})
+
+	claimID := req.PathParameters["id"]
+	if err := validate.ClaimID(claimID); err != nil {
+		return httpx.ErrorV1(http.StatusBadRequest, err.Error())
+	}
+
+	// Only the uploader drives their own upload, so there is no ?user_id= here.
+	claim, err := a.ddbRepo.GetClaim(ctx, user.Sub, claimID)
+	if errors.Is(err, ddb.ErrNotFound) {
+		return httpx.ErrorV1(http.StatusNotFound, "claim not found")
+	}
+	if err != nil {
+		log.Printf("multipart ddb error: %v", err)
+		return httpx.ErrorV1(http.StatusInternalServerError, "db error")
+	}
+	if !authz.Can(user, authz.ActionCreate, claim) {
+		return httpx.ErrorV1(http.StatusForbidden, "forbidden")
+	}
+	if claim.UploadID == "" {
+		return httpx.ErrorV1(http.StatusBadRequest, "claim is not a multipart upload")
+	}
+	if v, ok, err := httpx.IfMatch(req.Headers); err != nil {
+		return httpx.ErrorV1(http.StatusBadRequest, err.Error())
+	} else if ok && v != claim.Version {
+		return httpx.ErrorV1(http.StatusPreconditionFailed, "claim changed; reload and retry")
+	}
+
+	switch req.HTTPMethod {
+	case http.MethodPost:
+		return a.complete(ctx, claim, req.Body)
+	case http.MethodDelete:
+		return a.abort(ctx, claim)
+	}
+	return httpx.ErrorV1(http.StatusMethodNotAllowed, "method not allowed")
+}
+
+// complete assembles the parts. The indexer finalizes the record on the resulting
+// ObjectCreated:CompleteMultipartUpload event, so the claim is still UPLOADING here.
+func (a *App) complete(ctx context.Context, claim models.Claim, raw string) (events.APIGatewayProxyResponse, error) {
+	if claim.Status != models.StatusUploading {
+		return httpx.ErrorV1(http.StatusConflict, "claim is "+string(claim.Status))
+	}
+
+	var body completeRequest
+	if err := json.Unmarshal([]byte(raw), &body); err != nil {
+		return httpx.ErrorV1(http.StatusBadRequest, "invalid json")
+	}
+	if err := checkParts(body.Parts, claim.PartCount); err != nil {
+		return httpx.ErrorV1(http.StatusBadRequest, err.Error())
+	}
+
+	err := s3io.CompleteMultipart(ctx, a.s3c, a.env.Bucket, claim.S3Key, claim.UploadID, body.Parts)
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorFault() == smithy.FaultClient {
+			return httpx.ErrorV1(http.StatusBadRequest, apiErr.ErrorCode())
+		}
+		log.Printf("complete multipart %s: %v", claim.UploadID, err)
+		return httpx.ErrorV1(http.StatusInternalServerError, "storage error")
+	}
+
+	return httpx.JSONV1(http.StatusAccepted, multipartResponse{ClaimID: claim.ClaimID, Status: string(models.StatusUploading)})
+}
+
+// abort discards the parts and marks the claim FAILED. Only an UPLOADING claim can be
+// aborted; repeating an abort succeeds without touching it, anything else is 409.
+func (a *App) abort(ctx context.Context, claim models.Claim) (events.APIGatewayProxyResponse, error) {
+	if claim.Status == models.StatusFailed && claim.FailureReason == models.FailureUploadAborted {
+		return httpx.JSONV1(http.StatusOK, multipartResponse{ClaimID: claim.ClaimID, Status: string(models.StatusFailed)})
+	}
+	if claim.Status != models.StatusUploading {
+		return httpx.ErrorV1(http.StatusConflict, "claim is "+string(claim.Status))
+	}
+
+	err := s3io.AbortMultipart(ctx, a.s3c, a.env.Bucket, claim.S3Key, claim.UploadID)
+	var apiErr smithy.APIError
+	if err != nil && !(errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchUpload") {
+		log.Printf("abort multipart %s: %v", claim.UploadID, err)
+		return httpx.ErrorV1(http.StatusInternalServerError, "storage error")
+	}
+
+	err = a.ddbRepo.MarkFailed(ctx, claim.UserID, claim.ClaimID, claim.Version, models.StatusUploading, models.FailureUploadAborted, ddb.NowISO())
+	if errors.Is(err, ddb.ErrConflict) { // reaped, withdrawn or aborted since it was loaded
+		return httpx.ErrorV1(http.StatusConflict, "claim changed; reload and retry")
+	}
+	if err != nil {
+		log.Printf("abort ddb error: %v", err)
+		return httpx.ErrorV1(http.StatusInternalServerError, "db error")
+	}
+
+	return httpx.JSONV1(http.StatusOK, multipartResponse{ClaimID: claim.ClaimID, Status: string(models.StatusFailed)})
+}
+
+// checkParts validates part numbers are unique, within 1..count, and carry ETags.
+func checkParts(parts []s3io.Part, count int32) error {
+	if len(parts) == 0 || int32(len(parts)) > count {
+		return fmt.Errorf("provide 1..%d parts", count)
+	}
+	seen := make(map[int32]bool, len(parts))
+	for _, p := range parts {
+		if p.Number < 1 || p.Number > count || seen[p.Number] {
+			return fmt.Errorf("invalid part_number %d", p.Number)
+		}
+		if p.ETag == "" {
+			return fmt.Errorf("missing etag for part %d", p.Number)
+		}
+		seen[p.Number] = true
+	}
+	return nil
+}
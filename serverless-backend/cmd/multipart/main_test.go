@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/config"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/ddb"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/models"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/s3io"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/oklog/ulid/v2"
+)
+
+const testBucket = "claims-bucket"
+
+// newTestApp returns an App over MemStore and a fake bucket with the dev auth bypass on,
+// and a claim owned by u-1 whose three-part upload is in progress.
+func newTestApp(t *testing.T) (*App, *s3io.Fake, *ddb.MemStore, models.Claim) {
+	t.Helper()
+	objects, store := &s3io.Fake{}, &ddb.MemStore{}
+	claimID := ulid.Make().String()
+	key := s3io.BuildKey("u-1", claimID, s3io.ExtText)
+	claim := models.Claim{
+		UserID: "u-1", ClaimID: claimID, S3Key: key, Status: models.StatusUploading,
+		UploadID:  objects.StartUpload(testBucket, key, s3io.ContentTypeText, nil),
+		PartCount: 3,
+	}
+	if err := store.PutPending(context.Background(), claim); err != nil {
+		t.Fatal(err)
+	}
+	a := &App{env: config.Env{Bucket: testBucket, DevBypassAuth: true}, s3c: objects, ddbRepo: store}
+	return a, objects, store, claim
+}
+
+// call runs the handler as u-1: POST .../multipart/complete with body, or DELETE .../multipart.
+func call(t *testing.T, a *App, method, claimID, body string) events.APIGatewayProxyResponse {
+	t.Helper()
+	path := "/claims/" + claimID + "/multipart"
+	if method == http.MethodPost {
+		path += "/complete"
+	}
+	resp, err := a.handler(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod:     method,
+		Path:           path,
+		Headers:        map[string]string{"x-user-sub": "u-1"},
+		PathParameters: map[string]string{"id": claimID},
+		Body:           body,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+// status decodes the status field of a multipart response.
+func status(t *testing.T, resp events.APIGatewayProxyResponse) string {
+	t.Helper()
+	var out multipartResponse
+	if err := json.Unmarshal([]byte(resp.Body), &out); err != nil {
+		t.Fatalf("decode %q: %v", resp.Body, err)
+	}
+	return out.Status
+}
+
+func TestCompleteValidatesParts(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"invalid json", `{"parts":`},
+		{"no parts", `{"parts":[]}`},
+		{"too many parts", `{"parts":[{"part_number":1,"etag":"a"},{"part_number":2,"etag":"b"},{"part_number":3,"etag":"c"},{"part_number":3,"etag":"d"}]}`},
+		{"duplicate part", `{"parts":[{"part_number":1,"etag":"a"},{"part_number":1,"etag":"b"}]}`},
+		{"part zero", `{"parts":[{"part_number":0,"etag":"a"}]}`},
+		{"part past count", `{"parts":[{"part_number":4,"etag":"a"}]}`},
+		{"missing etag", `{"parts":[{"part_number":1,"etag":""}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, objects, _, claim := newTestApp(t)
+			resp := call(t, a, http.MethodPost, claim.ClaimID, tt.body)
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400 (%s)", resp.StatusCode, resp.Body)
+			}
+			if !objects.HasUpload(claim.UploadID) {
+				t.Error("upload ended by a rejected request")
+			}
+		})
+	}
+}
+
+func TestCompleteAssemblesParts(t *testing.T) {
+	a, objects, store, claim := newTestApp(t)
+	resp := call(t, a, http.MethodPost, claim.ClaimID, `{"parts":[{"part_number":2,"etag":"b"},{"part_number":1,"etag":"a"},{"part_number":3,"etag":"c"}]}`)
+	if resp.StatusCode != http.StatusAccepted || status(t, resp) != string(models.StatusUploading) {
+		t.Fatalf("status = %d %s, want 202 UPLOADING", resp.StatusCode, resp.Body)
+	}
+	if objects.HasUpload(claim.UploadID) {
+		t.Error("upload still in progress")
+	}
+	head, err := objects.HeadObject(context.Background(), &s3.HeadObjectInput{Bucket: aws.String(testBucket), Key: aws.String(claim.S3Key)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if etag := aws.ToString(head.ETag); !s3io.IsMultipartETag(etag) {
+		t.Errorf("etag = %s, want a multipart ETag", etag)
+	}
+	// The indexer finalizes the claim from the S3 event; completing does not.
+	if c, _ := store.GetClaim(context.Background(), "u-1", claim.ClaimID); c.Status != models.StatusUploading {
+		t.Errorf("claim status = %s, want UPLOADING", c.Status)
+	}
+}
+
+func TestCompleteNoSuchUpload(t *testing.T) {
+	a, objects, _, claim := newTestApp(t)
+	if err := s3io.AbortMultipart(context.Background(), objects, testBucket, claim.S3Key, claim.UploadID); err != nil {
+		t.Fatal(err)
+	}
+	resp := call(t, a, http.MethodPost, claim.ClaimID, `{"parts":[{"part_number":1,"etag":"a"}]}`)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400 (%s)", resp.StatusCode, resp.Body)
+	}
+}
+
+func TestAlreadyComplete(t *testing.T) {
+	ctx := context.Background()
+	a, _, store, claim := newTestApp(t)
+	if err := store.UpsertComplete(ctx, "u-1", claim.ClaimID, ddb.AnyVersion, claim.S3Key, 10, "etag-3", "", ddb.NowISO()); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, method := range []string{http.MethodPost, http.MethodDelete} {
+		resp := call(t, a, method, claim.ClaimID, `{"parts":[{"part_number":1,"etag":"a"}]}`)
+		if resp.StatusCode != http.StatusConflict {
+			t.Errorf("%s: status = %d, want 409 (%s)", method, resp.StatusCode, resp.Body)
+		}
+	}
+	if c, _ := store.GetClaim(ctx, "u-1", claim.ClaimID); c.Status != models.StatusComplete {
+		t.Errorf("claim status = %s, want COMPLETE", c.Status)
+	}
+}
+
+func TestAbort(t *testing.T) {
+	ctx := context.Background()
+	a, objects, store, claim := newTestApp(t)
+
+	resp := call(t, a, http.MethodDelete, claim.ClaimID, "")
+	if resp.StatusCode != http.StatusOK || status(t, resp) != string(models.StatusFailed) {
+		t.Fatalf("status = %d %s, want 200 FAILED", resp.StatusCode, resp.Body)
+	}
+	if objects.HasUpload(claim.UploadID) {
+		t.Error("upload not aborted")
+	}
+	c, err := store.GetClaim(ctx, "u-1", claim.ClaimID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Status != models.StatusFailed || c.FailureReason != models.FailureUploadAborted {
+		t.Fatalf("claim = %s (%s), want FAILED (%s)", c.Status, c.FailureReason, models.FailureUploadAborted)
+	}
+
+	t.Run("repeated", func(t *testing.T) {
+		resp := call(t, a, http.MethodDelete, claim.ClaimID, "")
+		if resp.StatusCode != http.StatusOK || status(t, resp) != string(models.StatusFailed) {
+			t.Fatalf("status = %d %s, want 200 FAILED", resp.StatusCode, resp.Body)
+		}
+		if again, _ := store.GetClaim(ctx, "u-1", claim.ClaimID); again.Version != c.Version {
+			t.Errorf("version = %d, want %d: a repeated abort must not write", again.Version, c.Version)
+		}
+	})
+
+	t.Run("complete after abort", func(t *testing.T) {
+		resp := call(t, a, http.MethodPost, claim.ClaimID, `{"parts":[{"part_number":1,"etag":"a"}]}`)
+		if resp.StatusCode != http.StatusConflict {
+			t.Errorf("status = %d, want 409 (%s)", resp.StatusCode, resp.Body)
+		}
+	})
+}
+
+func TestAbortNoSuchUpload(t *testing.T) {
+	// S3 already discarded the upload (lifecycle rule, or an abort whose DynamoDB write failed).
+	a, objects, store, claim := newTestApp(t)
+	if err := s3io.AbortMultipart(context.Background(), objects, testBucket, claim.S3Key, claim.UploadID); err != nil {
+		t.Fatal(err)
+	}
+	resp := call(t, a, http.MethodDelete, claim.ClaimID, "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", resp.StatusCode, resp.Body)
+	}
+	if c, _ := store.GetClaim(context.Background(), "u-1", claim.ClaimID); c.Status != models.StatusFailed {
+		t.Errorf("claim status = %s, want FAILED", c.Status)
+	}
+}
+
+func TestAbortOtherFailure(t *testing.T) {
+	// A reaped upload is FAILED for another reason; aborting it changes nothing.
+	ctx := context.Background()
+	a, _, store, claim := newTestApp(t)
+	if err := store.MarkFailed(ctx, "u-1", claim.ClaimID, ddb.AnyVersion, models.StatusUploading, models.FailureUploadExpired, ddb.NowISO()); err != nil {
+		t.Fatal(err)
+	}
+	resp := call(t, a, http.MethodDelete, claim.ClaimID, "")
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("status = %d, want 409 (%s)", resp.StatusCode, resp.Body)
+	}
+	if c, _ := store.GetClaim(ctx, "u-1", claim.ClaimID); c.FailureReason != models.FailureUploadExpired {
+		t.Errorf("failure_reason = %s, want %s", c.FailureReason, models.FailureUploadExpired)
+	}
+}
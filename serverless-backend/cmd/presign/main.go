@@ -1,4 +1,5 @@
-// Package main provides functionality to generate presigned URLs for uploading files to S3.
+// Package main provides functionality to generate presigned URLs for uploading files to S3:
+// POST /claims/presign starts a new claim, POST /claims/{id}/attachments adds a document to one.
 package main
 
 import (
@@ -7,9 +8,10 @@ import (
 	"errors"
 	"log"
 	"net/http"
+	"path/filepath"
 	"strings"
-	"time"
 
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/audit"
 	"github.com/kylejryan/insurance-claim-upload-portal/internal/authz"
 	"github.com/kylejryan/insurance-claim-upload-portal/internal/awsutil"
 	"github.com/kylejryan/insurance-claim-upload-portal/internal/config"
@@ -32,24 +34,56 @@ type presignRequest struct {
 	Filename    string   `json:"filename"`
 	Tags        []string `json:"tags"`
 	Client      string   `json:"client"`
-	ContentType string   `json:"content_type"` // e.g. "text/plain"
+	ContentType string   `json:"content_type"` // e.g. "application/pdf"; inferred from the extension if empty
+
+	// Multipart uploads: set Multipart and the total size to receive per-part URLs.
+	Multipart bool  `json:"multipart"`
+	SizeBytes int64 `json:"size_bytes"`
+}
+
+// attachmentRequest is the body of POST /claims/{id}/attachments; tags and client come from the claim.
+type attachmentRequest struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
 }
 
 type presignResponse struct {
 	ClaimID       string            `json:"claim_id"`
+	AttachmentID  string            `json:"attachment_id,omitempty"`
 	S3Key         string            `json:"s3_key"`
-	PresignedURL  string            `json:"presigned_url"`
+	UploadMethod  string            `json:"upload_method"` // "PUT", "POST" or "MULTIPART"
+	PresignedURL  string            `json:"presigned_url,omitempty"`
 	ExpiresIn     int               `json:"expires_in"`
 	ContentType   string            `json:"content_type"`
-	UploadHeaders map[string]string `json:"upload_headers"`
+	MaxBytes      int64             `json:"max_bytes"`
+	UploadHeaders map[string]string `json:"upload_headers,omitempty"` // PUT: send as request headers
+	FormFields    map[string]string `json:"form_fields,omitempty"`    // POST: send as multipart fields before "file"
+
+	// MULTIPART: PUT each part to its URL, then POST /claims/{id}/multipart/complete with the ETags.
+	UploadID string         `json:"upload_id,omitempty"`
+	PartSize int64          `json:"part_size,omitempty"`
+	Parts    []s3io.PartURL `json:"parts,omitempty"`
 }
 
+// uploadMethodMultipart marks a response carrying per-part URLs.
+const uploadMethodMultipart = "MULTIPART"
+
 // --------- app ---------
 
+// presigner is the part of the S3 presign client presign uses.
+type presigner interface {
+	s3io.Presigner
+	s3io.PostPresigner
+	s3io.PartPresigner
+}
+
 type App struct {
-	env     config.Env
-	s3p     *s3.PresignClient
-	ddbRepo *ddb.Repo
+	env      config.Env
+	types    validate.Allowlist
+	verifier *authz.Verifier
+	s3p      presigner
+	s3c      s3io.MultipartAPI
+	ddbRepo  ddb.ClaimStore
 }
 
 // main initializes the app and starts the Lambda handler.
@@ -70,10 +104,23 @@ func main() {
 		}
 	})
 
+	verifier, err := authz.NewVerifier(env.Region, env.UserPoolID, env.UserPoolClientID, env.JWKSFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	types, err := validate.NewAllowlist(env.AllowedTypes)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	app := &App{
-		env:     env,
-		s3p:     s3.NewPresignClient(s3c),
-		ddbRepo: &ddb.Repo{DB: dynamodb.NewFromConfig(cfg), Table: env.Table},
+		env:      env,
+		types:    types,
+		verifier: verifier,
+		s3p:      s3.NewPresignClient(s3c),
+		s3c:      s3c,
+		ddbRepo:  &ddb.Repo{DB: dynamodb.NewFromConfig(cfg), Table: env.Table, AuditTable: env.AuditTable},
 	}
 	lambda.Start(app.handler)
 }
@@ -82,101 +129,272 @@ func main() {
 
 // handler processes the POST /claims/presign request to generate a presigned S3 upload URL.
 func (a *App) handler(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	sub, err := authz.FromAPIGWv1(req, a.env.DevBypassAuth)
+	user, err := authz.FromAPIGWv1(ctx, req, a.env.DevBypassAuth, a.verifier)
 	if err != nil {
 		return httpx.ErrorV1(http.StatusUnauthorized, "missing or invalid user")
 	}
+	ctx = audit.WithActor(ctx, audit.Actor{Sub: user.Sub, RequestID: req.RequestContext.RequestID})
+	sub := user.Sub
 
-	body, err := a.parseAndValidateRequest(req.Body)
+	if claimID, ok := req.PathParameters["id"]; ok {
+		return a.handleAttachment(ctx, user, claimID, req.Body)
+	}
+
+	body, ft, err := a.parseAndValidateRequest(req.Body)
 	if err != nil {
 		return httpx.ErrorV1(http.StatusBadRequest, err.Error())
 	}
 
 	cid := ulid.Make().String()
-	key := s3io.BuildKey(sub, cid) // <- centralized S3 key builder
+	key := s3io.BuildKey(sub, cid, ft.Ext()) // <- centralized S3 key builder
 
-	if err := a.createPendingRecord(ctx, sub, cid, key, body); err != nil {
+	if body.Multipart {
+		return a.handleMultipart(ctx, sub, cid, key, body)
+	}
+
+	if err := a.ddbRepo.PutPending(ctx, pendingClaim(sub, cid, key, body)); err != nil {
 		log.Printf("ddb PutPending err: %v", err)
 		return httpx.ErrorV1(http.StatusInternalServerError, "db error")
 	}
 
-	url, ttl, err := a.generatePresignedURL(ctx, sub, cid, key, body)
+	resp := presignResponse{
+		ClaimID:     cid,
+		S3Key:       key,
+		ContentType: body.ContentType,
+		MaxBytes:    a.env.MaxUploadBytes,
+	}
+	if err := a.presignUpload(ctx, key, body.ContentType, uploadMeta(sub, cid, body), &resp); err != nil {
+		log.Printf("presign err: %v", err)
+		return httpx.ErrorV1(http.StatusInternalServerError, "presign error")
+	}
+
+	return httpx.JSONV1(http.StatusOK, resp)
+}
+
+// handleAttachment records a pending attachment on the caller's claim and presigns its upload.
+func (a *App) handleAttachment(ctx context.Context, user models.UserClaims, claimID, raw string) (events.APIGatewayProxyResponse, error) {
+	if err := validate.ClaimID(claimID); err != nil {
+		return httpx.ErrorV1(http.StatusBadRequest, err.Error())
+	}
+	body, ft, err := a.parseAttachmentRequest(raw)
+	if err != nil {
+		return httpx.ErrorV1(http.StatusBadRequest, err.Error())
+	}
+
+	claim, err := a.ddbRepo.GetClaim(ctx, user.Sub, claimID)
+	if errors.Is(err, ddb.ErrNotFound) {
+		return httpx.ErrorV1(http.StatusNotFound, "claim not found")
+	}
+	if err != nil {
+		log.Printf("attachment ddb error: %v", err)
+		return httpx.ErrorV1(http.StatusInternalServerError, "db error")
+	}
+	if !authz.Can(user, authz.ActionCreate, claim) {
+		return httpx.ErrorV1(http.StatusForbidden, "forbidden")
+	}
+	if claim.Status == models.StatusWithdrawn {
+		return httpx.ErrorV1(http.StatusConflict, "claim is withdrawn")
+	}
+	if !claim.Status.AcceptsAttachments() {
+		return httpx.ErrorV1(http.StatusConflict, "claim is "+strings.ToLower(string(claim.Status))+"; it takes no new attachments")
+	}
+	if claim.AttachmentCount >= models.MaxAttachments {
+		return httpx.ErrorV1(http.StatusConflict, "claim already has the maximum number of attachments")
+	}
+
+	aid := ulid.Make().String()
+	key := s3io.AttachmentKey(user.Sub, claimID, aid, ft.Ext())
+	err = a.ddbRepo.PutPendingAttachment(ctx, models.Attachment{
+		UserID:       user.Sub,
+		ClaimID:      claimID,
+		AttachmentID: aid,
+		Filename:     sanitizeName(body.Filename),
+		ContentType:  body.ContentType,
+		S3Key:        key,
+	})
+	if errors.Is(err, ddb.ErrConflict) {
+		return httpx.ErrorV1(http.StatusConflict, "claim changed; retry")
+	}
+	if errors.Is(err, ddb.ErrRetryable) {
+		log.Printf("ddb PutPendingAttachment busy: %v", err)
+		return httpx.ErrorV1(http.StatusServiceUnavailable, "busy; retry")
+	}
 	if err != nil {
+		log.Printf("ddb PutPendingAttachment err: %v", err)
+		return httpx.ErrorV1(http.StatusInternalServerError, "db error")
+	}
+
+	resp := presignResponse{
+		ClaimID:      claimID,
+		AttachmentID: aid,
+		S3Key:        key,
+		ContentType:  body.ContentType,
+		MaxBytes:     a.env.MaxUploadBytes,
+	}
+	meta := map[string]string{"claim_id": claimID, "user_id": user.Sub, "attachment_id": aid}
+	if err := a.presignUpload(ctx, key, body.ContentType, meta, &resp); err != nil {
 		log.Printf("presign err: %v", err)
 		return httpx.ErrorV1(http.StatusInternalServerError, "presign error")
 	}
 
-	// build the exact headers the client must send on the PUT
-	up := s3io.UploadHeaders(
-		sub,
-		cid,
-		body.ContentType,
-		strings.Join(body.Tags, ","),
-		body.Client,
-	)
+	return httpx.JSONV1(http.StatusOK, resp)
+}
+
+// presignUpload fills resp with a presigned POST or PUT depending on UPLOAD_MODE.
+func (a *App) presignUpload(ctx context.Context, key, contentType string, meta map[string]string, resp *presignResponse) error {
+	if a.env.UploadMode == config.UploadModePost {
+		return a.presignPostUpload(ctx, key, contentType, meta, resp)
+	}
+	return a.presignPutUpload(ctx, key, contentType, meta, resp)
+}
+
+// handleMultipart starts a multipart upload, records it, and returns presigned part URLs.
+// The upload is started first so the pending record can carry its upload ID.
+func (a *App) handleMultipart(ctx context.Context, sub, cid, key string, body presignRequest) (events.APIGatewayProxyResponse, error) {
+	parts := s3io.PartCount(body.SizeBytes, a.env.MultipartPartSize)
+
+	uploadID, err := s3io.CreateMultipart(ctx, a.s3c, a.env.Bucket, key, body.ContentType, uploadMeta(sub, cid, body))
+	if err != nil {
+		log.Printf("create multipart err: %v", err)
+		return httpx.ErrorV1(http.StatusInternalServerError, "presign error")
+	}
+
+	claim := pendingClaim(sub, cid, key, body)
+	claim.UploadID = uploadID
+	claim.PartCount = parts
+	if err := a.ddbRepo.PutPending(ctx, claim); err != nil {
+		log.Printf("ddb PutPending err: %v", err)
+		if aerr := s3io.AbortMultipart(ctx, a.s3c, a.env.Bucket, key, uploadID); aerr != nil {
+			log.Printf("abort multipart %s: %v", uploadID, aerr)
+		}
+		return httpx.ErrorV1(http.StatusInternalServerError, "db error")
+	}
+
+	urls, err := s3io.PresignParts(ctx, a.s3p, a.env.Bucket, key, uploadID, parts, a.env.PresignTTL)
+	if err != nil {
+		log.Printf("presign parts err: %v", err)
+		return httpx.ErrorV1(http.StatusInternalServerError, "presign error")
+	}
 
 	return httpx.JSONV1(http.StatusOK, presignResponse{
-		ClaimID:       cid,
-		S3Key:         key,
-		PresignedURL:  url,
-		ExpiresIn:     int(ttl.Seconds()),
-		ContentType:   body.ContentType,
-		UploadHeaders: up,
+		ClaimID:      cid,
+		S3Key:        key,
+		UploadMethod: uploadMethodMultipart,
+		ExpiresIn:    int(a.env.PresignTTL.Seconds()),
+		ContentType:  body.ContentType,
+		MaxBytes:     a.env.MaxMultipartBytes,
+		UploadID:     uploadID,
+		PartSize:     a.env.MultipartPartSize,
+		Parts:        urls,
 	})
 }
 
+// presignPutUpload fills resp with a presigned PUT URL and the exact headers the client must send.
+func (a *App) presignPutUpload(ctx context.Context, key, contentType string, meta map[string]string, resp *presignResponse) error {
+	url, ttl, err := s3io.PresignPut(ctx, a.s3p, a.env.Bucket, key, contentType, meta, a.env.PresignTTL)
+	if err != nil {
+		return err
+	}
+	resp.UploadMethod = http.MethodPut
+	resp.PresignedURL = url
+	resp.ExpiresIn = int(ttl.Seconds())
+	resp.UploadHeaders = s3io.UploadHeaders(contentType, meta)
+	return nil
+}
+
+// presignPostUpload fills resp with a presigned POST policy capped at MaxUploadBytes.
+func (a *App) presignPostUpload(ctx context.Context, key, contentType string, meta map[string]string, resp *presignResponse) error {
+	post, ttl, err := s3io.PresignPost(ctx, a.s3p, a.env.Bucket, key, contentType, meta, a.env.MaxUploadBytes, a.env.PresignTTL)
+	if err != nil {
+		return err
+	}
+	resp.UploadMethod = http.MethodPost
+	resp.PresignedURL = post.URL
+	resp.ExpiresIn = int(ttl.Seconds())
+	resp.FormFields = post.Fields
+	return nil
+}
+
 // --------- validation / business logic ---------
 
-// parseAndValidateRequest unmarshals and validates the incoming JSON request body.
-func (a *App) parseAndValidateRequest(body string) (presignRequest, error) {
+// parseAndValidateRequest unmarshals and validates the incoming JSON request body and
+// returns the allowed file type it declares. req.ContentType is normalized to that type.
+func (a *App) parseAndValidateRequest(body string) (presignRequest, validate.FileType, error) {
 	var req presignRequest
 	if err := json.Unmarshal([]byte(body), &req); err != nil {
-		return req, errors.New("invalid json")
-	}
-	if req.ContentType == "" {
-		req.ContentType = s3io.ContentTypeText // <- single source of truth
+		return req, validate.FileType{}, errors.New("invalid json")
 	}
+	req.ContentType = a.inferContentType(req.Filename, req.ContentType)
 	// Validators
-	if err := validate.FilenameTxt(req.Filename); err != nil {
-		return req, err
-	}
-	if err := validate.ContentTypeTextPlain(req.ContentType); err != nil {
-		return req, err
+	ft, err := a.types.Check(req.Filename, req.ContentType)
+	if err != nil {
+		return req, ft, err
 	}
+	req.ContentType = ft.ContentType
 	if err := validate.TagsOK(req.Tags); err != nil {
-		return req, err
+		return req, ft, err
 	}
 	if err := validate.ClientOK(req.Client); err != nil {
-		return req, err
+		return req, ft, err
+	}
+	if req.Multipart {
+		if err := validate.MultipartSize(req.SizeBytes, a.env.MaxMultipartBytes, a.env.MultipartPartSize); err != nil {
+			return req, ft, err
+		}
+	}
+	return req, ft, nil
+}
+
+// parseAttachmentRequest unmarshals an attachment request and checks its file type.
+func (a *App) parseAttachmentRequest(body string) (attachmentRequest, validate.FileType, error) {
+	var req attachmentRequest
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		return req, validate.FileType{}, errors.New("invalid json")
+	}
+	req.ContentType = a.inferContentType(req.Filename, req.ContentType)
+	ft, err := a.types.Check(req.Filename, req.ContentType)
+	if err != nil {
+		return req, ft, err
+	}
+	req.ContentType = ft.ContentType
+	return req, ft, nil
+}
+
+// inferContentType returns ct, or the allowed type for filename's extension when ct is empty.
+func (a *App) inferContentType(filename, ct string) string {
+	if ct != "" {
+		return ct
+	}
+	if t, ok := a.types.ByExt(strings.ToLower(filepath.Ext(filename))); ok {
+		return t.ContentType
 	}
-	return req, nil
+	return ""
 }
 
-// headerLookup performs a case-insensitive lookup of an HTTP header key.
-func (a *App) createPendingRecord(ctx context.Context, userID, claimID, s3Key string, req presignRequest) error {
+// pendingClaim builds the UPLOADING record written before the client uploads.
+func pendingClaim(userID, claimID, s3Key string, req presignRequest) models.Claim {
 	pk, sk := ddb.MakeKeys(userID, claimID)
-	claim := models.Claim{
+	return models.Claim{
 		PK: pk, SK: sk,
-		ClaimID:  claimID,
-		UserID:   userID,
-		Filename: sanitizeName(req.Filename),
-		S3Key:    s3Key,
-		Tags:     req.Tags,
-		Client:   req.Client,
-		Status:   models.StatusUploading,
+		ClaimID:     claimID,
+		UserID:      userID,
+		Filename:    sanitizeName(req.Filename),
+		ContentType: req.ContentType,
+		S3Key:       s3Key,
+		Tags:        req.Tags,
+		Client:      req.Client,
+		Status:      models.StatusUploading,
 	}
-	return a.ddbRepo.PutPending(ctx, claim)
 }
 
-// generatePresignedURL creates a presigned PUT URL with metadata.
-func (a *App) generatePresignedURL(ctx context.Context, userID, claimID, s3Key string, req presignRequest) (string, time.Duration, error) {
-	meta := map[string]string{
+// uploadMeta builds the x-amz-meta-* values bound into the presigned upload.
+func uploadMeta(userID, claimID string, req presignRequest) map[string]string {
+	return map[string]string{
 		"claim_id": claimID,
 		"user_id":  userID,
 		"tags":     strings.Join(req.Tags, ","),
 		"client":   req.Client,
 	}
-	return s3io.PresignPut(ctx, a.s3p, a.env.Bucket, s3Key, req.ContentType, meta, a.env.PresignTTL)
 }
 
 // sanitizeName ensures the filename is non-empty and trimmed; else generates a random name.
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/config"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/ddb"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/models"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/s3io"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/validate"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/oklog/ulid/v2"
+)
+
+// newTestApp returns an App over MemStore and a fake presigner, with the dev auth bypass on.
+func newTestApp(t *testing.T) (*App, *ddb.MemStore) {
+	t.Helper()
+	types, err := validate.NewAllowlist("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &ddb.MemStore{}
+	return &App{
+		env: config.Env{
+			Bucket:            "claims-bucket",
+			PresignTTL:        5 * time.Minute,
+			DevBypassAuth:     true,
+			UploadMode:        config.UploadModePut,
+			MaxUploadBytes:    10 << 20,
+			MultipartPartSize: 8 << 20,
+			MaxMultipartBytes: 1 << 30,
+		},
+		types:   types,
+		s3p:     &s3io.Fake{},
+		ddbRepo: store,
+	}, store
+}
+
+// call runs the handler as sub and decodes the JSON response.
+func call(t *testing.T, a *App, sub, claimID, body string) (int, map[string]any) {
+	t.Helper()
+	req := events.APIGatewayProxyRequest{Body: body, Headers: map[string]string{}}
+	if sub != "" {
+		req.Headers["x-user-sub"] = sub
+	}
+	if claimID != "" {
+		req.PathParameters = map[string]string{"id": claimID}
+	}
+	resp, err := a.handler(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]any
+	if err := json.Unmarshal([]byte(resp.Body), &out); err != nil {
+		t.Fatalf("decode %q: %v", resp.Body, err)
+	}
+	return resp.StatusCode, out
+}
+
+func TestPresignNewClaim(t *testing.T) {
+	a, store := newTestApp(t)
+	code, out := call(t, a, "u-1", "", `{"filename":"letter.txt","tags":["auto"],"client":"Acme"}`)
+	if code != http.StatusOK {
+		t.Fatalf("status = %d, body %v", code, out)
+	}
+	if out["upload_method"] != http.MethodPut || out["presigned_url"] == "" || out["content_type"] != s3io.ContentTypeText {
+		t.Errorf("response = %v", out)
+	}
+
+	cid, _ := out["claim_id"].(string)
+	c, err := store.GetClaim(context.Background(), "u-1", cid)
+	if err != nil {
+		t.Fatalf("GetClaim(%q): %v", cid, err)
+	}
+	if c.Status != models.StatusUploading || c.S3Key != out["s3_key"] || c.Client != "Acme" || len(c.Tags) != 1 {
+		t.Errorf("pending claim = %+v", c)
+	}
+}
+
+func TestPresignRejects(t *testing.T) {
+	a, _ := newTestApp(t)
+	tests := []struct {
+		name string
+		sub  string
+		body string
+		want int
+	}{
+		{"no user", "", `{"filename":"letter.txt"}`, http.StatusUnauthorized},
+		{"bad json", "u-1", `{`, http.StatusBadRequest},
+		{"disallowed type", "u-1", `{"filename":"run.exe","content_type":"application/x-msdownload"}`, http.StatusBadRequest},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if code, out := call(t, a, tc.sub, "", tc.body); code != tc.want {
+				t.Errorf("status = %d, want %d (%v)", code, tc.want, out)
+			}
+		})
+	}
+}
+
+func TestPresignAttachment(t *testing.T) {
+	ctx := context.Background()
+	a, store := newTestApp(t)
+	ok, failed := ulid.Make().String(), ulid.Make().String()
+	for _, c := range []models.Claim{
+		{UserID: "u-1", ClaimID: ok, Status: models.StatusUploading},
+		{UserID: "u-1", ClaimID: failed, Status: models.StatusUploading},
+	} {
+		if err := store.PutPending(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.MarkFailed(ctx, "u-1", failed, ddb.AnyVersion, models.StatusUploading, models.FailureTooLarge, ddb.NowISO()); err != nil {
+		t.Fatal(err)
+	}
+
+	body := `{"filename":"photo.txt"}`
+	code, out := call(t, a, "u-1", ok, body)
+	if code != http.StatusOK || out["attachment_id"] == "" {
+		t.Fatalf("status = %d, body %v", code, out)
+	}
+	c, err := store.GetClaim(ctx, "u-1", ok)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.AttachmentCount != 1 || c.AttachmentsStatus != models.StatusUploading {
+		t.Errorf("claim = %+v, want one uploading attachment", c)
+	}
+
+	if code, out := call(t, a, "u-1", failed, body); code != http.StatusConflict {
+		t.Errorf("failed claim: status = %d, want 409 (%v)", code, out)
+	}
+	if code, out := call(t, a, "u-2", ok, body); code != http.StatusNotFound {
+		t.Errorf("other user's claim: status = %d, want 404 (%v)", code, out)
+	}
+}
@@ -0,0 +1,106 @@
+// Package main expires claims and attachments stuck in UPLOADING whose presigned upload
+// window has long passed. It runs on a schedule (EventBridge) and flips them to FAILED.
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/audit"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/awsutil"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/config"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/ddb"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/models"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// maxPerRun bounds work per invocation; anything left is picked up on the next schedule.
+const maxPerRun = 500
+
+// report summarizes one reaper run.
+type report struct {
+	Cutoff  string `json:"cutoff"`
+	Scanned int    `json:"scanned"`
+	Expired int    `json:"expired"`
+	Skipped int    `json:"skipped"` // moved on (e.g. finalized) between query and update
+	Errors  int    `json:"errors"`
+}
+
+// App holds the application state, including configuration and AWS clients.
+type App struct {
+	env     config.Env
+	ddbRepo ddb.ClaimStore
+}
+
+// main initializes the app and starts the Lambda handler.
+func main() {
+	env := config.MustLoad()
+	if err := env.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	cfg, _, err := awsutil.Load(context.Background(), env.Region)
+	if err != nil {
+		log.Fatal(err)
+	}
+	app := &App{
+		env:     env,
+		ddbRepo: &ddb.Repo{DB: dynamodb.NewFromConfig(cfg), Table: env.Table, AuditTable: env.AuditTable},
+	}
+	lambda.Start(app.handler)
+}
+
+// ---- Handler ----
+
+// handler runs one sweep. The scheduled event payload is ignored.
+func (a *App) handler(ctx context.Context, _ events.CloudWatchEvent) (report, error) {
+	requestID := ""
+	if lc, ok := lambdacontext.FromContext(ctx); ok {
+		requestID = lc.AwsRequestID
+	}
+	ctx = audit.WithActor(ctx, audit.Actor{Sub: audit.SystemReaper, RequestID: requestID})
+	cutoff := time.Now().UTC().Add(-(a.env.PresignTTL + a.env.ReaperGrace)).Format(time.RFC3339)
+	rep := report{Cutoff: cutoff}
+
+	stale, err := a.ddbRepo.ListStaleUploads(ctx, cutoff, maxPerRun)
+	if err != nil {
+		return rep, err
+	}
+	rep.Scanned = len(stale)
+
+	now := ddb.NowISO()
+	for _, c := range stale {
+		err := a.ddbRepo.MarkFailed(ctx, c.UserID, c.ClaimID, ddb.AnyVersion, models.StatusUploading, models.FailureUploadExpired, now)
+		switch {
+		case err == nil:
+			rep.Expired++
+			a.rollUp(ctx, c.UserID, c.ClaimID)
+		case errors.Is(err, ddb.ErrConflict):
+			rep.Skipped++
+		default:
+			rep.Errors++
+			log.Printf("reaper: expire %s/%s: %v", c.UserID, c.ClaimID, err)
+		}
+	}
+
+	log.Printf("reaper: cutoff=%s scanned=%d expired=%d skipped=%d errors=%d",
+		rep.Cutoff, rep.Scanned, rep.Expired, rep.Skipped, rep.Errors)
+	return rep, nil
+}
+
+// rollUp refreshes the owning claim's attachments_status when an expired item is an
+// attachment. Failures are only logged; the next attachment change recomputes it.
+func (a *App) rollUp(ctx context.Context, userID, itemID string) {
+	claimID, _, ok := ddb.SplitItemID(itemID)
+	if !ok {
+		return
+	}
+	if _, err := a.ddbRepo.RollUpAttachments(ctx, userID, claimID); err != nil {
+		log.Printf("reaper: roll up %s/%s: %v", userID, claimID, err)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/audit"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/config"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/ddb"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/models"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/oklog/ulid/v2"
+)
+
+// seed creates, for u-1, an UPLOADING claim with an UPLOADING attachment and a COMPLETE
+// claim, and returns their IDs.
+func seed(t *testing.T, store *ddb.MemStore) (uploading, attachment, complete string) {
+	t.Helper()
+	ctx := context.Background()
+	uploading, complete, attachment = ulid.Make().String(), ulid.Make().String(), ulid.Make().String()
+	for _, id := range []string{uploading, complete} {
+		c := models.Claim{UserID: "u-1", ClaimID: id, S3Key: "user/u-1/" + id + ".txt", Status: models.StatusUploading}
+		if err := store.PutPending(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.UpsertComplete(ctx, "u-1", complete, ddb.AnyVersion, "user/u-1/"+complete+".txt", 10, "etag", "", ddb.NowISO()); err != nil {
+		t.Fatal(err)
+	}
+	att := models.Attachment{UserID: "u-1", ClaimID: complete, AttachmentID: attachment, Filename: "photo.png", S3Key: "user/u-1/" + complete + "/" + attachment + ".png"}
+	if err := store.PutPendingAttachment(ctx, att); err != nil {
+		t.Fatal(err)
+	}
+	return uploading, attachment, complete
+}
+
+func TestReaperAgeCutoff(t *testing.T) {
+	// Everything was just created, so nothing is older than the presign TTL plus grace.
+	store := &ddb.MemStore{}
+	uploading, _, _ := seed(t, store)
+	a := &App{env: config.Env{PresignTTL: 5 * time.Minute, ReaperGrace: 15 * time.Minute}, ddbRepo: store}
+
+	rep, err := a.handler(context.Background(), events.CloudWatchEvent{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Scanned != 0 || rep.Expired != 0 {
+		t.Errorf("report = %+v, want nothing scanned", rep)
+	}
+	if want := time.Now().UTC().Add(-20 * time.Minute); rep.Cutoff > want.Add(time.Minute).Format(time.RFC3339) || rep.Cutoff < want.Add(-time.Minute).Format(time.RFC3339) {
+		t.Errorf("cutoff = %s, want about %s", rep.Cutoff, want.Format(time.RFC3339))
+	}
+	c, err := store.GetClaim(context.Background(), "u-1", uploading)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Status != models.StatusUploading {
+		t.Errorf("status = %s, want UPLOADING", c.Status)
+	}
+}
+
+func TestReaperExpiresStaleUploads(t *testing.T) {
+	ctx := context.Background()
+	store := &ddb.MemStore{}
+	uploading, attachment, complete := seed(t, store)
+	// A negative grace puts the cutoff in the future, so every UPLOADING item is stale.
+	a := &App{env: config.Env{ReaperGrace: -time.Hour}, ddbRepo: store}
+
+	rep, err := a.handler(ctx, events.CloudWatchEvent{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Scanned != 2 || rep.Expired != 2 || rep.Skipped != 0 || rep.Errors != 0 {
+		t.Errorf("report = %+v, want the claim and the attachment expired", rep)
+	}
+
+	c, err := store.GetClaim(ctx, "u-1", uploading)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Status != models.StatusFailed || c.FailureReason != models.FailureUploadExpired {
+		t.Errorf("claim = %s (%s), want FAILED (%s)", c.Status, c.FailureReason, models.FailureUploadExpired)
+	}
+	evs, err := store.ListHistory(ctx, "u-1", uploading)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := evs[len(evs)-1]; last.Action != models.AuditFail || last.Actor != audit.SystemReaper {
+		t.Errorf("last event = %s by %s, want %s by %s", last.Action, last.Actor, models.AuditFail, audit.SystemReaper)
+	}
+
+	att, err := store.GetAttachment(ctx, "u-1", complete, attachment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if att.Status != models.StatusFailed {
+		t.Errorf("attachment status = %s, want FAILED", att.Status)
+	}
+	parent, err := store.GetClaim(ctx, "u-1", complete)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parent.Status != models.StatusComplete || parent.AttachmentsStatus != models.StatusFailed {
+		t.Errorf("parent = %s, attachments %s; want COMPLETE with attachments FAILED", parent.Status, parent.AttachmentsStatus)
+	}
+
+	// A second sweep finds nothing left to expire.
+	if rep, err = a.handler(ctx, events.CloudWatchEvent{}); err != nil || rep.Scanned != 0 {
+		t.Errorf("second run = %+v, %v; want nothing scanned", rep, err)
+	}
+}
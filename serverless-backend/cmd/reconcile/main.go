@@ -0,0 +1,273 @@
+// Package main checks that the bucket and the table agree. It lists every object under
+// user/ and scans every claim and attachment item, joins them by the IDs in the object key,
+// and prints a JSON diff report:
+//
+//   - orphan_object: an object with no record (report only; nothing says whose claim it is)
+//   - missed_event: a record still UPLOADING or SCANNING whose object exists
+//   - etag_mismatch: a COMPLETE record whose object has since been replaced
+//   - missing_object: a COMPLETE record whose object is gone
+//
+// With -fix, missed events are run through the indexer (verify, scan, UpsertComplete),
+// and COMPLETE records that no longer describe their object are marked FAILED
+// (object_changed or object_missing), since nothing vouches for what is there now.
+//
+//	reconcile [-fix] [-min-age 15m] [-prefix user/]
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"log"
+	"net/url"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/audit"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/awsutil"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/config"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/ddb"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/indexer"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/models"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/s3io"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// Finding kinds in the report.
+const (
+	kindOrphanObject  = "orphan_object"
+	kindMissedEvent   = "missed_event"
+	kindETagMismatch  = "etag_mismatch"
+	kindMissingObject = "missing_object"
+)
+
+// report summarizes one reconcile run.
+type report struct {
+	Bucket   string         `json:"bucket"`
+	Table    string         `json:"table"`
+	Fix      bool           `json:"fix"`
+	Objects  int            `json:"objects"`
+	Records  int            `json:"records"`
+	Counts   map[string]int `json:"counts"` // findings by kind
+	Fixed    int            `json:"fixed"`
+	Errors   int            `json:"errors"`
+	Findings []finding      `json:"findings,omitempty"`
+}
+
+// finding is one disagreement between the bucket and the table.
+type finding struct {
+	Kind       string `json:"kind"`
+	Key        string `json:"key,omitempty"`
+	UserID     string `json:"user_id,omitempty"`
+	ItemID     string `json:"item_id,omitempty"`
+	Status     string `json:"status,omitempty"`
+	RecordETag string `json:"record_etag,omitempty"`
+	ObjectETag string `json:"object_etag,omitempty"`
+	Fix        string `json:"fix,omitempty"` // what -fix did (or would do)
+	Error      string `json:"error,omitempty"`
+}
+
+// itemRef identifies a claim or attachment item.
+type itemRef struct{ userID, itemID string }
+
+// objectStore is the part of the S3 client reconcile uses: the listing, plus what the
+// indexer needs for -fix.
+type objectStore interface {
+	s3io.Lister
+	indexer.S3API
+}
+
+// App holds the reconcile options and clients.
+type App struct {
+	env    config.Env
+	s3c    objectStore
+	repo   ddb.ClaimStore
+	proc   *indexer.Processor
+	fix    bool
+	minAge time.Duration
+	rep    report
+}
+
+// main parses flags, builds the diff, applies fixes if asked and prints the report.
+// It exits non-zero if a fix failed.
+func main() {
+	fix := flag.Bool("fix", false, "repair what can be repaired instead of only reporting")
+	minAge := flag.Duration("min-age", 15*time.Minute, "ignore UPLOADING/SCANNING records younger than this (their event may be in flight)")
+	prefix := flag.String("prefix", "user/", "object prefix to list")
+	flag.Parse()
+
+	env := config.MustLoad()
+	if err := env.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx = audit.WithActor(ctx, audit.Actor{Sub: audit.SystemReconcile})
+
+	cfg, endpoint, err := awsutil.Load(ctx, env.Region)
+	if err != nil {
+		log.Fatal(err)
+	}
+	s3c := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if endpoint != "" {
+			o.UsePathStyle = true // localstack/dev friendliness
+		}
+	})
+	repo := &ddb.Repo{DB: dynamodb.NewFromConfig(cfg), Table: env.Table, AuditTable: env.AuditTable}
+	app := &App{
+		env:    env,
+		s3c:    s3c,
+		repo:   repo,
+		fix:    *fix,
+		minAge: *minAge,
+		rep:    report{Bucket: env.Bucket, Table: env.Table, Fix: *fix, Counts: map[string]int{}},
+	}
+	if *fix {
+		if app.proc, err = indexer.New(env, s3c, repo); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	err = app.run(ctx, *prefix)
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(app.rep)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if app.rep.Errors > 0 {
+		os.Exit(1)
+	}
+}
+
+// run lists the bucket, then scans the table, matching each record against its object.
+// Objects left unmatched at the end have no record.
+func (a *App) run(ctx context.Context, prefix string) error {
+	objects := make(map[itemRef]s3io.Object)
+	var unparsed []s3io.Object
+	err := s3io.ListObjects(ctx, a.s3c, a.env.Bucket, prefix, func(o s3io.Object) error {
+		a.rep.Objects++
+		userID, itemID, ok := ddb.ItemForKey(o.Key)
+		if !ok {
+			unparsed = append(unparsed, o)
+			return nil
+		}
+		objects[itemRef{userID, itemID}] = o
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().UTC().Add(-a.minAge).Format(time.RFC3339)
+	err = a.repo.ScanRecords(ctx, func(rec models.Claim) error {
+		a.rep.Records++
+		ref := itemRef{rec.UserID, rec.ClaimID} // claim_id holds the attachment item ID too
+		obj, found := objects[ref]
+		delete(objects, ref)
+		a.check(ctx, rec, obj, found, cutoff)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for ref, o := range objects {
+		a.add(finding{Kind: kindOrphanObject, Key: o.Key, UserID: ref.userID, ItemID: ref.itemID, ObjectETag: o.ETag})
+	}
+	for _, o := range unparsed {
+		a.add(finding{Kind: kindOrphanObject, Key: o.Key, ObjectETag: o.ETag})
+	}
+	return nil
+}
+
+// check compares one record with its object (if found) and records any finding.
+func (a *App) check(ctx context.Context, rec models.Claim, obj s3io.Object, found bool, cutoff string) {
+	f := finding{UserID: rec.UserID, ItemID: rec.ClaimID, Key: rec.S3Key, Status: string(rec.Status), RecordETag: rec.ETag}
+	if found {
+		f.Key, f.ObjectETag = obj.Key, obj.ETag
+	}
+
+	switch rec.Status {
+	case models.StatusUploading, models.StatusScanning:
+		if !found || rec.CreatedAt >= cutoff {
+			return // still uploading, or the reaper's to expire
+		}
+		f.Kind, f.Fix = kindMissedEvent, "reindex"
+		if a.fix {
+			f.Error = errString(a.reindex(ctx, obj.Key))
+		}
+	case models.StatusComplete:
+		switch {
+		case !found:
+			f.Kind, f.Fix = kindMissingObject, "mark_failed"
+			if a.fix {
+				f.Error = errString(a.markFailed(ctx, rec, models.FailureObjectMissing))
+			}
+		case obj.ETag != rec.ETag:
+			f.Kind, f.Fix = kindETagMismatch, "mark_failed"
+			if a.fix {
+				f.Error = errString(a.markFailed(ctx, rec, models.FailureObjectChanged))
+			}
+		default:
+			return
+		}
+	default:
+		return // FAILED, QUARANTINED and WITHDRAWN records make no claim about the object
+	}
+
+	if a.fix {
+		if f.Error == "" {
+			a.rep.Fixed++
+		} else {
+			a.rep.Errors++
+		}
+	}
+	a.add(f)
+}
+
+// add appends a finding to the report.
+func (a *App) add(f finding) {
+	a.rep.Counts[f.Kind]++
+	a.rep.Findings = append(a.rep.Findings, f)
+}
+
+// reindex runs the object through the indexer as if its ObjectCreated event had arrived.
+func (a *App) reindex(ctx context.Context, key string) error {
+	var rec events.S3EventRecord
+	rec.EventName = "ObjectCreated:Put"
+	rec.S3.Bucket.Name = a.env.Bucket
+	rec.S3.Object.Key = url.QueryEscape(key) // event keys are URL-encoded
+	return a.proc.ProcessRecord(ctx, rec)
+}
+
+// markFailed moves a COMPLETE record to FAILED and refreshes its claim's roll-up.
+// A record that moved on since the scan (a newer version) is left alone.
+func (a *App) markFailed(ctx context.Context, rec models.Claim, reason string) error {
+	err := a.repo.MarkFailed(ctx, rec.UserID, rec.ClaimID, rec.Version, models.StatusComplete, reason, ddb.NowISO())
+	if errors.Is(err, ddb.ErrConflict) {
+		log.Printf("reconcile: %s/%s changed since the scan; skipping", rec.UserID, rec.ClaimID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if claimID, _, ok := ddb.SplitItemID(rec.ClaimID); ok {
+		_, err = a.repo.RollUpAttachments(ctx, rec.UserID, claimID)
+	}
+	return err
+}
+
+// errString returns err's message, or "" for nil.
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/config"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/ddb"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/indexer"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/models"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/s3io"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/scan"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/search"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+const testBucket = "claims-bucket"
+
+const letter = "Dear Claims Adjuster, my car was hit."
+
+// newTestApp returns an App over a fake bucket and MemStore. A negative minAge puts the
+// cutoff in the future, so every UPLOADING record counts as old enough.
+func newTestApp(t *testing.T, fix bool, minAge time.Duration) (*App, *s3io.Fake, *ddb.MemStore) {
+	t.Helper()
+	objects, store := &s3io.Fake{}, &ddb.MemStore{}
+	env := config.Env{
+		Bucket:            testBucket,
+		MaxUploadBytes:    1 << 20,
+		MaxMultipartBytes: 1 << 30,
+		Scanner:           scan.BackendNone,
+		SearchBackend:     search.BackendNone,
+	}
+	a := &App{env: env, s3c: objects, repo: store, fix: fix, minAge: minAge, rep: report{Fix: fix, Counts: map[string]int{}}}
+	if fix {
+		proc, err := indexer.New(env, objects, store)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a.proc = proc
+	}
+	return a, objects, store
+}
+
+// pending records an UPLOADING claim and, if body is not empty, puts its object.
+func pending(t *testing.T, objects *s3io.Fake, store *ddb.MemStore, userID, claimID, body string) string {
+	t.Helper()
+	key := s3io.BuildKey(userID, claimID, s3io.ExtText)
+	if err := store.PutPending(context.Background(), models.Claim{UserID: userID, ClaimID: claimID, S3Key: key, Status: models.StatusUploading}); err != nil {
+		t.Fatal(err)
+	}
+	if body != "" {
+		objects.Put(testBucket, key, s3io.ContentTypeText, []byte(body), map[string]string{"user_id": userID, "claim_id": claimID})
+	}
+	return key
+}
+
+// complete records a COMPLETE claim whose ETag matches its object.
+func complete(t *testing.T, objects *s3io.Fake, store *ddb.MemStore, userID, claimID string) string {
+	t.Helper()
+	ctx := context.Background()
+	key := pending(t, objects, store, userID, claimID, letter)
+	head, err := objects.HeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String(testBucket), Key: aws.String(key)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	etag := strings.Trim(aws.ToString(head.ETag), `"`)
+	if err := store.UpsertComplete(ctx, userID, claimID, ddb.AnyVersion, key, int64(len(letter)), etag, "", ddb.NowISO()); err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// seed sets up one record or object per finding kind, plus a consistent claim and an
+// UPLOADING claim whose object has not arrived, neither of which is a finding.
+func seed(t *testing.T, objects *s3io.Fake, store *ddb.MemStore) {
+	t.Helper()
+	ctx := context.Background()
+	complete(t, objects, store, "u-1", "c-ok")
+	pending(t, objects, store, "u-1", "c-waiting", "")
+	objects.Put(testBucket, s3io.BuildKey("u-1", "c-orphan", s3io.ExtText), "text/plain", []byte(letter), nil)
+	objects.Put(testBucket, "user/stray.txt", "text/plain", []byte(letter), nil)
+	pending(t, objects, store, "u-1", "c-missed", letter)
+
+	changed := complete(t, objects, store, "u-1", "c-changed")
+	objects.Put(testBucket, changed, s3io.ContentTypeText, []byte("Dear Claims Adjuster, never mind."), nil)
+
+	gone := complete(t, objects, store, "u-2", "c-gone")
+	if _, err := objects.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: aws.String(testBucket), Key: aws.String(gone)}); err != nil {
+		t.Fatal(err)
+	}
+
+	// An attachment recorded COMPLETE whose object never existed.
+	att := models.Attachment{UserID: "u-1", ClaimID: "c-ok", AttachmentID: "a-1", Filename: "photo.png", S3Key: s3io.AttachmentKey("u-1", "c-ok", "a-1", ".png")}
+	if err := store.PutPendingAttachment(ctx, att); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpsertComplete(ctx, "u-1", ddb.AttachmentItemID("c-ok", "a-1"), ddb.AnyVersion, att.S3Key, 10, "etag-a", "", ddb.NowISO()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.RollUpAttachments(ctx, "u-1", "c-ok"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// findings returns the report's findings as "kind item" strings, sorted.
+func findings(rep report) []string {
+	var out []string
+	for _, f := range rep.Findings {
+		item := f.ItemID
+		if item == "" {
+			item = f.Key
+		}
+		out = append(out, f.Kind+" "+item)
+	}
+	slices.Sort(out)
+	return out
+}
+
+func TestReconcileReport(t *testing.T) {
+	a, objects, store := newTestApp(t, false, -time.Hour)
+	seed(t, objects, store)
+	if err := a.run(context.Background(), "user/"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		kindETagMismatch + " c-changed",
+		kindMissedEvent + " c-missed",
+		kindMissingObject + " " + ddb.AttachmentItemID("c-ok", "a-1"),
+		kindMissingObject + " c-gone",
+		kindOrphanObject + " c-orphan",
+		kindOrphanObject + " user/stray.txt",
+	}
+	slices.Sort(want)
+	if got := findings(a.rep); !slices.Equal(got, want) {
+		t.Errorf("findings = %v, want %v", got, want)
+	}
+	if a.rep.Fixed != 0 || a.rep.Errors != 0 {
+		t.Errorf("report = %+v, want nothing fixed without -fix", a.rep)
+	}
+	if a.rep.Counts[kindOrphanObject] != 2 || a.rep.Counts[kindMissingObject] != 2 {
+		t.Errorf("counts = %v, want 2 orphan_object and 2 missing_object", a.rep.Counts)
+	}
+	for _, f := range a.rep.Findings {
+		if f.Kind == kindETagMismatch && (f.RecordETag == "" || f.RecordETag == f.ObjectETag) {
+			t.Errorf("etag_mismatch %+v, want the record and object ETags to differ", f)
+		}
+	}
+	// A report-only run changes nothing.
+	if c, _ := store.GetClaim(context.Background(), "u-1", "c-changed"); c.Status != models.StatusComplete {
+		t.Errorf("c-changed status = %s, want COMPLETE", c.Status)
+	}
+}
+
+func TestReconcileMinAge(t *testing.T) {
+	// Everything was just created, so the missed event may still be in flight.
+	a, objects, store := newTestApp(t, false, time.Hour)
+	seed(t, objects, store)
+	if err := a.run(context.Background(), "user/"); err != nil {
+		t.Fatal(err)
+	}
+	if n := a.rep.Counts[kindMissedEvent]; n != 0 {
+		t.Errorf("missed_event = %d, want 0 for a young record", n)
+	}
+}
+
+func TestReconcileFix(t *testing.T) {
+	ctx := context.Background()
+	a, objects, store := newTestApp(t, true, -time.Hour)
+	seed(t, objects, store)
+	if err := a.run(ctx, "user/"); err != nil {
+		t.Fatal(err)
+	}
+	if a.rep.Fixed != 4 || a.rep.Errors != 0 {
+		t.Errorf("fixed %d, errors %d; want 4 fixed, no errors (%+v)", a.rep.Fixed, a.rep.Errors, a.rep.Findings)
+	}
+
+	tests := []struct {
+		user, item string
+		status     models.ClaimStatus
+		reason     string
+	}{
+		{"u-1", "c-missed", models.StatusComplete, ""},
+		{"u-1", "c-changed", models.StatusFailed, models.FailureObjectChanged},
+		{"u-2", "c-gone", models.StatusFailed, models.FailureObjectMissing},
+		{"u-1", ddb.AttachmentItemID("c-ok", "a-1"), models.StatusFailed, models.FailureObjectMissing},
+		{"u-1", "c-ok", models.StatusComplete, ""},
+	}
+	for _, tt := range tests {
+		c, err := store.GetClaim(ctx, tt.user, tt.item)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.item, err)
+		}
+		if c.Status != tt.status || c.FailureReason != tt.reason {
+			t.Errorf("%s = %s (%s), want %s (%s)", tt.item, c.Status, c.FailureReason, tt.status, tt.reason)
+		}
+	}
+	if c, _ := store.GetClaim(ctx, "u-1", "c-ok"); c.AttachmentsStatus != models.StatusFailed {
+		t.Errorf("c-ok attachments = %s, want FAILED after the roll-up", c.AttachmentsStatus)
+	}
+	// Orphans are reported, never fixed.
+	if _, ok := objects.Object(testBucket, "user/stray.txt"); !ok {
+		t.Error("orphan object deleted")
+	}
+
+	// A second run finds only the orphans.
+	a.rep = report{Fix: true, Counts: map[string]int{}}
+	if err := a.run(ctx, "user/"); err != nil {
+		t.Fatal(err)
+	}
+	if len(a.rep.Findings) != 2 || a.rep.Counts[kindOrphanObject] != 2 {
+		t.Errorf("second run findings = %v, want only the two orphans", findings(a.rep))
+	}
+}
+
+func TestMarkFailedSkipsChangedRecord(t *testing.T) {
+	ctx := context.Background()
+	a, objects, store := newTestApp(t, true, -time.Hour)
+	complete(t, objects, store, "u-1", "c-1")
+	stale, err := store.GetClaim(ctx, "u-1", "c-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The claim moves on after the scan read it.
+	if _, err := store.PutAnalysis(ctx, "u-1", "c-1", ddb.AnyVersion, ddb.Analysis{Category: "auto"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.markFailed(ctx, stale, models.FailureObjectMissing); err != nil {
+		t.Fatalf("markFailed = %v, want nil for a record that changed", err)
+	}
+	if c, _ := store.GetClaim(ctx, "u-1", "c-1"); c.Status != models.StatusComplete {
+		t.Errorf("status = %s, want COMPLETE", c.Status)
+	}
+}
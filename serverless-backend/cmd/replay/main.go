@@ -0,0 +1,387 @@
+// Package main re-drives failed indexer events. It reads S3 event payloads from the
+// indexer's dead-letter queue (or a local JSONL file), runs each matching record through
+// the same internal/indexer path the Lambda uses, and prints a JSON summary.
+//
+//	replay -queue-url https://sqs.../claims-indexer-dlq [-dry-run] [-user-prefix U] [-claim-prefix C]
+//	replay -file failed.jsonl [-dry-run]
+//
+// Each file line is either an SQS message (as returned by ReceiveMessage) or a bare S3
+// event. DLQ messages are deleted once every record in them has been replayed or dropped
+// as unrecoverable; messages with records outside the filter, or with records that failed
+// again transiently, stay in the queue. A dry run deletes nothing and makes every message
+// it received visible again before it exits.
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/audit"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/awsutil"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/config"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/ddb"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/indexer"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// Record outcomes in the report.
+const (
+	outcomeWouldReplay = "would_replay" // dry run
+	outcomeReplayed    = "replayed"
+	outcomeDropped     = "dropped" // permanent error; replaying again cannot help
+	outcomeFailed      = "failed"  // transient error; left for another run
+)
+
+// report summarizes one replay run.
+type report struct {
+	Source   string   `json:"source"`
+	DryRun   bool     `json:"dry_run"`
+	Messages int      `json:"messages"`
+	Invalid  int      `json:"invalid"` // messages that are not S3 events
+	Records  int      `json:"records"`
+	Skipped  int      `json:"skipped"` // not an ObjectCreated record, or outside the filter
+	Matched  int      `json:"matched"`
+	Replayed int      `json:"replayed"`
+	Dropped  int      `json:"dropped"`
+	Failed   int      `json:"failed"`
+	Deleted  int      `json:"deleted"` // DLQ messages removed after a successful replay
+	Items    []result `json:"items,omitempty"`
+}
+
+// result is the outcome for one matching record.
+type result struct {
+	MessageID string `json:"message_id"`
+	Key       string `json:"key"`
+	UserID    string `json:"user_id,omitempty"`
+	ItemID    string `json:"item_id,omitempty"`
+	Status    string `json:"status,omitempty"` // current record status; dry run only
+	Outcome   string `json:"outcome"`
+	Error     string `json:"error,omitempty"`
+}
+
+// message is one failed delivery: its ID, the S3 event JSON, and the SQS receipt
+// handle when it came from a queue.
+type message struct {
+	ID      string
+	Body    string
+	Receipt string
+}
+
+// filter selects records by user and claim ID prefix; empty prefixes match everything.
+type filter struct {
+	UserPrefix  string
+	ClaimPrefix string
+}
+
+// queue is the part of the SQS client replay uses to drain the DLQ.
+type queue interface {
+	ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error)
+	DeleteMessage(ctx context.Context, params *sqs.DeleteMessageInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error)
+	ChangeMessageVisibilityBatch(ctx context.Context, params *sqs.ChangeMessageVisibilityBatchInput, optFns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityBatchOutput, error)
+}
+
+// App holds the replay options and clients.
+type App struct {
+	proc     *indexer.Processor
+	repo     ddb.ClaimStore
+	sqsc     queue
+	queueURL string
+	dryRun   bool
+	filter   filter
+	rep      report
+}
+
+// main parses flags, replays every message from the chosen source and prints the report.
+// It exits non-zero if any record failed transiently.
+func main() {
+	queueURL := flag.String("queue-url", os.Getenv("INDEXER_DLQ_URL"), "dead-letter queue to drain (default $INDEXER_DLQ_URL)")
+	file := flag.String("file", "", "JSONL file of SQS messages or S3 events; read instead of the queue")
+	dryRun := flag.Bool("dry-run", false, "report what would be replayed without changing anything")
+	userPrefix := flag.String("user-prefix", "", "only replay records whose user ID starts with this")
+	claimPrefix := flag.String("claim-prefix", "", "only replay records whose claim ID starts with this")
+	limit := flag.Int("limit", 0, "stop after this many messages (0 = all)")
+	visibility := flag.Duration("visibility", 5*time.Minute, "how long received DLQ messages stay hidden; must outlast the run")
+	flag.Parse()
+
+	if *queueURL == "" && *file == "" {
+		log.Fatal("replay: one of -queue-url or -file is required")
+	}
+
+	env := config.MustLoad()
+	if err := env.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	cfg, endpoint, err := awsutil.Load(ctx, env.Region)
+	if err != nil {
+		log.Fatal(err)
+	}
+	s3c := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if endpoint != "" {
+			o.UsePathStyle = true // localstack/dev friendliness
+		}
+	})
+	repo := &ddb.Repo{DB: dynamodb.NewFromConfig(cfg), Table: env.Table, AuditTable: env.AuditTable}
+	proc, err := indexer.New(env, s3c, repo)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	app := &App{
+		proc:   proc,
+		repo:   repo,
+		dryRun: *dryRun,
+		filter: filter{UserPrefix: *userPrefix, ClaimPrefix: *claimPrefix},
+		rep:    report{DryRun: *dryRun},
+	}
+	if *file != "" {
+		app.rep.Source = *file
+		err = app.replayFile(ctx, *file, *limit)
+	} else {
+		app.rep.Source = *queueURL
+		app.queueURL = *queueURL
+		app.sqsc = sqs.NewFromConfig(cfg)
+		err = app.replayQueue(ctx, *limit, *visibility)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(app.rep)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if app.rep.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// ---- Sources ----
+
+// replayQueue receives messages from the DLQ until it is empty (or limit is reached).
+// Received messages stay hidden for visibility, so nothing is seen twice in one run; a dry
+// run unhides them all again on the way out.
+func (a *App) replayQueue(ctx context.Context, limit int, visibility time.Duration) error {
+	seen := make(map[string]bool)
+	var held []string // receipt handles to release; dry run only
+	if a.dryRun {
+		defer func() { a.release(context.WithoutCancel(ctx), held) }()
+	}
+	for limit == 0 || a.rep.Messages < limit {
+		batch := int32(10)
+		if limit > 0 && limit-a.rep.Messages < 10 {
+			batch = int32(limit - a.rep.Messages)
+		}
+		out, err := a.sqsc.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:            &a.queueURL,
+			MaxNumberOfMessages: batch,
+			VisibilityTimeout:   int32(visibility / time.Second),
+			WaitTimeSeconds:     1,
+		})
+		if err != nil {
+			return fmt.Errorf("receive: %w", err)
+		}
+		if len(out.Messages) == 0 {
+			return nil
+		}
+		fresh := false
+		for _, m := range out.Messages {
+			id := aws.ToString(m.MessageId)
+			if seen[id] { // visibility ran out mid-run
+				continue
+			}
+			seen[id], fresh = true, true
+			if a.dryRun {
+				held = append(held, aws.ToString(m.ReceiptHandle))
+			}
+			a.replayMessage(ctx, message{ID: id, Body: aws.ToString(m.Body), Receipt: aws.ToString(m.ReceiptHandle)})
+		}
+		if !fresh { // only messages left behind earlier in this run
+			return nil
+		}
+	}
+	return nil
+}
+
+// release makes received messages visible again at once, so a dry run leaves the queue
+// as it found it (apart from each message's receive count).
+func (a *App) release(ctx context.Context, receipts []string) {
+	for len(receipts) > 0 {
+		n := min(len(receipts), 10) // batch limit
+		entries := make([]sqstypes.ChangeMessageVisibilityBatchRequestEntry, n)
+		for i, r := range receipts[:n] {
+			entries[i] = sqstypes.ChangeMessageVisibilityBatchRequestEntry{
+				Id:                aws.String(fmt.Sprint(i)),
+				ReceiptHandle:     aws.String(r),
+				VisibilityTimeout: 0,
+			}
+		}
+		out, err := a.sqsc.ChangeMessageVisibilityBatch(ctx, &sqs.ChangeMessageVisibilityBatchInput{QueueUrl: &a.queueURL, Entries: entries})
+		switch {
+		case err != nil:
+			log.Printf("replay: release %d messages: %v", n, err)
+		case len(out.Failed) > 0:
+			log.Printf("replay: release: %d of %d messages stay hidden until their visibility runs out", len(out.Failed), n)
+		}
+		receipts = receipts[n:]
+	}
+}
+
+// replayFile replays every line of a JSONL file (or up to limit lines).
+func (a *App) replayFile(ctx context.Context, path string, limit int) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return a.replayLines(ctx, f, limit)
+}
+
+// replayLines decodes one message per non-blank line of r.
+func (a *App) replayLines(ctx context.Context, r io.Reader, limit int) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64<<10), 1<<20) // SQS bodies are at most 256 KiB
+	line := 0
+	for sc.Scan() {
+		line++
+		raw := strings.TrimSpace(sc.Text())
+		if raw == "" {
+			continue
+		}
+		if limit > 0 && a.rep.Messages >= limit {
+			return nil
+		}
+		a.replayMessage(ctx, decodeLine(raw, line))
+	}
+	return sc.Err()
+}
+
+// decodeLine accepts a bare S3 event or an SQS message wrapping one. SQS messages from
+// the API ("Body", "MessageId") and from Lambda events ("body", "messageId") both decode.
+func decodeLine(raw string, line int) message {
+	var ev events.S3Event
+	if err := json.Unmarshal([]byte(raw), &ev); err == nil && len(ev.Records) > 0 {
+		return message{ID: fmt.Sprintf("line-%d", line), Body: raw}
+	}
+	var m events.SQSMessage
+	if err := json.Unmarshal([]byte(raw), &m); err == nil && m.Body != "" {
+		if m.MessageId == "" {
+			m.MessageId = fmt.Sprintf("line-%d", line)
+		}
+		return message{ID: m.MessageId, Body: m.Body}
+	}
+	return message{ID: fmt.Sprintf("line-%d", line), Body: raw} // reported as invalid
+}
+
+// ---- Replay ----
+
+// replayMessage replays every matching record of one message and, for DLQ messages,
+// deletes the message if nothing in it is left to do.
+func (a *App) replayMessage(ctx context.Context, m message) {
+	a.rep.Messages++
+	var ev events.S3Event
+	if err := json.Unmarshal([]byte(m.Body), &ev); err != nil || len(ev.Records) == 0 {
+		log.Printf("replay: message %s is not an S3 event; leaving it", m.ID)
+		a.rep.Invalid++
+		return
+	}
+
+	done := true
+	for _, rec := range ev.Records {
+		a.rep.Records++
+		if !indexer.Eligible(rec) {
+			a.rep.Skipped++
+			continue
+		}
+		key, _ := url.QueryUnescape(rec.S3.Object.Key)
+		userID, itemID, ok := a.filter.match(key)
+		if !ok {
+			a.rep.Skipped++
+			done = false // the rest of the message is someone else's
+			continue
+		}
+		a.rep.Matched++
+		res := result{MessageID: m.ID, Key: key, UserID: userID, ItemID: itemID}
+		a.replayRecord(ctx, rec, &res)
+		if res.Outcome != outcomeReplayed && res.Outcome != outcomeDropped {
+			done = false
+		}
+		a.rep.Items = append(a.rep.Items, res)
+	}
+
+	if done && !a.dryRun && m.Receipt != "" {
+		if _, err := a.sqsc.DeleteMessage(ctx, &sqs.DeleteMessageInput{QueueUrl: &a.queueURL, ReceiptHandle: &m.Receipt}); err != nil {
+			log.Printf("replay: delete message %s: %v", m.ID, err)
+			return
+		}
+		a.rep.Deleted++
+	}
+}
+
+// replayRecord runs one record through the indexer, or in a dry run looks up the status
+// of its claim or attachment, and records the outcome in res.
+func (a *App) replayRecord(ctx context.Context, rec events.S3EventRecord, res *result) {
+	if a.dryRun {
+		res.Outcome = outcomeWouldReplay
+		if res.ItemID == "" {
+			return
+		}
+		c, err := a.repo.GetClaim(ctx, res.UserID, res.ItemID)
+		switch {
+		case errors.Is(err, ddb.ErrNotFound):
+			res.Status = "missing"
+		case err != nil:
+			res.Error = err.Error()
+		default:
+			res.Status = string(c.Status)
+		}
+		return
+	}
+
+	ctx = audit.WithActor(ctx, audit.Actor{Sub: audit.SystemReplay, RequestID: res.MessageID})
+	err := a.proc.ProcessRecord(ctx, rec)
+	switch {
+	case err == nil:
+		res.Outcome = outcomeReplayed
+		a.rep.Replayed++
+	case indexer.IsPermanent(err):
+		res.Outcome, res.Error = outcomeDropped, err.Error()
+		a.rep.Dropped++
+	default:
+		res.Outcome, res.Error = outcomeFailed, err.Error()
+		a.rep.Failed++
+	}
+	log.Printf("replay: %s %s", res.Outcome, res.Key)
+}
+
+// match reports whether key belongs to the selected users and claims, along with the
+// user and item ID it maps to. A key that does not parse only matches an empty filter.
+func (f filter) match(key string) (userID, itemID string, ok bool) {
+	userID, itemID, parsed := ddb.ItemForKey(key)
+	if !parsed {
+		return "", "", f.UserPrefix == "" && f.ClaimPrefix == ""
+	}
+	claimID := itemID
+	if c, _, isAtt := ddb.SplitItemID(itemID); isAtt {
+		claimID = c
+	}
+	return userID, itemID, strings.HasPrefix(userID, f.UserPrefix) && strings.HasPrefix(claimID, f.ClaimPrefix)
+}
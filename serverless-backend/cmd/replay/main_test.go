@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/config"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/ddb"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/indexer"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/models"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/s3io"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/scan"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/search"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+const testBucket = "claims-bucket"
+
+// fakeQueue hands out its messages once, using each message ID as its receipt handle,
+// and records the receipts deleted and released.
+type fakeQueue struct {
+	msgs     []sqstypes.Message
+	deleted  []string
+	released []string
+}
+
+func (q *fakeQueue) ReceiveMessage(_ context.Context, in *sqs.ReceiveMessageInput, _ ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	n := min(int(in.MaxNumberOfMessages), len(q.msgs))
+	out := &sqs.ReceiveMessageOutput{Messages: q.msgs[:n]}
+	q.msgs = q.msgs[n:]
+	return out, nil
+}
+
+func (q *fakeQueue) DeleteMessage(_ context.Context, in *sqs.DeleteMessageInput, _ ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error) {
+	q.deleted = append(q.deleted, aws.ToString(in.ReceiptHandle))
+	return &sqs.DeleteMessageOutput{}, nil
+}
+
+func (q *fakeQueue) ChangeMessageVisibilityBatch(_ context.Context, in *sqs.ChangeMessageVisibilityBatchInput, _ ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityBatchOutput, error) {
+	for _, e := range in.Entries {
+		q.released = append(q.released, aws.ToString(e.ReceiptHandle))
+	}
+	return &sqs.ChangeMessageVisibilityBatchOutput{}, nil
+}
+
+// s3Event returns the JSON of an ObjectCreated event for keys.
+func s3Event(t *testing.T, keys ...string) string {
+	t.Helper()
+	var ev events.S3Event
+	for _, k := range keys {
+		ev.Records = append(ev.Records, events.S3EventRecord{
+			EventName: "ObjectCreated:Put",
+			S3: events.S3Entity{
+				Bucket: events.S3Bucket{Name: testBucket},
+				Object: events.S3Object{Key: k},
+			},
+		})
+	}
+	b, err := json.Marshal(ev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestDecodeLine(t *testing.T) {
+	event := s3Event(t, "user/u-1/c-1.txt")
+	quoted, err := json.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		raw  string
+		want message
+	}{
+		{"bare S3 event", event, message{ID: "line-7", Body: event}},
+		{"Lambda SQS message", `{"messageId":"m-1","receiptHandle":"r-1","body":` + string(quoted) + `}`, message{ID: "m-1", Body: event}},
+		{"API SQS message", `{"MessageId":"m-2","ReceiptHandle":"r-2","Body":` + string(quoted) + `}`, message{ID: "m-2", Body: event}},
+		{"SQS message without an ID", `{"body":` + string(quoted) + `}`, message{ID: "line-7", Body: event}},
+		{"S3 test event", `{"Service":"Amazon S3","Event":"s3:TestEvent"}`, message{ID: "line-7", Body: `{"Service":"Amazon S3","Event":"s3:TestEvent"}`}},
+		{"not json", "not json", message{ID: "line-7", Body: "not json"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decodeLine(tt.raw, 7); got != tt.want {
+				t.Errorf("decodeLine = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	claim := s3io.BuildKey("u-1", "01ABC", s3io.ExtText)
+	att := s3io.AttachmentKey("u-1", "01ABC", "01XYZ", ".png")
+	tests := []struct {
+		name   string
+		filter filter
+		key    string
+		user   string
+		item   string
+		ok     bool
+	}{
+		{"claim, no filter", filter{}, claim, "u-1", "01ABC", true},
+		{"attachment, no filter", filter{}, att, "u-1", ddb.AttachmentItemID("01ABC", "01XYZ"), true},
+		{"user prefix", filter{UserPrefix: "u-"}, claim, "u-1", "01ABC", true},
+		{"other user", filter{UserPrefix: "u-2"}, claim, "u-1", "01ABC", false},
+		{"claim prefix", filter{ClaimPrefix: "01A"}, claim, "u-1", "01ABC", true},
+		{"other claim", filter{ClaimPrefix: "01B"}, claim, "u-1", "01ABC", false},
+		{"attachment by its claim", filter{ClaimPrefix: "01AB"}, att, "u-1", ddb.AttachmentItemID("01ABC", "01XYZ"), true},
+		{"both prefixes", filter{UserPrefix: "u-1", ClaimPrefix: "01B"}, claim, "u-1", "01ABC", false},
+		{"malformed key, no filter", filter{}, "uploads/c-1.txt", "", "", true},
+		{"malformed key, filtered", filter{UserPrefix: "u-1"}, "uploads/c-1.txt", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, item, ok := tt.filter.match(tt.key)
+			if user != tt.user || item != tt.item || ok != tt.ok {
+				t.Errorf("match(%s) = %q %q %v, want %q %q %v", tt.key, user, item, ok, tt.user, tt.item, tt.ok)
+			}
+		})
+	}
+}
+
+// newTestApp returns an App draining q through a Processor over a fake bucket and
+// MemStore, replaying only u-1's records.
+func newTestApp(t *testing.T, q *fakeQueue, dryRun bool) (*App, *s3io.Fake, *ddb.MemStore) {
+	t.Helper()
+	objects, store := &s3io.Fake{}, &ddb.MemStore{}
+	proc, err := indexer.New(config.Env{
+		Bucket:            testBucket,
+		MaxUploadBytes:    1 << 20,
+		MaxMultipartBytes: 1 << 30,
+		Scanner:           scan.BackendNone,
+		SearchBackend:     search.BackendNone,
+	}, objects, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &App{
+		proc: proc, repo: store, sqsc: q, queueURL: "https://sqs.test/claims-indexer-dlq",
+		dryRun: dryRun, filter: filter{UserPrefix: "u-1"}, rep: report{DryRun: dryRun},
+	}, objects, store
+}
+
+// upload records a pending claim, puts body at its key and returns the key.
+func upload(t *testing.T, objects *s3io.Fake, store *ddb.MemStore, userID, claimID string) string {
+	t.Helper()
+	key := s3io.BuildKey(userID, claimID, s3io.ExtText)
+	if err := store.PutPending(context.Background(), models.Claim{UserID: userID, ClaimID: claimID, S3Key: key, Status: models.StatusUploading}); err != nil {
+		t.Fatal(err)
+	}
+	objects.Put(testBucket, key, s3io.ContentTypeText, []byte("Dear Claims Adjuster, my car was hit."), map[string]string{"user_id": userID, "claim_id": claimID})
+	return key
+}
+
+func TestReplayQueueDeletesFinishedMessages(t *testing.T) {
+	for _, dryRun := range []bool{false, true} {
+		name := "replay"
+		if dryRun {
+			name = "dry run"
+		}
+		t.Run(name, func(t *testing.T) {
+			q := &fakeQueue{}
+			a, objects, store := newTestApp(t, q, dryRun)
+			ok := upload(t, objects, store, "u-1", "c-1")
+			alsoOK := upload(t, objects, store, "u-1", "c-2")
+			throttled := upload(t, objects, store, "u-1", "c-3")
+			other := upload(t, objects, store, "u-2", "c-4")
+			bystander := upload(t, objects, store, "u-1", "c-5")
+			gone := s3io.BuildKey("u-1", "c-6", s3io.ExtText) // no object, no record: dropped
+			objects.Errs = map[string]error{throttled: errors.New("SlowDown: please reduce your request rate")}
+
+			for _, m := range []struct{ id, body string }{
+				{"m-ok", s3Event(t, ok)},
+				{"m-gone", s3Event(t, gone)},
+				{"m-ok-and-gone", s3Event(t, alsoOK, gone)},
+				{"m-throttled", s3Event(t, bystander, throttled)}, // failed again: stays
+				{"m-filtered", s3Event(t, other)},                 // someone else's: stays
+				{"m-invalid", "not json"},                         // stays for a person to look at
+			} {
+				q.msgs = append(q.msgs, sqstypes.Message{MessageId: aws.String(m.id), ReceiptHandle: aws.String(m.id), Body: aws.String(m.body)})
+			}
+			all := []string{"m-ok", "m-gone", "m-ok-and-gone", "m-throttled", "m-filtered", "m-invalid"}
+
+			if err := a.replayQueue(context.Background(), 0, time.Minute); err != nil {
+				t.Fatal(err)
+			}
+			if a.rep.Messages != 6 || a.rep.Invalid != 1 {
+				t.Errorf("report = %+v, want 6 messages, 1 invalid", a.rep)
+			}
+
+			if dryRun {
+				if len(q.deleted) != 0 {
+					t.Errorf("deleted = %v, want nothing in a dry run", q.deleted)
+				}
+				if !slices.Equal(q.released, all) {
+					t.Errorf("released = %v, want %v", q.released, all)
+				}
+				if s := claimStatus(t, store, "u-1", "c-1"); s != models.StatusUploading {
+					t.Errorf("c-1 status = %s, want UPLOADING after a dry run", s)
+				}
+				return
+			}
+
+			if want := []string{"m-ok", "m-gone", "m-ok-and-gone"}; !slices.Equal(q.deleted, want) {
+				t.Errorf("deleted = %v, want %v", q.deleted, want)
+			}
+			if a.rep.Deleted != 3 || a.rep.Replayed != 3 || a.rep.Dropped != 2 || a.rep.Failed != 1 || a.rep.Skipped != 1 {
+				t.Errorf("report = %+v, want 3 deleted, 3 replayed, 2 dropped, 1 failed, 1 skipped", a.rep)
+			}
+			if len(q.released) != 0 {
+				t.Errorf("released = %v, want nothing outside a dry run", q.released)
+			}
+			for _, c := range []string{"c-1", "c-2", "c-5"} {
+				if s := claimStatus(t, store, "u-1", c); s != models.StatusComplete {
+					t.Errorf("%s status = %s, want COMPLETE", c, s)
+				}
+			}
+			if s := claimStatus(t, store, "u-2", "c-4"); s != models.StatusUploading {
+				t.Errorf("filtered claim status = %s, want UPLOADING", s)
+			}
+		})
+	}
+}
+
+// claimStatus returns a claim's current status.
+func claimStatus(t *testing.T, store *ddb.MemStore, userID, claimID string) models.ClaimStatus {
+	t.Helper()
+	c, err := store.GetClaim(context.Background(), userID, claimID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c.Status
+}
@@ -0,0 +1,188 @@
+// Package main powers PATCH /claims/{id}/status: adjusters move a claim through review
+// (SUBMITTED -> UNDER_REVIEW -> NEEDS_INFO -> APPROVED/DENIED -> CLOSED, see models.ReviewStatus),
+// and PUT /claims/{id}/vendors, which assigns the vendors allowed to download its redacted copy.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/audit"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/authz"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/awsutil"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/config"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/ddb"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/httpx"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/models"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/validate"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// App holds the application state, including configuration and AWS clients.
+type App struct {
+	env      config.Env
+	verifier *authz.Verifier
+	ddbRepo  ddb.ClaimStore
+}
+
+// statusRequest is the PATCH body.
+type statusRequest struct {
+	Status string `json:"status"`
+	Note   string `json:"note"` // required for NEEDS_INFO: tells the claimant what is missing
+}
+
+// vendorsRequest is the PUT /claims/{id}/vendors body: the complete new assignment.
+type vendorsRequest struct {
+	Vendors []string `json:"vendors"` // vendor subs; empty unassigns everyone
+}
+
+// main initializes the app and starts the Lambda handler.
+func main() {
+	env := config.MustLoad()
+	if err := env.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	cfg, _, err := awsutil.Load(context.Background(), env.Region)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	verifier, err := authz.NewVerifier(env.Region, env.UserPoolID, env.UserPoolClientID, env.JWKSFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	app := &App{
+		env:      env,
+		verifier: verifier,
+		ddbRepo:  &ddb.Repo{DB: dynamodb.NewFromConfig(cfg), Table: env.Table, AuditTable: env.AuditTable},
+	}
+	lambda.Start(app.handler)
+}
+
+// --- handler ---
+
+// handler processes PATCH /claims/{id}/status?user_id=<owner>. Only adjusters and admins
+// may review. The transition is checked here for a helpful message and enforced again by
+// the conditional write, which is what makes it safe against concurrent reviewers.
+// With If-Match, the claim must still be at that version (else 412).
+func (a *App) handler(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	user, err := authz.FromAPIGWv1(ctx, req, a.env.DevBypassAuth, a.verifier)
+	if err != nil {
+		return httpx.ErrorV1(http.StatusUnauthorized, "missing or invalid user")
+	}
+	ctx = audit.WithActor(ctx, audit.Actor{Sub: user.Sub, RequestID: req.RequestContext.RequestID})
+
+	claimID := req.PathParameters["id"]
+	if err := validate.ClaimID(claimID); err != nil {
+		return httpx.ErrorV1(http.StatusBadRequest, err.Error())
+	}
+
+	owner := authz.TargetUser(user, req.QueryStringParameters)
+	if !authz.Can(user, authz.ActionUpdate, models.Claim{UserID: owner}) {
+		return httpx.ErrorV1(http.StatusForbidden, "forbidden")
+	}
+
+	if strings.HasSuffix(req.Path, "/vendors") {
+		return a.vendors(ctx, req, user.Sub, owner, claimID)
+	}
+
+	var body statusRequest
+	if err := json.Unmarshal([]byte(req.Body), &body); err != nil {
+		return httpx.ErrorV1(http.StatusBadRequest, "invalid json")
+	}
+	to := models.ReviewStatus(strings.ToUpper(strings.TrimSpace(body.Status)))
+	if !to.Valid() {
+		return httpx.ErrorV1(http.StatusBadRequest, "invalid status")
+	}
+	note := strings.TrimSpace(body.Note)
+	if err := validate.ReviewNote(note); err != nil {
+		return httpx.ErrorV1(http.StatusBadRequest, err.Error())
+	}
+	if to == models.ReviewNeedsInfo && note == "" {
+		return httpx.ErrorV1(http.StatusBadRequest, "note required for NEEDS_INFO")
+	}
+
+	expected, conditional, err := httpx.IfMatch(req.Headers)
+	if err != nil {
+		return httpx.ErrorV1(http.StatusBadRequest, err.Error())
+	}
+
+	claim, err := a.ddbRepo.GetClaim(ctx, owner, claimID)
+	if errors.Is(err, ddb.ErrNotFound) {
+		return httpx.ErrorV1(http.StatusNotFound, "claim not found")
+	}
+	if err != nil {
+		log.Printf("review ddb error: %v", err)
+		return httpx.ErrorV1(http.StatusInternalServerError, "db error")
+	}
+	if conditional && claim.Version != expected {
+		return httpx.ErrorV1(http.StatusPreconditionFailed, "claim changed; reload and retry")
+	}
+	if claim.Status != models.StatusComplete {
+		return httpx.ErrorV1(http.StatusConflict, "claim is "+string(claim.Status))
+	}
+	from := claim.Review()
+	if !models.CanTransition(from, to) {
+		return httpx.ErrorV1(http.StatusConflict, "cannot move from "+string(from)+" to "+string(to))
+	}
+
+	// Pinned to the version just checked, so the transition applies to what was validated.
+	updated, err := a.ddbRepo.SetReviewStatus(ctx, owner, claimID, claim.Version, to, user.Sub, note, ddb.NowISO())
+	switch {
+	case errors.Is(err, ddb.ErrNotFound):
+		return httpx.ErrorV1(http.StatusNotFound, "claim not found")
+	case errors.Is(err, ddb.ErrConflict) && conditional:
+		return httpx.ErrorV1(http.StatusPreconditionFailed, "claim changed; reload and retry")
+	case errors.Is(err, ddb.ErrConflict): // lost a race with another reviewer or a withdrawal
+		return httpx.ErrorV1(http.StatusConflict, "claim changed; reload and retry")
+	case err != nil:
+		log.Printf("review ddb error: %v", err)
+		return httpx.ErrorV1(http.StatusInternalServerError, "db error")
+	}
+
+	log.Printf("review %s/%s %s -> %s by %s", owner, claimID, from, to, user.Sub)
+	return httpx.TaggedJSONV1(http.StatusOK, updated.Version, updated.View())
+}
+
+// vendors handles PUT /claims/{id}/vendors?user_id=<owner>, replacing the claim's vendor
+// assignment. With If-Match, the claim must still be at that version (else 412).
+func (a *App) vendors(ctx context.Context, req events.APIGatewayProxyRequest, actor, owner, claimID string) (events.APIGatewayProxyResponse, error) {
+	var body vendorsRequest
+	if err := json.Unmarshal([]byte(req.Body), &body); err != nil {
+		return httpx.ErrorV1(http.StatusBadRequest, "invalid json")
+	}
+	if err := validate.Vendors(body.Vendors); err != nil {
+		return httpx.ErrorV1(http.StatusBadRequest, err.Error())
+	}
+	expected, conditional, err := httpx.IfMatch(req.Headers)
+	if err != nil {
+		return httpx.ErrorV1(http.StatusBadRequest, err.Error())
+	}
+	if !conditional {
+		expected = ddb.AnyVersion
+	}
+
+	updated, err := a.ddbRepo.SetVendors(ctx, owner, claimID, expected, body.Vendors)
+	switch {
+	case errors.Is(err, ddb.ErrNotFound):
+		return httpx.ErrorV1(http.StatusNotFound, "claim not found")
+	case errors.Is(err, ddb.ErrConflict) && conditional:
+		return httpx.ErrorV1(http.StatusPreconditionFailed, "claim changed; reload and retry")
+	case errors.Is(err, ddb.ErrConflict):
+		return httpx.ErrorV1(http.StatusConflict, "claim changed; reload and retry")
+	case err != nil:
+		log.Printf("vendors ddb error: %v", err)
+		return httpx.ErrorV1(http.StatusInternalServerError, "db error")
+	}
+
+	log.Printf("vendors %s/%s = %v by %s", owner, claimID, body.Vendors, actor)
+	return httpx.TaggedJSONV1(http.StatusOK, updated.Version, updated.View())
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/config"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/ddb"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/httpx"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/models"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/oklog/ulid/v2"
+)
+
+// newTestApp returns an App over MemStore with the dev auth bypass on, and the ID of a
+// COMPLETE claim owned by u-1 (at version 2, review SUBMITTED).
+func newTestApp(t *testing.T) (*App, *ddb.MemStore, string) {
+	t.Helper()
+	ctx := context.Background()
+	store := &ddb.MemStore{}
+	claimID := ulid.Make().String()
+	key := "user/u-1/" + claimID + ".txt"
+	if err := store.PutPending(ctx, models.Claim{UserID: "u-1", ClaimID: claimID, S3Key: key, Status: models.StatusUploading}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpsertComplete(ctx, "u-1", claimID, ddb.AnyVersion, key, 10, "etag", "", ddb.NowISO()); err != nil {
+		t.Fatal(err)
+	}
+	return &App{env: config.Env{DevBypassAuth: true}, ddbRepo: store}, store, claimID
+}
+
+// call runs the handler on path as adjuster adj-1 for u-1's claim, with an If-Match
+// header when ifMatch is not empty.
+func call(t *testing.T, a *App, path, claimID, ifMatch, body string) events.APIGatewayProxyResponse {
+	t.Helper()
+	headers := map[string]string{"x-user-sub": "adj-1", "x-user-groups": "adjuster"}
+	if ifMatch != "" {
+		headers["If-Match"] = ifMatch
+	}
+	resp, err := a.handler(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  path,
+		Headers:               headers,
+		PathParameters:        map[string]string{"id": claimID},
+		QueryStringParameters: map[string]string{"user_id": "u-1"},
+		Body:                  body,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+// version returns the claim's current version.
+func version(t *testing.T, store *ddb.MemStore, claimID string) int64 {
+	t.Helper()
+	c, err := store.GetClaim(context.Background(), "u-1", claimID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c.Version
+}
+
+func TestReviewIfMatch(t *testing.T) {
+	const underReview = `{"status":"UNDER_REVIEW"}`
+
+	t.Run("current version", func(t *testing.T) {
+		a, store, id := newTestApp(t)
+		resp := call(t, a, "/claims/"+id+"/status", id, httpx.ETag(2), underReview)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("status = %d, body %s", resp.StatusCode, resp.Body)
+		}
+		if resp.Headers["ETag"] != httpx.ETag(3) || version(t, store, id) != 3 {
+			t.Errorf("ETag = %s at version %d, want %s", resp.Headers["ETag"], version(t, store, id), httpx.ETag(3))
+		}
+		var view models.ClaimView
+		if err := json.Unmarshal([]byte(resp.Body), &view); err != nil {
+			t.Fatal(err)
+		}
+		if view.ReviewStatus != string(models.ReviewUnderReview) {
+			t.Errorf("review_status = %s, want UNDER_REVIEW", view.ReviewStatus)
+		}
+	})
+
+	t.Run("stale version", func(t *testing.T) {
+		a, store, id := newTestApp(t)
+		resp := call(t, a, "/claims/"+id+"/status", id, httpx.ETag(1), underReview)
+		if resp.StatusCode != http.StatusPreconditionFailed {
+			t.Fatalf("status = %d, want 412 (%s)", resp.StatusCode, resp.Body)
+		}
+		if v := version(t, store, id); v != 2 {
+			t.Errorf("version = %d after a refused write, want 2", v)
+		}
+	})
+
+	t.Run("lost update", func(t *testing.T) {
+		// Two adjusters load version 2; the second to write must not overwrite the first.
+		a, _, id := newTestApp(t)
+		if resp := call(t, a, "/claims/"+id+"/status", id, httpx.ETag(2), underReview); resp.StatusCode != http.StatusOK {
+			t.Fatalf("first reviewer: status = %d (%s)", resp.StatusCode, resp.Body)
+		}
+		resp := call(t, a, "/claims/"+id+"/vendors", id, httpx.ETag(2), `{"vendors":["v-1"]}`)
+		if resp.StatusCode != http.StatusPreconditionFailed {
+			t.Errorf("second reviewer: status = %d, want 412 (%s)", resp.StatusCode, resp.Body)
+		}
+	})
+
+	t.Run("without If-Match", func(t *testing.T) {
+		// Unconditional requests are last-writer-wins, whatever the version.
+		a, store, id := newTestApp(t)
+		if resp := call(t, a, "/claims/"+id+"/vendors", id, "", `{"vendors":["v-1"]}`); resp.StatusCode != http.StatusOK {
+			t.Fatalf("vendors: status = %d (%s)", resp.StatusCode, resp.Body)
+		}
+		resp := call(t, a, "/claims/"+id+"/status", id, "", underReview)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("status = %d (%s)", resp.StatusCode, resp.Body)
+		}
+		if v := version(t, store, id); v != 4 || resp.Headers["ETag"] != httpx.ETag(4) {
+			t.Errorf("version = %d, ETag %s, want 4", v, resp.Headers["ETag"])
+		}
+	})
+
+	t.Run("wildcard", func(t *testing.T) {
+		a, _, id := newTestApp(t)
+		if resp := call(t, a, "/claims/"+id+"/status", id, "*", underReview); resp.StatusCode != http.StatusOK {
+			t.Errorf("status = %d, want 200 (%s)", resp.StatusCode, resp.Body)
+		}
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		a, store, id := newTestApp(t)
+		for _, path := range []string{"/claims/" + id + "/status", "/claims/" + id + "/vendors"} {
+			resp := call(t, a, path, id, "2", `{"status":"UNDER_REVIEW","vendors":[]}`)
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("%s: status = %d, want 400 (%s)", path, resp.StatusCode, resp.Body)
+			}
+		}
+		if v := version(t, store, id); v != 2 {
+			t.Errorf("version = %d after rejected requests, want 2", v)
+		}
+	})
+}
+
+func TestVendorsIfMatch(t *testing.T) {
+	a, store, id := newTestApp(t)
+	path := "/claims/" + id + "/vendors"
+
+	if resp := call(t, a, path, id, httpx.ETag(5), `{"vendors":["v-1"]}`); resp.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("future version: status = %d, want 412 (%s)", resp.StatusCode, resp.Body)
+	}
+	resp := call(t, a, path, id, httpx.ETag(2), `{"vendors":["v-1"]}`)
+	if resp.StatusCode != http.StatusOK || resp.Headers["ETag"] != httpx.ETag(3) {
+		t.Fatalf("status = %d, ETag %s, want 200 with %s (%s)", resp.StatusCode, resp.Headers["ETag"], httpx.ETag(3), resp.Body)
+	}
+	c, err := store.GetClaim(context.Background(), "u-1", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Vendors) != 1 || c.Vendors[0] != "v-1" {
+		t.Errorf("vendors = %v, want [v-1]", c.Vendors)
+	}
+}
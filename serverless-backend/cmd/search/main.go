@@ -0,0 +1,140 @@
+// Package main powers GET /claims/search: full-text search over the user's claim letters
+// (REST API Gateway v1).
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/authz"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/awsutil"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/config"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/ddb"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/httpx"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/models"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/search"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/validate"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// App holds the application state, including configuration and AWS clients.
+type App struct {
+	env      config.Env
+	verifier *authz.Verifier
+	ddbRepo  ddb.ClaimStore
+	index    search.Index // nil when SEARCH_BACKEND=none
+}
+
+// defaultLimit is the number of hits returned without ?limit=.
+const defaultLimit = 20
+
+// searchHit is one result: the claim's view and how well it matched.
+type searchHit struct {
+	models.ClaimView
+	Score float64 `json:"score"`
+}
+
+// main initializes the app and starts the Lambda handler.
+func main() {
+	env := config.MustLoad()
+	if err := env.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	cfg, endpoint, err := awsutil.Load(context.Background(), env.Region)
+	if err != nil {
+		log.Fatal(err)
+	}
+	verifier, err := authz.NewVerifier(env.Region, env.UserPoolID, env.UserPoolClientID, env.JWKSFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	s3c := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if endpoint != "" {
+			o.UsePathStyle = true // localstack/dev friendliness
+		}
+	})
+	index, err := search.New(env.SearchBackend, env.SearchDir, s3c, env.Bucket)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	app := &App{
+		env:      env,
+		verifier: verifier,
+		ddbRepo:  &ddb.Repo{DB: dynamodb.NewFromConfig(cfg), Table: env.Table, AuditTable: env.AuditTable},
+		index:    index,
+	}
+	lambda.Start(app.handler)
+}
+
+// --- handler ---
+
+// handler processes GET /claims/search?q=<terms>, returning the owner's claims whose letters
+// contain every term, best match first (?limit=1..100, default 20). Only the owner's shard of
+// the index is read, so the same ?user_id= rules as listing apply. Withdrawing a claim
+// removes it from the index; hits are still re-read from the table, and any claim since
+// removed or withdrawn is dropped, so fewer than limit may come back.
+func (a *App) handler(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	user, err := authz.FromAPIGWv1(ctx, req, a.env.DevBypassAuth, a.verifier)
+	if err != nil {
+		return httpx.ErrorV1(http.StatusUnauthorized, "missing or invalid user")
+	}
+
+	q := req.QueryStringParameters
+	owner := authz.TargetUser(user, q)
+	if !authz.Can(user, authz.ActionRead, models.Claim{UserID: owner}) {
+		return httpx.ErrorV1(http.StatusForbidden, "forbidden")
+	}
+
+	query := strings.TrimSpace(q["q"])
+	if err := validate.SearchQuery(query); err != nil {
+		return httpx.ErrorV1(http.StatusBadRequest, err.Error())
+	}
+	limit := int32(defaultLimit)
+	if q["limit"] != "" {
+		if limit, err = validate.PageLimit(q["limit"]); err != nil {
+			return httpx.ErrorV1(http.StatusBadRequest, err.Error())
+		}
+	}
+	if a.index == nil {
+		return httpx.ErrorV1(http.StatusServiceUnavailable, "search is not enabled")
+	}
+
+	hits, err := a.index.Search(ctx, owner, query, int(limit))
+	if errors.Is(err, search.ErrEmptyQuery) {
+		return httpx.ErrorV1(http.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		log.Printf("search index error: %v", err)
+		return httpx.ErrorV1(http.StatusInternalServerError, "search error")
+	}
+
+	items := []searchHit{}
+	for _, h := range hits {
+		claim, err := a.ddbRepo.GetClaim(ctx, owner, h.ClaimID)
+		if errors.Is(err, ddb.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			log.Printf("search ddb error: %v", err)
+			return httpx.ErrorV1(http.StatusInternalServerError, "db error")
+		}
+		if claim.Status == models.StatusWithdrawn { // its removal from the index failed
+			continue
+		}
+		items = append(items, searchHit{ClaimView: authz.ClaimView(user, claim), Score: h.Score})
+	}
+	return httpx.JSONV1(http.StatusOK, map[string]any{
+		"user_id": owner,
+		"q":       query,
+		"items":   items,
+	})
+}
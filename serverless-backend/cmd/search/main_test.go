@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/config"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/ddb"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/models"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/search"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/oklog/ulid/v2"
+)
+
+// searchResponse is the GET /claims/search body.
+type searchResponse struct {
+	UserID string           `json:"user_id"`
+	Q      string           `json:"q"`
+	Items  []map[string]any `json:"items"`
+}
+
+// newTestApp returns an App over MemStore and a directory index with the dev auth bypass
+// on, and u-1's indexed claims: an Austin letter, a Dallas letter and a withdrawn Austin
+// letter still in the index, in that order.
+func newTestApp(t *testing.T) (*App, []string) {
+	t.Helper()
+	ctx := context.Background()
+	store, index := &ddb.MemStore{}, search.NewDir(t.TempDir())
+	var ids []string
+	for _, text := range []string{
+		"Rear-end collision in Austin, TX. Policy #A12345.",
+		"Burst pipe flooded the kitchen in Dallas, TX.",
+		"Hail damage to my roof in Austin, TX.",
+	} {
+		c := models.Claim{UserID: "u-1", ClaimID: ulid.Make().String(), Status: models.StatusUploading}
+		c.S3Key = "user/u-1/" + c.ClaimID + ".txt"
+		if err := store.PutPending(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+		if err := index.Add(ctx, search.Doc{UserID: c.UserID, ClaimID: c.ClaimID, Text: text}); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, c.ClaimID)
+	}
+	if _, err := store.Withdraw(ctx, "u-1", ids[2], ddb.AnyVersion, ddb.NowISO()); err != nil {
+		t.Fatal(err)
+	}
+	return &App{env: config.Env{DevBypassAuth: true}, ddbRepo: store, index: index}, ids
+}
+
+// find runs GET /claims/search as sub with groups and query, and decodes the response.
+func find(t *testing.T, a *App, sub, groups string, query map[string]string) (int, searchResponse) {
+	t.Helper()
+	resp, err := a.handler(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/claims/search",
+		Headers:               map[string]string{"x-user-sub": sub, "x-user-groups": groups},
+		QueryStringParameters: query,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out searchResponse
+	if err := json.Unmarshal([]byte(resp.Body), &out); err != nil {
+		t.Fatalf("decode %q: %v", resp.Body, err)
+	}
+	return resp.StatusCode, out
+}
+
+func TestSearch(t *testing.T) {
+	a, ids := newTestApp(t)
+	tests := []struct {
+		name string
+		q    string
+		want []any
+	}{
+		{"city", "Austin, TX", []any{ids[0]}}, // the withdrawn letter is dropped
+		{"policy number", "a12345", []any{ids[0]}},
+		{"shared term", "tx", nil},
+		{"no match", "earthquake", []any{}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			code, out := find(t, a, "u-1", "", map[string]string{"q": tc.q})
+			if code != http.StatusOK {
+				t.Fatalf("status = %d", code)
+			}
+			if out.UserID != "u-1" || out.Q != tc.q {
+				t.Errorf("user_id, q = %q, %q", out.UserID, out.Q)
+			}
+			if tc.want == nil {
+				if len(out.Items) != 2 {
+					t.Errorf("items = %v, want both live letters", out.Items)
+				}
+				return
+			}
+			if len(out.Items) != len(tc.want) {
+				t.Fatalf("items = %v, want %v", out.Items, tc.want)
+			}
+			for i, it := range out.Items {
+				if it["claim_id"] != tc.want[i] {
+					t.Errorf("item %d = %v, want %v", i, it["claim_id"], tc.want[i])
+				}
+				if _, ok := it["s3_key"]; ok {
+					t.Errorf("item %v is not a ClaimView: it exposes s3_key", it["claim_id"])
+				}
+				if score, _ := it["score"].(float64); score <= 0 {
+					t.Errorf("item %v score = %v, want > 0", it["claim_id"], it["score"])
+				}
+			}
+		})
+	}
+}
+
+func TestSearchAccess(t *testing.T) {
+	a, _ := newTestApp(t)
+	tests := []struct {
+		name   string
+		sub    string
+		groups string
+		query  map[string]string
+		want   int
+	}{
+		{"anonymous", "", "", map[string]string{"q": "austin"}, http.StatusUnauthorized},
+		{"claimant searches another user", "u-2", "", map[string]string{"q": "austin", "user_id": "u-1"}, http.StatusForbidden},
+		{"adjuster searches another user", "u-2", "adjuster", map[string]string{"q": "austin", "user_id": "u-1"}, http.StatusOK},
+		{"missing q", "u-1", "", nil, http.StatusBadRequest},
+		{"stopwords only", "u-1", "", map[string]string{"q": "the of"}, http.StatusBadRequest},
+		{"bad limit", "u-1", "", map[string]string{"q": "austin", "limit": "0"}, http.StatusBadRequest},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if code, _ := find(t, a, tc.sub, tc.groups, tc.query); code != tc.want {
+				t.Errorf("status = %d, want %d", code, tc.want)
+			}
+		})
+	}
+
+	a.index = nil
+	if code, _ := find(t, a, "u-1", "", map[string]string{"q": "austin"}); code != http.StatusServiceUnavailable {
+		t.Errorf("search disabled: status = %d, want 503", code)
+	}
+}
+
+func TestSearchOwnShardOnly(t *testing.T) {
+	a, _ := newTestApp(t)
+	code, out := find(t, a, "u-2", "", map[string]string{"q": "austin"})
+	if code != http.StatusOK || len(out.Items) != 0 {
+		t.Errorf("u-2 searching its own claims: status %d, items %v; want 200 and none", code, out.Items)
+	}
+}
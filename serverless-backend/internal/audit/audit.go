@@ -0,0 +1,36 @@
+// Package audit carries who is acting through a request so that every repository write
+// can record it in the claim's history (see ddb.Repo and models.AuditEvent).
+package audit
+
+import "context"
+
+// Actors for writes made by the backend itself rather than a user.
+const (
+	SystemIndexer   = "system:indexer"
+	SystemReaper    = "system:reaper"
+	SystemReplay    = "system:replay"
+	SystemReconcile = "system:reconcile"
+	System          = "system" // fallback when no actor was attached
+)
+
+// Actor is who performed a write and the request it belonged to.
+type Actor struct {
+	Sub       string // user sub, or one of the System* constants
+	RequestID string // API Gateway request ID, SQS message ID, ...
+}
+
+type actorKey struct{}
+
+// WithActor returns ctx carrying a.
+func WithActor(ctx context.Context, a Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, a)
+}
+
+// From returns the actor attached to ctx, or System if there is none.
+func From(ctx context.Context) Actor {
+	a, _ := ctx.Value(actorKey{}).(Actor)
+	if a.Sub == "" {
+		a.Sub = System
+	}
+	return a
+}
@@ -2,18 +2,23 @@
 package authz
 
 import (
-	"encoding/base64"
+	"context"
 	"encoding/json"
 	"errors"
 	"strings"
 
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/models"
+
 	"github.com/aws/aws-lambda-go/events"
 )
 
 // ErrUnauthorized is returned when a user is not authorized to access a resource.
 var ErrUnauthorized = errors.New("unauthorized")
 
-const devBypassHeader = "x-user-sub"
+const (
+	devBypassHeader       = "x-user-sub"
+	devBypassGroupsHeader = "x-user-groups" // comma-separated; dev only
+)
 
 // --- small utils ---
 
@@ -39,62 +44,102 @@ func stringIf(v any) string {
 	return ""
 }
 
-// subFromClaims extracts the "sub" claim from a JWT claims map.
-func subFromClaims(raw any) string {
+// claimsMap normalizes the authorizer "claims" value into a map.
+func claimsMap(raw any) map[string]any {
 	switch c := raw.(type) {
 	case map[string]any:
-		return stringIf(c["sub"])
+		return c
 	case map[string]string:
-		return c["sub"]
+		m := make(map[string]any, len(c))
+		for k, v := range c {
+			m[k] = v
+		}
+		return m
 	case string:
 		var m map[string]any
 		if json.Unmarshal([]byte(c), &m) == nil {
-			return stringIf(m["sub"])
+			return m
 		}
 	}
-	return ""
+	return nil
 }
 
-// subFromAuthHeader extracts the "sub" claim from the Authorization header.
-func subFromAuthHeader(headers map[string]string) string {
-	auth := headerLookup(headers, "Authorization")
-	if auth == "" {
-		return ""
+// groupsIf parses cognito:groups, which API Gateway may pass as a JSON array,
+// a Go-formatted "[a b]" string, or a comma-separated string.
+func groupsIf(v any) []string {
+	switch g := v.(type) {
+	case []any:
+		out := make([]string, 0, len(g))
+		for _, x := range g {
+			if s := stringIf(x); s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	case []string:
+		return g
+	case string:
+		g = strings.Trim(strings.TrimSpace(g), "[]")
+		return strings.FieldsFunc(g, func(r rune) bool { return r == ',' || r == ' ' })
 	}
+	return nil
+}
+
+// userFromClaims extracts user claims from a JWT claims map.
+func userFromClaims(raw any) models.UserClaims {
+	m := claimsMap(raw)
+	if m == nil {
+		return models.UserClaims{}
+	}
+	return models.UserClaims{
+		Sub:    stringIf(m["sub"]),
+		Email:  stringIf(m["email"]),
+		Groups: groupsIf(m["cognito:groups"]),
+	}
+}
+
+// bearerToken returns the raw JWT from the Authorization header.
+func bearerToken(headers map[string]string) string {
+	auth := strings.TrimSpace(headerLookup(headers, "Authorization"))
 	if strings.HasPrefix(strings.ToLower(auth), "bearer ") {
 		auth = strings.TrimSpace(auth[len("bearer "):])
 	}
-	parts := strings.Split(auth, ".")
-	if len(parts) != 3 {
-		return ""
-	}
-	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	return auth
+}
+
+// FromAPIGWv1 extracts the caller identity from a REST (v1) request.
+// Sources, in order: the dev bypass header (when enabled), the Cognito authorizer
+// context set by API Gateway, and finally the Authorization header verified by v.
+// When v is nil the header is not trusted. Roles are derived from cognito:groups.
+func FromAPIGWv1(ctx context.Context, req events.APIGatewayProxyRequest, devBypass bool, v *Verifier) (models.UserClaims, error) {
+	u, err := identify(ctx, req, devBypass, v)
 	if err != nil {
-		return ""
-	}
-	var m map[string]any
-	if json.Unmarshal(payload, &m) != nil {
-		return ""
+		return models.UserClaims{}, err
 	}
-	return stringIf(m["sub"])
+	u.Roles = models.RolesFromGroups(u.Groups)
+	return u, nil
 }
 
-// FromAPIGWv1 extracts the Cognito user sub from a REST (v1) request.
-func FromAPIGWv1(req events.APIGatewayProxyRequest, devBypass bool) (string, error) {
-	// Try each extraction method in order of preference
-	extractors := []func() string{
-		func() string { return tryDevBypass(req.Headers, devBypass) },
-		func() string { return tryAuthorizerContext(req.RequestContext.Authorizer) },
-		func() string { return subFromAuthHeader(req.Headers) },
+// identify resolves the caller's claims without deriving roles.
+func identify(ctx context.Context, req events.APIGatewayProxyRequest, devBypass bool, v *Verifier) (models.UserClaims, error) {
+	if sub := tryDevBypass(req.Headers, devBypass); sub != "" {
+		return models.UserClaims{Sub: sub, Groups: groupsIf(headerLookup(req.Headers, devBypassGroupsHeader))}, nil
 	}
-
-	for _, extract := range extractors {
-		if sub := extract(); sub != "" {
-			return sub, nil
-		}
+	if u := tryAuthorizerContext(req.RequestContext.Authorizer); u.Sub != "" {
+		return u, nil
 	}
-
-	return "", ErrUnauthorized
+	if v == nil {
+		return models.UserClaims{}, ErrUnauthorized
+	}
+	tok := bearerToken(req.Headers)
+	if tok == "" {
+		return models.UserClaims{}, ErrUnauthorized
+	}
+	u, err := v.Verify(ctx, tok)
+	if err != nil {
+		return models.UserClaims{}, errors.Join(ErrUnauthorized, err)
+	}
+	return u, nil
 }
 
 // tryDevBypass checks for dev bypass header if enabled.
@@ -105,24 +150,21 @@ func tryDevBypass(headers map[string]string, devBypass bool) string {
 	return strings.TrimSpace(headerLookup(headers, devBypassHeader))
 }
 
-// tryAuthorizerContext extracts sub from Cognito authorizer context.
-func tryAuthorizerContext(authorizer map[string]interface{}) string {
+// tryAuthorizerContext extracts user claims from the Cognito authorizer context.
+func tryAuthorizerContext(authorizer map[string]interface{}) models.UserClaims {
 	if authorizer == nil {
-		return ""
+		return models.UserClaims{}
 	}
 
-	// Try each potential source in the authorizer context
-	sources := []func() string{
-		func() string { return subFromClaims(authorizer["claims"]) },
-		func() string { return stringIf(authorizer["sub"]) },
-		func() string { return stringIf(authorizer["principalId"]) },
+	if u := userFromClaims(authorizer["claims"]); u.Sub != "" {
+		return u
 	}
 
-	for _, source := range sources {
-		if sub := source(); sub != "" {
-			return sub
+	// Custom authorizers may only set a principal.
+	for _, k := range []string{"sub", "principalId"} {
+		if sub := stringIf(authorizer[k]); sub != "" {
+			return models.UserClaims{Sub: sub}
 		}
 	}
-
-	return ""
+	return models.UserClaims{}
 }
@@ -0,0 +1,180 @@
+package authz
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrUnknownKey is returned when no signing key matches a token's kid.
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// KeySource resolves RSA public keys by key ID.
+type KeySource interface {
+	Key(ctx context.Context, kid string) (*rsa.PublicKey, error)
+}
+
+// --- static keys (offline tests, bundled JWKS) ---
+
+// StaticKeys is a fixed kid -> key set.
+type StaticKeys map[string]*rsa.PublicKey
+
+// Key returns the key for kid or ErrUnknownKey.
+func (s StaticKeys) Key(_ context.Context, kid string) (*rsa.PublicKey, error) {
+	if k, ok := s[kid]; ok {
+		return k, nil
+	}
+	return nil, ErrUnknownKey
+}
+
+// LoadJWKSFile reads a JWKS JSON document from disk.
+func LoadJWKSFile(path string) (StaticKeys, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseJWKS(b)
+}
+
+// --- remote JWKS with caching ---
+
+const (
+	defaultJWKSTTL     = time.Hour
+	minJWKSRefreshWait = time.Minute
+)
+
+// JWKS fetches a remote key set and caches it. Unknown kids trigger a refresh
+// (at most once per minute) so Cognito key rotation is picked up without a redeploy.
+type JWKS struct {
+	URL    string
+	Client *http.Client  // defaults to a 5s-timeout client
+	TTL    time.Duration // defaults to 1h
+
+	mu        sync.Mutex
+	keys      StaticKeys
+	fetchedAt time.Time
+}
+
+// NewJWKS returns a JWKS cache for url.
+func NewJWKS(url string) *JWKS {
+	return &JWKS{URL: url}
+}
+
+// Key returns the key for kid, refreshing the cache when stale or when kid is unknown.
+func (j *JWKS) Key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	ttl := j.TTL
+	if ttl <= 0 {
+		ttl = defaultJWKSTTL
+	}
+	age := time.Since(j.fetchedAt)
+
+	if k, ok := j.keys[kid]; ok && age < ttl {
+		return k, nil
+	}
+	if j.keys == nil || age >= minJWKSRefreshWait {
+		if err := j.refresh(ctx); err != nil {
+			// Serve a stale key rather than fail closed on a transient fetch error.
+			if k, ok := j.keys[kid]; ok {
+				return k, nil
+			}
+			return nil, err
+		}
+	}
+	return j.keys.Key(ctx, kid)
+}
+
+// refresh downloads and parses the key set. Caller holds j.mu.
+func (j *JWKS) refresh(ctx context.Context) error {
+	client := j.Client
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.URL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("jwks fetch: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("jwks fetch: status %d", resp.StatusCode)
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("jwks read: %w", err)
+	}
+	keys, err := ParseJWKS(b)
+	if err != nil {
+		return err
+	}
+	j.keys = keys
+	j.fetchedAt = time.Now()
+	return nil
+}
+
+// --- parsing ---
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// ParseJWKS decodes the RSA signing keys from a JWKS document.
+func ParseJWKS(b []byte) (StaticKeys, error) {
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("jwks parse: %w", err)
+	}
+	keys := make(StaticKeys, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		pub, err := rsaFromJWK(k)
+		if err != nil {
+			return nil, fmt.Errorf("jwks key %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = pub
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("jwks: no RSA signing keys")
+	}
+	return keys, nil
+}
+
+// rsaFromJWK builds an RSA public key from base64url modulus/exponent.
+func rsaFromJWK(k jwk) (*rsa.PublicKey, error) {
+	nb, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+	eb, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+	e := new(big.Int).SetBytes(eb)
+	if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+		return nil, errors.New("bad exponent")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(nb), E: int(e.Int64())}, nil
+}
@@ -0,0 +1,191 @@
+package authz
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/models"
+)
+
+// ErrInvalidToken is returned (wrapped) for any JWT that fails verification.
+var ErrInvalidToken = errors.New("invalid token")
+
+// Verifier checks Cognito-issued RS256 JWTs.
+type Verifier struct {
+	Keys     KeySource
+	Issuer   string // https://cognito-idp.<region>.amazonaws.com/<pool-id>
+	Audience string // app client ID
+	TokenUse string // "id" or "access"; defaults to "id"
+	Leeway   time.Duration
+	Now      func() time.Time // for tests; defaults to time.Now
+}
+
+// CognitoIssuer returns the issuer URL for a Cognito user pool.
+func CognitoIssuer(region, poolID string) string {
+	return fmt.Sprintf("https://cognito-idp.%s.amazonaws.com/%s", region, poolID)
+}
+
+// NewVerifier builds a Verifier for a Cognito user pool. Keys come from jwksFile when set,
+// otherwise from the pool's public JWKS endpoint. Returns nil when poolID is empty.
+func NewVerifier(region, poolID, clientID, jwksFile string) (*Verifier, error) {
+	if poolID == "" {
+		return nil, nil
+	}
+	iss := CognitoIssuer(region, poolID)
+
+	var keys KeySource = NewJWKS(iss + "/.well-known/jwks.json")
+	if jwksFile != "" {
+		static, err := LoadJWKSFile(jwksFile)
+		if err != nil {
+			return nil, err
+		}
+		keys = static
+	}
+	return &Verifier{Keys: keys, Issuer: iss, Audience: clientID, Leeway: 30 * time.Second}, nil
+}
+
+// tokenClaims is the subset of Cognito token claims we validate or surface.
+type tokenClaims struct {
+	Sub      string   `json:"sub"`
+	Email    string   `json:"email"`
+	Iss      string   `json:"iss"`
+	Aud      audience `json:"aud"`
+	ClientID string   `json:"client_id"` // access tokens carry client_id instead of aud
+	TokenUse string   `json:"token_use"`
+	Exp      int64    `json:"exp"`
+	Nbf      int64    `json:"nbf"`
+	Groups   []string `json:"cognito:groups"`
+}
+
+// audience accepts both the string and array forms of "aud".
+type audience []string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var s string
+	if json.Unmarshal(b, &s) == nil {
+		*a = audience{s}
+		return nil
+	}
+	var ss []string
+	if err := json.Unmarshal(b, &ss); err != nil {
+		return err
+	}
+	*a = ss
+	return nil
+}
+
+func (a audience) contains(v string) bool {
+	for _, s := range a {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Verify checks the signature and standard claims of a compact JWT and returns the caller's claims.
+func (v *Verifier) Verify(ctx context.Context, token string) (models.UserClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return models.UserClaims{}, fmt.Errorf("%w: malformed", ErrInvalidToken)
+	}
+	if err := v.verifySignature(ctx, parts); err != nil {
+		return models.UserClaims{}, err
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return models.UserClaims{}, fmt.Errorf("%w: payload encoding", ErrInvalidToken)
+	}
+	var c tokenClaims
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return models.UserClaims{}, fmt.Errorf("%w: payload json", ErrInvalidToken)
+	}
+	if err := v.validateClaims(c); err != nil {
+		return models.UserClaims{}, err
+	}
+	return models.UserClaims{Sub: c.Sub, Email: c.Email, Groups: c.Groups, Roles: models.RolesFromGroups(c.Groups)}, nil
+}
+
+// verifySignature validates the RS256 signature over header.payload.
+func (v *Verifier) verifySignature(ctx context.Context, parts []string) error {
+	hb, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return fmt.Errorf("%w: header encoding", ErrInvalidToken)
+	}
+	var hdr struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := json.Unmarshal(hb, &hdr); err != nil {
+		return fmt.Errorf("%w: header json", ErrInvalidToken)
+	}
+	if hdr.Alg != "RS256" {
+		return fmt.Errorf("%w: unsupported alg %q", ErrInvalidToken, hdr.Alg)
+	}
+
+	key, err := v.Keys.Key(ctx, hdr.Kid)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return fmt.Errorf("%w: signature encoding", ErrInvalidToken)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+		return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+	}
+	return nil
+}
+
+// validateClaims enforces iss/aud/token_use/exp/nbf.
+func (v *Verifier) validateClaims(c tokenClaims) error {
+	now := time.Now
+	if v.Now != nil {
+		now = v.Now
+	}
+	t := now().Unix()
+	leeway := int64(v.Leeway / time.Second)
+
+	want := v.TokenUse
+	if want == "" {
+		want = "id"
+	}
+
+	switch {
+	case c.Sub == "":
+		return fmt.Errorf("%w: missing sub", ErrInvalidToken)
+	case c.Iss != v.Issuer:
+		return fmt.Errorf("%w: issuer mismatch", ErrInvalidToken)
+	case c.TokenUse != want:
+		return fmt.Errorf("%w: token_use %q", ErrInvalidToken, c.TokenUse)
+	case !v.audienceOK(c):
+		return fmt.Errorf("%w: audience mismatch", ErrInvalidToken)
+	case c.Exp == 0 || t > c.Exp+leeway:
+		return fmt.Errorf("%w: expired", ErrInvalidToken)
+	case c.Nbf != 0 && t+leeway < c.Nbf:
+		return fmt.Errorf("%w: not yet valid", ErrInvalidToken)
+	}
+	return nil
+}
+
+// audienceOK checks aud (ID tokens) or client_id (access tokens) against the configured client.
+func (v *Verifier) audienceOK(c tokenClaims) bool {
+	if v.Audience == "" {
+		return true
+	}
+	if c.TokenUse == "access" {
+		return c.ClientID == v.Audience
+	}
+	return c.Aud.contains(v.Audience)
+}
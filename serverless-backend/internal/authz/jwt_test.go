@@ -0,0 +1,152 @@
+package authz
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testIssuer   = "https://cognito-idp.us-east-1.amazonaws.com/us-east-1_test"
+	testAudience = "client-123"
+	testKid      = "kid-1"
+)
+
+var testNow = time.Date(2024, 12, 12, 12, 0, 0, 0, time.UTC)
+
+// testKeys returns a signing key and a second, unrelated key.
+func testKeys(t *testing.T) (signing, other *rsa.PrivateKey) {
+	t.Helper()
+	var err error
+	if signing, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+		t.Fatal(err)
+	}
+	if other, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+		t.Fatal(err)
+	}
+	return signing, other
+}
+
+// signJWT builds a compact RS256 JWT over claims with the given header kid and alg.
+func signJWT(t *testing.T, key *rsa.PrivateKey, alg, kid string, claims map[string]any) string {
+	t.Helper()
+	enc := func(v any) string {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	signed := enc(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"}) + "." + enc(claims)
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// idClaims returns valid ID token claims, with overrides applied (nil deletes a claim).
+func idClaims(overrides map[string]any) map[string]any {
+	c := map[string]any{
+		"sub":            "user-1",
+		"email":          "john.smith@example.com",
+		"iss":            testIssuer,
+		"aud":            testAudience,
+		"token_use":      "id",
+		"exp":            testNow.Add(time.Hour).Unix(),
+		"cognito:groups": []string{"adjuster"},
+	}
+	for k, v := range overrides {
+		if v == nil {
+			delete(c, k)
+			continue
+		}
+		c[k] = v
+	}
+	return c
+}
+
+func TestVerify(t *testing.T) {
+	signing, other := testKeys(t)
+	v := &Verifier{
+		Keys:     StaticKeys{testKid: &signing.PublicKey},
+		Issuer:   testIssuer,
+		Audience: testAudience,
+		Leeway:   30 * time.Second,
+		Now:      func() time.Time { return testNow },
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr string // substring of the error; "" means valid
+	}{
+		{"valid id token", signJWT(t, signing, "RS256", testKid, idClaims(nil)), ""},
+		{"aud as array", signJWT(t, signing, "RS256", testKid, idClaims(map[string]any{"aud": []string{"other", testAudience}})), ""},
+		{"expired within leeway", signJWT(t, signing, "RS256", testKid, idClaims(map[string]any{"exp": testNow.Add(-10 * time.Second).Unix()})), ""},
+		{"bad signature", signJWT(t, other, "RS256", testKid, idClaims(nil)), "bad signature"},
+		{"unknown kid", signJWT(t, signing, "RS256", "kid-2", idClaims(nil)), ErrUnknownKey.Error()},
+		{"alg none", signJWT(t, signing, "none", testKid, idClaims(nil)), "unsupported alg"},
+		{"wrong issuer", signJWT(t, signing, "RS256", testKid, idClaims(map[string]any{"iss": "https://evil.example.com"})), "issuer mismatch"},
+		{"wrong audience", signJWT(t, signing, "RS256", testKid, idClaims(map[string]any{"aud": "other-client"})), "audience mismatch"},
+		{"expired", signJWT(t, signing, "RS256", testKid, idClaims(map[string]any{"exp": testNow.Add(-time.Minute).Unix()})), "expired"},
+		{"missing exp", signJWT(t, signing, "RS256", testKid, idClaims(map[string]any{"exp": nil})), "expired"},
+		{"not yet valid", signJWT(t, signing, "RS256", testKid, idClaims(map[string]any{"nbf": testNow.Add(time.Minute).Unix()})), "not yet valid"},
+		{"access token for id verifier", signJWT(t, signing, "RS256", testKid, idClaims(map[string]any{"token_use": "access"})), "token_use"},
+		{"missing sub", signJWT(t, signing, "RS256", testKid, idClaims(map[string]any{"sub": nil})), "missing sub"},
+		{"malformed", "not-a-jwt", "malformed"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			user, err := v.Verify(context.Background(), tc.token)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Verify: %v", err)
+				}
+				if user.Sub != "user-1" || !slices.Contains(user.Groups, "adjuster") {
+					t.Errorf("user = %+v", user)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidToken) {
+				t.Fatalf("err = %v, want ErrInvalidToken", err)
+			}
+			if !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("err = %q, want it to mention %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyAccessToken(t *testing.T) {
+	signing, _ := testKeys(t)
+	v := &Verifier{
+		Keys:     StaticKeys{testKid: &signing.PublicKey},
+		Issuer:   testIssuer,
+		Audience: testAudience,
+		TokenUse: "access",
+		Now:      func() time.Time { return testNow },
+	}
+	access := func(clientID string) string {
+		return signJWT(t, signing, "RS256", testKid, idClaims(map[string]any{
+			"token_use": "access", "aud": nil, "client_id": clientID,
+		}))
+	}
+
+	if _, err := v.Verify(context.Background(), access(testAudience)); err != nil {
+		t.Errorf("matching client_id: %v", err)
+	}
+	if _, err := v.Verify(context.Background(), access("other-client")); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("wrong client_id: err = %v, want ErrInvalidToken", err)
+	}
+}
@@ -0,0 +1,90 @@
+package authz
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/models"
+)
+
+// ErrForbidden is returned when an authenticated user may not perform an action.
+var ErrForbidden = errors.New("forbidden")
+
+// Action is an operation a user may attempt on a claim.
+type Action string
+
+// Possible values for Action
+const (
+	ActionRead         Action = "read"          // view a claim or list a user's claims
+	ActionReadRedacted Action = "read_redacted" // download a claim's PII-redacted copy
+	ActionCreate       Action = "create"        // open a new claim
+	ActionUpdate       Action = "update"        // change claim status/metadata (claims team)
+	ActionDelete       Action = "delete"        // withdraw a claim
+)
+
+// Can reports whether user may perform action on claim.
+//
+//	           read      read_redacted  create  update  delete
+//	claimant   own       own            own     -       own
+//	adjuster   any       any            own     any     own
+//	admin      any       any            own     any     any
+//	vendor     own       assigned       own     -       own
+//
+// Claims are always created under the caller's own sub, so create is owner-only for every role.
+// A vendor reads only the claims listing its sub in Vendors, so pass the stored claim, not
+// just its owner, when checking ActionReadRedacted for a vendor.
+func Can(user models.UserClaims, action Action, claim models.Claim) bool {
+	if user.Sub == "" {
+		return false
+	}
+	own := claim.UserID == user.Sub
+	admin := user.HasRole(models.RoleAdmin)
+	staff := admin || user.HasRole(models.RoleAdjuster)
+
+	switch action {
+	case ActionRead:
+		return own || staff
+	case ActionReadRedacted:
+		return own || staff || (user.HasRole(models.RoleVendor) && claim.AssignedTo(user.Sub))
+	case ActionCreate:
+		return own
+	case ActionUpdate:
+		return staff
+	case ActionDelete:
+		return own || admin
+	}
+	return false
+}
+
+// RedactedOnly reports whether user may see claim only through its redacted copy.
+func RedactedOnly(user models.UserClaims, claim models.Claim) bool {
+	return !Can(user, ActionRead, claim) && Can(user, ActionReadRedacted, claim)
+}
+
+// ClaimView returns the view of claim to send user. Vendor assignments are the claims
+// team's business, so Vendors is left out for anyone who cannot update the claim.
+func ClaimView(user models.UserClaims, claim models.Claim) models.ClaimView {
+	v := claim.View()
+	if !Can(user, ActionUpdate, claim) {
+		v.Vendors = nil
+	}
+	return v
+}
+
+// Authorize is Can returning ErrForbidden on denial.
+func Authorize(user models.UserClaims, action Action, claim models.Claim) error {
+	if !Can(user, action, claim) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// TargetUser returns the owner a request addresses: the ?user_id= query parameter
+// when present (staff acting on another user's claims), otherwise the caller.
+// Callers must still check Can against the returned owner.
+func TargetUser(user models.UserClaims, query map[string]string) string {
+	if q := strings.TrimSpace(query["user_id"]); q != "" {
+		return q
+	}
+	return user.Sub
+}
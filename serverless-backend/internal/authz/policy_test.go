@@ -0,0 +1,144 @@
+package authz
+
+import (
+	"testing"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/models"
+)
+
+// TestCan walks the matrix in Can's doc comment: every role × action × own/other claim.
+// The other user's claim here has no vendors assigned; see TestCanVendorAssignment.
+func TestCan(t *testing.T) {
+	users := map[string]models.UserClaims{
+		"claimant": {Sub: "u-claimant", Roles: []models.Role{models.RoleClaimant}},
+		"adjuster": {Sub: "u-adjuster", Roles: []models.Role{models.RoleClaimant, models.RoleAdjuster}},
+		"admin":    {Sub: "u-admin", Roles: []models.Role{models.RoleClaimant, models.RoleAdmin}},
+		"vendor":   {Sub: "u-vendor", Roles: []models.Role{models.RoleClaimant, models.RoleVendor}},
+	}
+
+	type cell struct{ own, other bool }
+	matrix := map[string]map[Action]cell{
+		"claimant": {
+			ActionRead:         {own: true, other: false},
+			ActionReadRedacted: {own: true, other: false},
+			ActionCreate:       {own: true, other: false},
+			ActionUpdate:       {own: false, other: false},
+			ActionDelete:       {own: true, other: false},
+		},
+		"adjuster": {
+			ActionRead:         {own: true, other: true},
+			ActionReadRedacted: {own: true, other: true},
+			ActionCreate:       {own: true, other: false},
+			ActionUpdate:       {own: true, other: true},
+			ActionDelete:       {own: true, other: false},
+		},
+		"admin": {
+			ActionRead:         {own: true, other: true},
+			ActionReadRedacted: {own: true, other: true},
+			ActionCreate:       {own: true, other: false},
+			ActionUpdate:       {own: true, other: true},
+			ActionDelete:       {own: true, other: true},
+		},
+		"vendor": {
+			ActionRead:         {own: true, other: false},
+			ActionReadRedacted: {own: true, other: false},
+			ActionCreate:       {own: true, other: false},
+			ActionUpdate:       {own: false, other: false},
+			ActionDelete:       {own: true, other: false},
+		},
+	}
+
+	for role, actions := range matrix {
+		user := users[role]
+		for action, want := range actions {
+			own := models.Claim{UserID: user.Sub}
+			other := models.Claim{UserID: "u-someone-else"}
+			if got := Can(user, action, own); got != want.own {
+				t.Errorf("%s %s own claim = %v, want %v", role, action, got, want.own)
+			}
+			if got := Can(user, action, other); got != want.other {
+				t.Errorf("%s %s other's claim = %v, want %v", role, action, got, want.other)
+			}
+		}
+	}
+}
+
+func TestCanVendorAssignment(t *testing.T) {
+	vendor := models.UserClaims{Sub: "u-vendor", Roles: []models.Role{models.RoleClaimant, models.RoleVendor}}
+	claimant := models.UserClaims{Sub: "u-claimant", Roles: []models.Role{models.RoleClaimant}}
+
+	tests := []struct {
+		name  string
+		user  models.UserClaims
+		claim models.Claim
+		want  bool
+	}{
+		{"assigned vendor", vendor, models.Claim{UserID: "u-1", Vendors: []string{"u-vendor"}}, true},
+		{"assigned among others", vendor, models.Claim{UserID: "u-1", Vendors: []string{"u-other", "u-vendor"}}, true},
+		{"vendor on another owner's claim", vendor, models.Claim{UserID: "u-2", Vendors: []string{"u-other"}}, false},
+		{"vendor on unassigned claim", vendor, models.Claim{UserID: "u-2"}, false},
+		{"claimant named as vendor", claimant, models.Claim{UserID: "u-2", Vendors: []string{"u-claimant"}}, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Can(tc.user, ActionReadRedacted, tc.claim); got != tc.want {
+				t.Errorf("Can(read_redacted) = %v, want %v", got, tc.want)
+			}
+			if Can(tc.user, ActionRead, tc.claim) {
+				t.Error("assignment must never grant the original")
+			}
+			if got := RedactedOnly(tc.user, tc.claim); got != tc.want {
+				t.Errorf("RedactedOnly = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCanDeniesAnonymousAndUnknownActions(t *testing.T) {
+	admin := models.UserClaims{Sub: "u-admin", Roles: []models.Role{models.RoleAdmin}}
+	anon := models.UserClaims{Roles: []models.Role{models.RoleAdmin}}
+
+	for _, action := range []Action{ActionRead, ActionReadRedacted, ActionCreate, ActionUpdate, ActionDelete} {
+		if Can(anon, action, models.Claim{}) {
+			t.Errorf("user without sub may %s", action)
+		}
+	}
+	if Can(admin, Action("purge"), models.Claim{UserID: admin.Sub}) {
+		t.Error("unknown action allowed")
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	claimant := models.UserClaims{Sub: "u-1", Roles: []models.Role{models.RoleClaimant}}
+	if err := Authorize(claimant, ActionRead, models.Claim{UserID: "u-1"}); err != nil {
+		t.Errorf("own claim: %v", err)
+	}
+	if err := Authorize(claimant, ActionRead, models.Claim{UserID: "u-2"}); err != ErrForbidden {
+		t.Errorf("other's claim: err = %v, want ErrForbidden", err)
+	}
+}
+
+func TestClaimViewHidesVendorsFromNonStaff(t *testing.T) {
+	claim := models.Claim{UserID: "u-claimant", ClaimID: "c-1", Vendors: []string{"u-vendor", "u-vendor-2"}}
+	tests := []struct {
+		name    string
+		user    models.UserClaims
+		vendors int
+	}{
+		{"owner", models.UserClaims{Sub: "u-claimant", Roles: []models.Role{models.RoleClaimant}}, 0},
+		{"vendor", models.UserClaims{Sub: "u-vendor", Roles: []models.Role{models.RoleClaimant, models.RoleVendor}}, 0},
+		{"adjuster", models.UserClaims{Sub: "u-adjuster", Roles: []models.Role{models.RoleClaimant, models.RoleAdjuster}}, 2},
+		{"admin", models.UserClaims{Sub: "u-admin", Roles: []models.Role{models.RoleClaimant, models.RoleAdmin}}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := ClaimView(tt.user, claim)
+			if len(v.Vendors) != tt.vendors {
+				t.Errorf("vendors = %v, want %d", v.Vendors, tt.vendors)
+			}
+			if v.ClaimID != "c-1" {
+				t.Errorf("claim_id = %q, want c-1", v.ClaimID)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+// Package classify assigns a canonical claim category (auto, property, theft, ...) to a
+// claim letter with weighted keyword rules. It is deterministic and runs offline.
+package classify
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+//go:embed rules.json
+var defaultRules []byte
+
+// Rules is the on-disk rules file: each category scores the sum of its keyword weights
+// over every match in the text; the best-scoring category wins if it reaches MinScore.
+type Rules struct {
+	MinScore   int            `json:"min_score"`
+	Default    string         `json:"default"` // category when nothing reaches MinScore
+	Categories []CategoryRule `json:"categories"`
+}
+
+// CategoryRule lists the weighted keywords for one category. Keywords are matched
+// case-insensitively on word boundaries and may be phrases.
+type CategoryRule struct {
+	Category string         `json:"category"`
+	Keywords map[string]int `json:"keywords"`
+}
+
+// Classifier is a compiled rule set. It is safe for concurrent use.
+type Classifier struct {
+	minScore   int
+	def        string
+	categories []category
+}
+
+type category struct {
+	name     string
+	keywords []keyword
+}
+
+type keyword struct {
+	rx     *regexp.Regexp
+	weight int
+}
+
+// Result is a classification: the chosen category and its score (0 for the default).
+type Result struct {
+	Category string
+	Score    int
+}
+
+// Load compiles the rules file at path, or the built-in rules when path is empty.
+func Load(path string) (*Classifier, error) {
+	raw := defaultRules
+	if path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		raw = b
+	}
+	var r Rules
+	if err := json.Unmarshal(raw, &r); err != nil {
+		return nil, fmt.Errorf("classify rules: %w", err)
+	}
+	return New(r)
+}
+
+// New compiles r. Categories earlier in the list win ties.
+func New(r Rules) (*Classifier, error) {
+	if len(r.Categories) == 0 {
+		return nil, errors.New("classify rules: no categories")
+	}
+	c := &Classifier{minScore: r.MinScore, def: strings.ToLower(strings.TrimSpace(r.Default))}
+	for _, cr := range r.Categories {
+		name := strings.ToLower(strings.TrimSpace(cr.Category))
+		if name == "" {
+			return nil, errors.New("classify rules: empty category name")
+		}
+		cat := category{name: name}
+		for kw, w := range cr.Keywords {
+			rx, err := regexp.Compile(`(?i)\b` + regexp.QuoteMeta(strings.TrimSpace(kw)) + `\b`)
+			if err != nil {
+				return nil, fmt.Errorf("classify rules: keyword %q: %w", kw, err)
+			}
+			cat.keywords = append(cat.keywords, keyword{rx: rx, weight: w})
+		}
+		c.categories = append(c.categories, cat)
+	}
+	return c, nil
+}
+
+// Classify scores text against every category and returns the winner.
+func (c *Classifier) Classify(text string) Result {
+	best := Result{Category: c.def}
+	for _, cat := range c.categories {
+		score := 0
+		for _, kw := range cat.keywords {
+			score += kw.weight * len(kw.rx.FindAllStringIndex(text, -1))
+		}
+		if score >= c.minScore && score > best.Score {
+			best = Result{Category: cat.name, Score: score}
+		}
+	}
+	return best
+}
@@ -0,0 +1,157 @@
+package classify
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// corpus is the repository's sample letters, one per kind of claim.
+const corpus = "../../../data"
+
+func TestClassifyCorpus(t *testing.T) {
+	c, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"auto_accident_john_smith":     "auto",
+		"bike_accident_lisa_nguyen":    "auto",
+		"burst_pipe_david_gonzalez":    "property",
+		"car_theft_angelica_moore":     "theft",
+		"dog_bite_sarah_jones":         "liability",
+		"fire_damage_robert_thomas":    "fire",
+		"flood_damage_tina_cole":       "flood",
+		"hit_and_run_marcus_wilson":    "auto",
+		"injury_at_work_michael_brown": "injury",
+		"property_damage_karen_lee":    "property",
+	}
+	letters, err := filepath.Glob(filepath.Join(corpus, "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(letters) != len(want) {
+		t.Fatalf("%d letters in %s, want %d; add new ones to this table", len(letters), corpus, len(want))
+	}
+	for _, path := range letters {
+		name := strings.TrimSuffix(filepath.Base(path), ".txt")
+		t.Run(name, func(t *testing.T) {
+			text, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := c.Classify(string(text))
+			if got.Category != want[name] {
+				t.Errorf("category = %s (score %d), want %s", got.Category, got.Score, want[name])
+			}
+			if got.Score < 3 {
+				t.Errorf("score = %d, want at least the built-in min_score 3", got.Score)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	tests := []struct {
+		name    string
+		path    string
+		wantErr string
+	}{
+		{"built-in", "", ""},
+		{"file", write("ok.json", `{"min_score":1,"default":"Other","categories":[{"category":" Marine ","keywords":{"boat":2}}]}`), ""},
+		{"missing file", filepath.Join(dir, "missing.json"), "no such file"},
+		{"bad json", write("bad.json", `{"categories":`), "classify rules"},
+		{"no categories", write("empty.json", `{"min_score":1,"categories":[]}`), "no categories"},
+		{"empty category name", write("noname.json", `{"categories":[{"category":"  ","keywords":{"boat":1}}]}`), "empty category name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Load(tt.path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want one mentioning %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(c.categories) == 0 {
+				t.Error("no categories compiled")
+			}
+		})
+	}
+
+	// Names are trimmed and lowercased, for the default too.
+	c, err := Load(filepath.Join(dir, "ok.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Classify("a BOAT sank"); got != (Result{Category: "marine", Score: 2}) {
+		t.Errorf("Classify = %+v, want marine 2", got)
+	}
+	if got := c.Classify("nothing"); got != (Result{Category: "other"}) {
+		t.Errorf("Classify = %+v, want other 0", got)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	c, err := New(Rules{
+		MinScore: 3,
+		Default:  "other",
+		Categories: []CategoryRule{
+			{Category: "theft", Keywords: map[string]int{"stolen": 3, "break-in": 2}},
+			{Category: "auto", Keywords: map[string]int{"car": 2, "hit & run": 3}},
+			{Category: "fire", Keywords: map[string]int{"fire": 3}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		text string
+		want Result
+	}{
+		{"empty text", "", Result{Category: "other"}},
+		{"below min score", "my car", Result{Category: "other"}},
+		{"at min score", "STOLEN", Result{Category: "theft", Score: 3}},
+		{"every match counts", "car, car and another car", Result{Category: "auto", Score: 6}},
+		{"phrase with punctuation", "a hit & run", Result{Category: "auto", Score: 3}},
+		{"word boundaries", "carpet fires and a cart", Result{Category: "other"}},
+		{"highest score wins", "stolen car, then a car fire and more car", Result{Category: "auto", Score: 6}},
+		// Tied at 3: theft is listed first, and a later category must beat it outright.
+		{"earlier category wins a tie", "a fire, and the car was stolen", Result{Category: "theft", Score: 3}},
+		{"tie order is rule order, not text order", "fire then stolen", Result{Category: "theft", Score: 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.Classify(tt.text); got != tt.want {
+				t.Errorf("Classify(%q) = %+v, want %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassifyNoDefault(t *testing.T) {
+	// Without a default, text below MinScore gets no category.
+	c, err := New(Rules{MinScore: 5, Categories: []CategoryRule{{Category: "fire", Keywords: map[string]int{"fire": 3}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Classify("a small fire"); got != (Result{}) {
+		t.Errorf("Classify = %+v, want no category", got)
+	}
+	if got := c.Classify("fire, fire"); got != (Result{Category: "fire", Score: 6}) {
+		t.Errorf("Classify = %+v, want fire 6", got)
+	}
+}
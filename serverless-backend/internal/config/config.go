@@ -8,25 +8,91 @@ import (
 	"time"
 )
 
+// Upload modes for presign.
+const (
+	UploadModePut  = "put"  // presigned PUT; size checked only by the indexer
+	UploadModePost = "post" // presigned POST policy with content-length-range
+)
+
 // Env holds the configuration values for the application.
 type Env struct {
 	Region        string
 	Bucket        string
 	Table         string
+	AuditTable    string // append-only claim history, see internal/audit
 	PresignTTL    time.Duration
+	DownloadTTL   time.Duration
+	ReaperGrace   time.Duration // extra wait past PresignTTL before an upload is declared dead
 	DevBypassAuth bool
+	CursorSecret  string // HMAC key for list pagination cursors
+
+	UploadMode     string // UploadModePut or UploadModePost
+	MaxUploadBytes int64  // enforced by POST policy and by the indexer
+	AllowedTypes   string // comma-separated MIME types; empty means validate.DefaultContentTypes
+
+	MultipartPartSize int64 // bytes per part for multipart uploads (>= 5 MiB)
+	MaxMultipartBytes int64 // size cap for multipart uploads
+
+	Scanner      string        // malware scanner backend: "clamav" or "none" (see internal/scan)
+	ClamAVAddr   string        // clamd host:port
+	ScanTimeout  time.Duration // per-object scan deadline
+	ScanMaxBytes int64         // largest object sent to the scanner; match clamd's StreamMaxLength
+
+	ClassifyRules string // optional rules file for internal/classify; empty uses the built-in rules
+	PIIDetectors  string // comma-separated internal/pii detector kinds; empty means all
+
+	SearchBackend string // full-text index backend: "s3", "dir" or "none" (see internal/search)
+	SearchDir     string // shard directory for SEARCH_BACKEND=dir
+
+	// Cognito JWT verification (used when the API Gateway authorizer context is absent).
+	UserPoolID       string
+	UserPoolClientID string
+	JWKSFile         string // optional local JWKS; skips fetching from Cognito
 }
 
 // MustLoad reads the environment variables and returns an Env struct.
 func MustLoad() Env {
 	ttlSec, _ := strconv.Atoi(get("PRESIGN_TTL_SECONDS", "300"))
+	dlSec, _ := strconv.Atoi(get("DOWNLOAD_TTL_SECONDS", "60"))
+	graceSec, _ := strconv.Atoi(get("REAPER_GRACE_SECONDS", "900"))
+	maxBytes, _ := strconv.ParseInt(get("MAX_UPLOAD_BYTES", "10485760"), 10, 64)      // 10 MiB
+	partSize, _ := strconv.ParseInt(get("MULTIPART_PART_SIZE", "8388608"), 10, 64)    // 8 MiB
+	maxMulti, _ := strconv.ParseInt(get("MAX_MULTIPART_BYTES", "1073741824"), 10, 64) // 1 GiB
+	scanSec, _ := strconv.Atoi(get("SCAN_TIMEOUT_SECONDS", "60"))
+	scanMax, _ := strconv.ParseInt(get("SCAN_MAX_BYTES", "26214400"), 10, 64) // 25 MiB, clamd's default
 	devBypass := get("DEV_BYPASS_AUTH", "") == "true"
 	e := Env{
 		Region:        get("AWS_REGION", "us-east-1"),
 		Bucket:        must("S3_BUCKET"),
 		Table:         must("DDB_TABLE"),
+		AuditTable:    must("AUDIT_TABLE"),
 		PresignTTL:    time.Duration(ttlSec) * time.Second,
+		DownloadTTL:   time.Duration(dlSec) * time.Second,
+		ReaperGrace:   time.Duration(graceSec) * time.Second,
 		DevBypassAuth: devBypass,
+		CursorSecret:  get("CURSOR_SECRET", ""),
+
+		UploadMode:     get("UPLOAD_MODE", UploadModePut),
+		MaxUploadBytes: maxBytes,
+		AllowedTypes:   get("ALLOWED_CONTENT_TYPES", ""),
+
+		MultipartPartSize: partSize,
+		MaxMultipartBytes: maxMulti,
+
+		Scanner:      get("SCANNER", "clamav"),
+		ClamAVAddr:   get("CLAMAV_ADDR", ""),
+		ScanTimeout:  time.Duration(scanSec) * time.Second,
+		ScanMaxBytes: scanMax,
+
+		ClassifyRules: get("CLASSIFY_RULES_FILE", ""),
+		PIIDetectors:  get("PII_DETECTORS", ""),
+
+		SearchBackend: get("SEARCH_BACKEND", "s3"),
+		SearchDir:     get("SEARCH_DIR", ""),
+
+		UserPoolID:       get("COGNITO_USER_POOL_ID", ""),
+		UserPoolClientID: get("COGNITO_CLIENT_ID", ""),
+		JWKSFile:         get("JWKS_FILE", ""),
 	}
 	return e
 }
@@ -53,8 +119,20 @@ func (e Env) Validate() error {
 	if e.Table == "" {
 		return fmt.Errorf("missing env DDB_TABLE")
 	}
+	if e.AuditTable == "" {
+		return fmt.Errorf("missing env AUDIT_TABLE")
+	}
 	if e.Bucket == "" {
 		return fmt.Errorf("missing env S3_BUCKET")
 	}
+	if e.UploadMode != UploadModePut && e.UploadMode != UploadModePost {
+		return fmt.Errorf("UPLOAD_MODE must be %q or %q", UploadModePut, UploadModePost)
+	}
+	if e.MaxUploadBytes <= 0 {
+		return fmt.Errorf("MAX_UPLOAD_BYTES must be positive")
+	}
+	if e.MultipartPartSize < 5<<20 {
+		return fmt.Errorf("MULTIPART_PART_SIZE must be at least 5 MiB")
+	}
 	return nil
 }
@@ -0,0 +1,277 @@
+package ddb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/models"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/s3io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// attachmentSep joins a claim ID and attachment ID into an attachment's sort key.
+const attachmentSep = "#ATT#"
+
+// AttachmentItemID returns the sort key (claim_id attribute) of an attachment item.
+// Attachment updates reuse the claim methods (UpsertComplete, MarkFailed) with this ID.
+func AttachmentItemID(claimID, attachmentID string) string {
+	return claimID + attachmentSep + attachmentID
+}
+
+// SplitItemID splits an attachment sort key into its claim and attachment IDs.
+// ok is false for plain claim IDs.
+func SplitItemID(itemID string) (claimID, attachmentID string, ok bool) {
+	return strings.Cut(itemID, attachmentSep)
+}
+
+// ItemForKey maps an object key to the item it belongs to: the claim for
+// user/<sub>/<claim>.<ext>, or the attachment item for user/<sub>/<claim>/<att>.<ext>.
+func ItemForKey(key string) (userID, itemID string, ok bool) {
+	if u, c, _, ok := s3io.ParseKey(key); ok {
+		return u, c, true
+	}
+	if u, c, a, _, ok := s3io.ParseAttachmentKey(key); ok {
+		return u, AttachmentItemID(c, a), true
+	}
+	return "", "", false
+}
+
+// PutPendingAttachment writes an UPLOADING attachment and bumps the claim's attachment
+// count and version in one transaction. It returns ErrConflict if the claim is missing, not
+// in a status that accepts attachments (see models.ClaimStatus.AcceptsAttachments), or
+// already has models.MaxAttachments attachments, and ErrRetryable if DynamoDB cancelled the
+// transaction for any other reason. The claim update is conditioned on the version read
+// just before; losing only that race (another attachment, a review) re-reads and retries.
+func (r *Repo) PutPendingAttachment(ctx context.Context, a models.Attachment) error {
+	a, item, set, err := pendingAttachment(a)
+	if err != nil {
+		return err
+	}
+	ev, err := r.auditPut(ctx, a.UserID, a.ItemID, models.AuditAttach, nil, set)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		parent, err := r.getItem(ctx, a.UserID, a.ClaimID)
+		if err != nil {
+			return err
+		}
+		expected := itemVersion(parent)
+		w := types.TransactWriteItem{
+			Update: &types.Update{
+				TableName: aws.String(r.Table),
+				Key: map[string]types.AttributeValue{
+					"user_id":  &types.AttributeValueMemberS{Value: a.UserID},
+					"claim_id": &types.AttributeValueMemberS{Value: a.ClaimID},
+				},
+				// versioned appends the version's ADD clause, so the count is SET here.
+				UpdateExpression: awsStr("SET attachments_status = :u, attachment_count = if_not_exists(attachment_count, :zero) + :one"),
+				ExpressionAttributeNames: map[string]string{
+					"#s": "status",
+				},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":u":    &types.AttributeValueMemberS{Value: string(models.StatusUploading)},
+					":zero": &types.AttributeValueMemberN{Value: "0"},
+					":one":  &types.AttributeValueMemberN{Value: "1"},
+					":sc":   &types.AttributeValueMemberS{Value: string(models.StatusScanning)},
+					":c":    &types.AttributeValueMemberS{Value: string(models.StatusComplete)},
+					":max":  &types.AttributeValueMemberN{Value: strconv.Itoa(models.MaxAttachments)},
+				},
+				// Keep in step with models.ClaimStatus.AcceptsAttachments.
+				ConditionExpression: awsStr("attribute_exists(claim_id) AND #s IN (:u, :sc, :c) AND " +
+					"(attribute_not_exists(attachment_count) OR attachment_count < :max)"),
+			},
+		}
+		versioned(&w, expected)
+
+		_, err = r.DB.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+			TransactItems: []types.TransactWriteItem{
+				w,
+				{
+					Put: &types.Put{
+						TableName:           aws.String(r.Table),
+						Item:                item,
+						ConditionExpression: awsStr("attribute_not_exists(claim_id)"),
+					},
+				},
+				ev,
+			},
+		})
+		var tce *types.TransactionCanceledException
+		if errors.As(err, &tce) {
+			if attempt < parentAttempts && versionMoved(tce, expected) {
+				continue
+			}
+			return cancelled(tce)
+		}
+		return err
+	}
+}
+
+// parentAttempts bounds how often attachment bookkeeping re-reads a claim after losing
+// a race for its version.
+const parentAttempts = 5
+
+// versionMoved reports whether a transaction whose first item updates a claim at version
+// expected failed because that claim's version has since changed.
+func versionMoved(tce *types.TransactionCanceledException, expected int64) bool {
+	if len(tce.CancellationReasons) == 0 {
+		return false
+	}
+	reason := tce.CancellationReasons[0]
+	return aws.ToString(reason.Code) == "ConditionalCheckFailed" && len(reason.Item) > 0 &&
+		itemVersion(reason.Item) != expected
+}
+
+// cancelled maps a cancelled transaction to ErrConflict when one of its conditions failed,
+// and to ErrRetryable otherwise (ThrottlingError, TransactionConflict and the like).
+func cancelled(tce *types.TransactionCanceledException) error {
+	for _, r := range tce.CancellationReasons {
+		if aws.ToString(r.Code) == "ConditionalCheckFailed" {
+			return &ConflictError{}
+		}
+	}
+	return fmt.Errorf("%w: %v", ErrRetryable, tce)
+}
+
+// pendingAttachment fills in a new UPLOADING attachment and returns it with its item and
+// the fields its audit event records.
+func pendingAttachment(a models.Attachment) (models.Attachment, map[string]types.AttributeValue, fields, error) {
+	a.ItemID = AttachmentItemID(a.ClaimID, a.AttachmentID)
+	a.Status = models.StatusUploading
+	a.CreatedAt = NowISO()
+	a.Version = 1 // a new item; the claim's version is bumped separately, in the same transaction
+	item, err := attributevalue.MarshalMap(a)
+	if err != nil {
+		return a, nil, nil, err
+	}
+	var set fields
+	if err := attributevalue.UnmarshalMap(item, &set); err != nil {
+		return a, nil, nil, err
+	}
+	return a, item, set, nil
+}
+
+// GetAttachment loads a single attachment. Returns ErrNotFound if absent.
+func (r *Repo) GetAttachment(ctx context.Context, userID, claimID, attachmentID string) (models.Attachment, error) {
+	out, err := r.DB.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.Table),
+		Key: map[string]types.AttributeValue{
+			"user_id":  &types.AttributeValueMemberS{Value: userID},
+			"claim_id": &types.AttributeValueMemberS{Value: AttachmentItemID(claimID, attachmentID)},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return models.Attachment{}, err
+	}
+	if len(out.Item) == 0 {
+		return models.Attachment{}, ErrNotFound
+	}
+
+	var a models.Attachment
+	if err := attributevalue.UnmarshalMap(out.Item, &a); err != nil {
+		return models.Attachment{}, err
+	}
+	return a, nil
+}
+
+// ListAttachments returns a claim's attachments, oldest first.
+func (r *Repo) ListAttachments(ctx context.Context, userID, claimID string) ([]models.Attachment, error) {
+	p := dynamodb.NewQueryPaginator(r.DB, &dynamodb.QueryInput{
+		TableName:              aws.String(r.Table),
+		KeyConditionExpression: aws.String("user_id = :u AND begins_with(claim_id, :p)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":u": &types.AttributeValueMemberS{Value: userID},
+			":p": &types.AttributeValueMemberS{Value: claimID + attachmentSep},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+
+	var atts []models.Attachment
+	for p.HasMorePages() {
+		out, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var page []models.Attachment
+		if err := attributevalue.UnmarshalListOfMaps(out.Items, &page); err != nil {
+			return nil, err
+		}
+		atts = append(atts, page...)
+	}
+	return atts, nil
+}
+
+// RollUpAttachments recomputes the claim's attachments_status from its attachments.
+// Call it after any attachment changes status. Like every other write it is conditioned
+// on the claim's version (read before the attachments are listed) and bumps it; if the
+// claim changes in between, the roll-up is recomputed. A status that is already current is
+// not rewritten. It is the one write without an audit event: the summary is derived
+// entirely from attachment changes, which are audited.
+func (r *Repo) RollUpAttachments(ctx context.Context, userID, claimID string) (models.ClaimStatus, error) {
+	for attempt := 1; ; attempt++ {
+		parent, err := r.getItem(ctx, userID, claimID)
+		if err != nil {
+			return "", err
+		}
+		expected := itemVersion(parent)
+
+		atts, err := r.ListAttachments(ctx, userID, claimID)
+		if err != nil {
+			return "", err
+		}
+		statuses := make([]models.ClaimStatus, 0, len(atts))
+		for _, a := range atts {
+			statuses = append(statuses, a.Status)
+		}
+		status := models.RollUp(statuses)
+		switch {
+		case status == "":
+			return "", nil
+		case parent == nil:
+			return "", ErrNotFound
+		case attrS(parent, "attachments_status") == string(status):
+			return status, nil
+		}
+
+		w := types.TransactWriteItem{
+			Update: &types.Update{
+				TableName: aws.String(r.Table),
+				Key: map[string]types.AttributeValue{
+					"user_id":  &types.AttributeValueMemberS{Value: userID},
+					"claim_id": &types.AttributeValueMemberS{Value: claimID},
+				},
+				UpdateExpression: awsStr("SET attachments_status = :s"),
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":s": &types.AttributeValueMemberS{Value: string(status)},
+				},
+				ConditionExpression: awsStr("attribute_exists(claim_id)"),
+			},
+		}
+		versioned(&w, expected)
+		_, err = r.DB.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+			TransactItems: []types.TransactWriteItem{w},
+		})
+		var tce *types.TransactionCanceledException
+		if errors.As(err, &tce) {
+			switch {
+			case len(tce.CancellationReasons) > 0 && aws.ToString(tce.CancellationReasons[0].Code) == "ConditionalCheckFailed" &&
+				len(tce.CancellationReasons[0].Item) == 0:
+				return "", ErrNotFound
+			case attempt < parentAttempts && versionMoved(tce, expected):
+				continue
+			}
+			return "", cancelled(tce)
+		}
+		return status, err
+	}
+}
@@ -0,0 +1,130 @@
+package ddb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestCancelled(t *testing.T) {
+	reasons := func(codes ...string) *types.TransactionCanceledException {
+		tce := &types.TransactionCanceledException{}
+		for _, c := range codes {
+			tce.CancellationReasons = append(tce.CancellationReasons, types.CancellationReason{Code: aws.String(c)})
+		}
+		return tce
+	}
+
+	tests := []struct {
+		name string
+		tce  *types.TransactionCanceledException
+		want error
+	}{
+		{"parent condition failed", reasons("ConditionalCheckFailed", "None", "None"), ErrConflict},
+		{"attachment already exists", reasons("None", "ConditionalCheckFailed", "None"), ErrConflict},
+		{"throttled", reasons("ThrottlingError", "None", "None"), ErrRetryable},
+		{"transaction conflict", reasons("None", "TransactionConflict", "None"), ErrRetryable},
+		{"no reasons", reasons(), ErrRetryable},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := cancelled(tc.tce)
+			if !errors.Is(err, tc.want) {
+				t.Errorf("cancelled = %v, want %v", err, tc.want)
+			}
+			if tc.want == ErrRetryable && errors.Is(err, ErrConflict) {
+				t.Error("retryable cancellation reported as a conflict")
+			}
+		})
+	}
+}
+
+func TestPutPendingAttachmentParentStatus(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		status models.ClaimStatus
+		ok     bool
+	}{
+		{models.StatusUploading, true},
+		{models.StatusScanning, true},
+		{models.StatusComplete, true},
+		{models.StatusFailed, false},
+		{models.StatusQuarantined, false},
+		{models.StatusWithdrawn, false},
+	}
+	for _, tc := range tests {
+		t.Run(string(tc.status), func(t *testing.T) {
+			m := &MemStore{}
+			if err := m.PutPending(ctx, models.Claim{UserID: "u-1", ClaimID: "c-1", Status: models.StatusUploading}); err != nil {
+				t.Fatal(err)
+			}
+			if tc.status != models.StatusUploading {
+				if _, err := m.write(ctx, "u-1", "c-1", models.AuditFail, AnyVersion, exists, fields{"status": tc.status}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := m.PutPendingAttachment(ctx, models.Attachment{UserID: "u-1", ClaimID: "c-1", AttachmentID: "a-1"})
+			if tc.ok && err != nil {
+				t.Fatalf("PutPendingAttachment: %v", err)
+			}
+			if !tc.ok && !errors.Is(err, ErrConflict) {
+				t.Fatalf("err = %v, want ErrConflict", err)
+			}
+			if got := tc.status.AcceptsAttachments(); got != tc.ok {
+				t.Errorf("AcceptsAttachments = %v, want %v", got, tc.ok)
+			}
+		})
+	}
+}
+
+func TestAttachmentWritesVersionParent(t *testing.T) {
+	ctx := context.Background()
+	m := &MemStore{}
+	if err := m.PutPending(ctx, models.Claim{UserID: "u-1", ClaimID: "c-1", Status: models.StatusUploading}); err != nil {
+		t.Fatal(err)
+	}
+	version := func() int64 {
+		t.Helper()
+		c, err := m.GetClaim(ctx, "u-1", "c-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c.Version
+	}
+
+	// Adding an attachment bumps the claim, so a writer holding the old version loses.
+	if err := m.PutPendingAttachment(ctx, models.Attachment{UserID: "u-1", ClaimID: "c-1", AttachmentID: "a-1"}); err != nil {
+		t.Fatal(err)
+	}
+	if v := version(); v != 2 {
+		t.Fatalf("version after attach = %d, want 2", v)
+	}
+	_, err := m.Withdraw(ctx, "u-1", "c-1", 1, NowISO())
+	var ce *ConflictError
+	if !errors.As(err, &ce) || ce.Version != 2 {
+		t.Fatalf("stale withdraw err = %v, want a conflict at version 2", err)
+	}
+
+	// A roll-up that changes attachments_status bumps it too; one that changes nothing does not.
+	if err := m.MarkFailed(ctx, "u-1", AttachmentItemID("c-1", "a-1"), AnyVersion, models.StatusUploading, models.FailureUploadExpired, NowISO()); err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []int64{3, 3} {
+		status, err := m.RollUpAttachments(ctx, "u-1", "c-1")
+		if err != nil || status != models.StatusFailed {
+			t.Fatalf("roll-up %d = %s, %v; want FAILED", i+1, status, err)
+		}
+		if v := version(); v != want {
+			t.Errorf("version after roll-up %d = %d, want %d", i+1, v, want)
+		}
+	}
+	if _, err := m.SetVendors(ctx, "u-1", "c-1", 2, []string{"v-1"}); !errors.Is(err, ErrConflict) {
+		t.Errorf("stale write after roll-up err = %v, want ErrConflict", err)
+	}
+}
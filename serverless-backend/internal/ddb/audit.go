@@ -0,0 +1,273 @@
+package ddb
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/audit"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/oklog/ulid/v2"
+)
+
+// AuditRef returns the audit table partition key for a claim's history.
+func AuditRef(userID, claimID string) string {
+	return userID + "#" + claimID
+}
+
+// fields lists the attributes a write sets, for its audit event's before/after diff.
+// A nil value means the attribute is removed.
+type fields map[string]any
+
+// ifAbsent marks a value written with if_not_exists: it changes nothing when the
+// attribute is already set.
+type ifAbsent struct{ v any }
+
+// condFailed is returned by write when the write's condition fails, including its version
+// check. Item is the item as it was, nil if it does not exist, so callers can tell why.
+type condFailed struct {
+	Item map[string]types.AttributeValue
+}
+
+func (e *condFailed) Error() string { return "conditional check failed" }
+
+// conflict returns the *ConflictError for a failed write, carrying the item's version.
+func (e *condFailed) conflict() error {
+	return &ConflictError{Version: itemVersion(e.Item)}
+}
+
+// itemVersion returns an item's version attribute; 0 if it is missing.
+func itemVersion(item map[string]types.AttributeValue) int64 {
+	var v int64
+	if av, ok := item["version"]; ok {
+		_ = attributevalue.Unmarshal(av, &v)
+	}
+	return v
+}
+
+// write runs w in one transaction with an audit event recording action on itemID, so the
+// change and its history entry land together or not at all. set describes what w writes;
+// the event diffs it against a consistent read taken just before.
+//
+// write also versions the item: w is conditioned on the item still being at version
+// expected (or, for AnyVersion, at the version just read) and bumps it by one. A stale
+// expected version fails like any other condition. It returns the new version.
+func (r *Repo) write(ctx context.Context, userID, itemID, action string, expected int64, w types.TransactWriteItem, set fields) (int64, error) {
+	before, err := r.getItem(ctx, userID, itemID)
+	if err != nil {
+		return 0, err
+	}
+	cur := itemVersion(before)
+	if expected == AnyVersion {
+		expected = cur
+	}
+	if before != nil && cur != expected {
+		return 0, &condFailed{Item: before} // no point sending a write that must fail
+	}
+	next := expected + 1
+	set["version"] = next
+
+	ev, err := r.auditPut(ctx, userID, itemID, action, before, set)
+	if err != nil {
+		return 0, err
+	}
+	versioned(&w, expected)
+	_, err = r.DB.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{w, ev},
+	})
+	var tce *types.TransactionCanceledException
+	if errors.As(err, &tce) && len(tce.CancellationReasons) > 0 &&
+		aws.ToString(tce.CancellationReasons[0].Code) == "ConditionalCheckFailed" {
+		return 0, &condFailed{Item: tce.CancellationReasons[0].Item}
+	}
+	if err != nil {
+		return 0, err
+	}
+	return next, nil
+}
+
+// versioned adds the version check and increment to w, a Put or an Update. Items written
+// before versioning have no version attribute and count as version 0.
+func versioned(w *types.TransactWriteItem, expected int64) {
+	check := "attribute_not_exists(#ver)"
+	values := map[string]types.AttributeValue{}
+	if expected > 0 {
+		check = "#ver = :ver"
+		values[":ver"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(expected, 10)}
+	}
+	names := map[string]string{"#ver": "version"}
+
+	switch {
+	case w.Put != nil:
+		p := w.Put
+		p.Item["version"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(expected+1, 10)}
+		p.ConditionExpression = andCond(p.ConditionExpression, check)
+		p.ExpressionAttributeNames = mergeNames(p.ExpressionAttributeNames, names)
+		if len(values) > 0 { // DynamoDB rejects an empty map
+			p.ExpressionAttributeValues = mergeValues(p.ExpressionAttributeValues, values)
+		}
+		p.ReturnValuesOnConditionCheckFailure = types.ReturnValuesOnConditionCheckFailureAllOld
+	case w.Update != nil:
+		u := w.Update
+		values[":one"] = &types.AttributeValueMemberN{Value: "1"}
+		u.UpdateExpression = awsStr(aws.ToString(u.UpdateExpression) + " ADD #ver :one")
+		u.ConditionExpression = andCond(u.ConditionExpression, check)
+		u.ExpressionAttributeNames = mergeNames(u.ExpressionAttributeNames, names)
+		u.ExpressionAttributeValues = mergeValues(u.ExpressionAttributeValues, values)
+		u.ReturnValuesOnConditionCheckFailure = types.ReturnValuesOnConditionCheckFailureAllOld
+	}
+}
+
+// andCond joins a condition expression (possibly nil) with another clause.
+func andCond(cond *string, clause string) *string {
+	if cond == nil {
+		return awsStr(clause)
+	}
+	return awsStr("(" + *cond + ") AND " + clause)
+}
+
+// mergeNames adds add to m, allocating m if needed.
+func mergeNames(m, add map[string]string) map[string]string {
+	if m == nil {
+		m = make(map[string]string, len(add))
+	}
+	for k, v := range add {
+		m[k] = v
+	}
+	return m
+}
+
+// mergeValues adds add to m, allocating m if needed.
+func mergeValues(m, add map[string]types.AttributeValue) map[string]types.AttributeValue {
+	if m == nil {
+		m = make(map[string]types.AttributeValue, len(add))
+	}
+	for k, v := range add {
+		m[k] = v
+	}
+	return m
+}
+
+// auditPut builds the Put for an audit event about itemID, filed under its claim.
+func (r *Repo) auditPut(ctx context.Context, userID, itemID, action string, before map[string]types.AttributeValue, set fields) (types.TransactWriteItem, error) {
+	ev, err := newEvent(ctx, userID, itemID, action, before, set)
+	if err != nil {
+		return types.TransactWriteItem{}, err
+	}
+	item, err := attributevalue.MarshalMap(ev)
+	if err != nil {
+		return types.TransactWriteItem{}, err
+	}
+	return types.TransactWriteItem{Put: &types.Put{
+		TableName:           aws.String(r.AuditTable),
+		Item:                item,
+		ConditionExpression: awsStr("attribute_not_exists(event_id)"), // events are never overwritten
+	}}, nil
+}
+
+// newEvent builds the audit event for a write of set over before, by the actor in ctx.
+func newEvent(ctx context.Context, userID, itemID, action string, before map[string]types.AttributeValue, set fields) (models.AuditEvent, error) {
+	ch, err := diff(before, set)
+	if err != nil {
+		return models.AuditEvent{}, err
+	}
+	claimID := itemID
+	if c, _, ok := SplitItemID(itemID); ok {
+		claimID = c
+	}
+	actor := audit.From(ctx)
+	return models.AuditEvent{
+		ClaimRef:  AuditRef(userID, claimID),
+		EventID:   ulid.Make().String(),
+		ItemID:    itemID,
+		Action:    action,
+		Actor:     actor.Sub,
+		RequestID: actor.RequestID,
+		At:        NowISO(),
+		Changes:   ch,
+	}, nil
+}
+
+// diff returns the attributes in set whose value differs from before.
+func diff(before map[string]types.AttributeValue, set fields) (map[string]models.Change, error) {
+	out := make(map[string]models.Change)
+	for k, v := range set {
+		var old any
+		if av, ok := before[k]; ok {
+			if err := attributevalue.Unmarshal(av, &old); err != nil {
+				return nil, err
+			}
+		}
+		if ia, ok := v.(ifAbsent); ok {
+			if _, set := before[k]; set {
+				continue
+			}
+			v = ia.v
+		}
+		var nv any
+		if v != nil { // round-trip so numbers, lists and maps compare like the stored side
+			av, err := attributevalue.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			if err := attributevalue.Unmarshal(av, &nv); err != nil {
+				return nil, err
+			}
+		}
+		if !reflect.DeepEqual(old, nv) {
+			out[k] = models.Change{Before: old, After: nv}
+		}
+	}
+	if len(out) == 0 {
+		return nil, nil
+	}
+	return out, nil
+}
+
+// getItem reads an item's raw attributes; nil if it does not exist.
+func (r *Repo) getItem(ctx context.Context, userID, itemID string) (map[string]types.AttributeValue, error) {
+	out, err := r.DB.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.Table),
+		Key: map[string]types.AttributeValue{
+			"user_id":  &types.AttributeValueMemberS{Value: userID},
+			"claim_id": &types.AttributeValueMemberS{Value: itemID},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Item, nil
+}
+
+// ListHistory returns a claim's audit events, including its attachments', oldest first.
+func (r *Repo) ListHistory(ctx context.Context, userID, claimID string) ([]models.AuditEvent, error) {
+	p := dynamodb.NewQueryPaginator(r.DB, &dynamodb.QueryInput{
+		TableName:              aws.String(r.AuditTable),
+		KeyConditionExpression: aws.String("claim_ref = :r"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":r": &types.AttributeValueMemberS{Value: AuditRef(userID, claimID)},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+
+	var events []models.AuditEvent
+	for p.HasMorePages() {
+		out, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var page []models.AuditEvent
+		if err := attributevalue.UnmarshalListOfMaps(out.Items, &page); err != nil {
+			return nil, err
+		}
+		events = append(events, page...)
+	}
+	return events, nil
+}
@@ -0,0 +1,91 @@
+package ddb
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrBadCursor is returned when a pagination cursor is malformed, tampered with,
+// or was issued to a different user.
+var ErrBadCursor = errors.New("invalid cursor")
+
+// errNoCursorSecret is returned when a page needs a cursor but no signing secret is configured.
+var errNoCursorSecret = errors.New("ddb: cursor secret not configured")
+
+// encodeCursor serializes a LastEvaluatedKey into an opaque "<payload>.<mac>" token.
+// The MAC covers the owning userID so a cursor cannot be replayed against another partition.
+func encodeCursor(secret []byte, userID string, lek map[string]types.AttributeValue) (string, error) {
+	if len(lek) == 0 {
+		return "", nil
+	}
+	if len(secret) == 0 {
+		return "", errNoCursorSecret
+	}
+
+	flat := make(map[string]string, len(lek))
+	for k, v := range lek {
+		s, ok := v.(*types.AttributeValueMemberS)
+		if !ok {
+			return "", errors.New("ddb: unsupported key attribute type in cursor: " + k)
+		}
+		flat[k] = s.Value
+	}
+	raw, err := json.Marshal(flat)
+	if err != nil {
+		return "", err
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(raw)
+	mac := base64.RawURLEncoding.EncodeToString(signCursor(secret, userID, payload))
+	return payload + "." + mac, nil
+}
+
+// decodeCursor verifies a token produced by encodeCursor and returns the ExclusiveStartKey.
+// An empty token yields a nil key (first page).
+func decodeCursor(secret []byte, userID, token string) (map[string]types.AttributeValue, error) {
+	if token == "" {
+		return nil, nil
+	}
+	if len(secret) == 0 {
+		return nil, errNoCursorSecret
+	}
+
+	payload, macB64, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrBadCursor
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(macB64)
+	if err != nil || !hmac.Equal(mac, signCursor(secret, userID, payload)) {
+		return nil, ErrBadCursor
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, ErrBadCursor
+	}
+	var flat map[string]string
+	if err := json.Unmarshal(raw, &flat); err != nil || len(flat) == 0 {
+		return nil, ErrBadCursor
+	}
+
+	key := make(map[string]types.AttributeValue, len(flat))
+	for k, v := range flat {
+		key[k] = &types.AttributeValueMemberS{Value: v}
+	}
+	return key, nil
+}
+
+// signCursor computes HMAC-SHA256 over the user ID and encoded payload.
+func signCursor(secret []byte, userID, payload string) []byte {
+	h := hmac.New(sha256.New, secret)
+	h.Write([]byte(userID))
+	h.Write([]byte{0})
+	h.Write([]byte(payload))
+	return h.Sum(nil)
+}
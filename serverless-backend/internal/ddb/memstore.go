@@ -0,0 +1,575 @@
+package ddb
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// MemStore is an in-memory ClaimStore for running handlers without DynamoDB. It stores
+// the same items Repo writes and checks the same conditions, so writes fail, version and
+// audit the same way. It is safe for concurrent use; the zero value is an empty store.
+type MemStore struct {
+	// CursorSecret signs pagination cursors returned by ListByUser, as for Repo.
+	CursorSecret []byte
+
+	mu     sync.Mutex
+	items  map[memKey]map[string]types.AttributeValue
+	events map[string][]models.AuditEvent // by claim_ref, oldest first
+}
+
+// memKey is an item's primary key.
+type memKey struct{ userID, itemID string }
+
+// --- writes ---
+
+// PutPending stores a new claim; see Repo.PutPending.
+func (m *MemStore) PutPending(ctx context.Context, c models.Claim) error {
+	_, err := m.write(ctx, c.UserID, c.ClaimID, models.AuditCreate, AnyVersion, func(item map[string]types.AttributeValue) bool {
+		return item == nil
+	}, pendingFields(c))
+	var cf *condFailed
+	if errors.As(err, &cf) {
+		return cf.conflict()
+	}
+	return err
+}
+
+// UpsertComplete finalizes a claim or attachment; see Repo.UpsertComplete.
+func (m *MemStore) UpsertComplete(ctx context.Context, userID, claimID string, expected int64, s3Key string, size int64, etag, versionID, uploadedAt string) error {
+	set := fields{"status": models.StatusComplete, "uploaded_at": uploadedAt, "size_bytes": size, "etag": etag, "s3_key": s3Key}
+	if versionID != "" {
+		set["version_id"] = versionID
+	}
+	if _, _, isAtt := SplitItemID(claimID); !isAtt {
+		set["review_status"] = ifAbsent{models.ReviewSubmitted}
+	}
+	_, err := m.write(ctx, userID, claimID, models.AuditComplete, expected, func(item map[string]types.AttributeValue) bool {
+		s := attrS(item, "status")
+		return item != nil && s != string(models.StatusWithdrawn) && s != string(models.StatusQuarantined) &&
+			!(s == string(models.StatusComplete) && attrS(item, "etag") == etag)
+	}, set)
+	var cf *condFailed
+	if errors.As(err, &cf) {
+		if attrS(cf.Item, "status") == string(models.StatusComplete) && attrS(cf.Item, "etag") == etag {
+			return nil
+		}
+		return cf.conflict()
+	}
+	return err
+}
+
+// Withdraw marks a claim WITHDRAWN; see Repo.Withdraw.
+func (m *MemStore) Withdraw(ctx context.Context, userID, claimID string, expected int64, at string) (models.Claim, error) {
+	set := fields{"status": models.StatusWithdrawn, "withdrawn_at": ifAbsent{at}}
+	_, err := m.write(ctx, userID, claimID, models.AuditWithdraw, expected, exists, set)
+	var cf *condFailed
+	if errors.As(err, &cf) {
+		if len(cf.Item) == 0 {
+			return models.Claim{}, ErrNotFound
+		}
+		return models.Claim{}, cf.conflict()
+	}
+	if err != nil {
+		return models.Claim{}, err
+	}
+	return m.GetClaim(ctx, userID, claimID)
+}
+
+// MarkFailed moves an item from status from to FAILED; see Repo.MarkFailed.
+func (m *MemStore) MarkFailed(ctx context.Context, userID, claimID string, expected int64, from models.ClaimStatus, reason, at string) error {
+	set := fields{"status": models.StatusFailed, "failure_reason": reason, "failed_at": at}
+	_, err := m.write(ctx, userID, claimID, models.AuditFail, expected, statusIn(from), set)
+	var cf *condFailed
+	if errors.As(err, &cf) {
+		return cf.conflict()
+	}
+	return err
+}
+
+// MarkScanning moves an item to SCANNING; see Repo.MarkScanning.
+func (m *MemStore) MarkScanning(ctx context.Context, userID, itemID string, expected int64) (int64, error) {
+	set := fields{"status": models.StatusScanning}
+	ver, err := m.write(ctx, userID, itemID, models.AuditScan, expected, statusIn(models.StatusUploading, models.StatusScanning), set)
+	var cf *condFailed
+	if errors.As(err, &cf) {
+		return 0, cf.conflict()
+	}
+	return ver, err
+}
+
+// MarkQuarantined flags a SCANNING item as QUARANTINED; see Repo.MarkQuarantined.
+func (m *MemStore) MarkQuarantined(ctx context.Context, userID, itemID string, expected int64, quarantineKey, signature, at string) error {
+	set := fields{"status": models.StatusQuarantined, "quarantine_key": quarantineKey, "scan_signature": signature, "quarantined_at": at}
+	_, err := m.write(ctx, userID, itemID, models.AuditQuarantine, expected, statusIn(models.StatusScanning), set)
+	var cf *condFailed
+	if errors.As(err, &cf) {
+		return cf.conflict()
+	}
+	return err
+}
+
+// PutAnalysis stores an Analysis on a claim; see Repo.PutAnalysis.
+func (m *MemStore) PutAnalysis(ctx context.Context, userID, claimID string, expected int64, an Analysis) (int64, error) {
+	set := fields{"category": an.Category}
+	if !an.Extracted.Empty() {
+		set["extracted"] = an.Extracted
+	}
+	if an.RedactedKey != "" {
+		set["redacted_key"] = an.RedactedKey
+		if len(an.PII) > 0 {
+			set["pii"] = an.PII
+		}
+	}
+	ver, err := m.write(ctx, userID, claimID, models.AuditAnalyze, expected, exists, set)
+	var cf *condFailed
+	if errors.As(err, &cf) {
+		if len(cf.Item) == 0 {
+			return 0, ErrNotFound
+		}
+		return 0, cf.conflict()
+	}
+	return ver, err
+}
+
+// SetReviewStatus moves a claim's review; see Repo.SetReviewStatus.
+func (m *MemStore) SetReviewStatus(ctx context.Context, userID, claimID string, expected int64, to models.ReviewStatus, actor, note, at string) (models.Claim, error) {
+	sources := models.ReviewSources(to)
+	if len(sources) == 0 {
+		return models.Claim{}, &ConflictError{}
+	}
+	set := fields{"review_status": to, "reviewed_by": actor, "reviewed_at": at, "review_note": nil}
+	if note != "" {
+		set["review_note"] = note
+	}
+	_, err := m.write(ctx, userID, claimID, models.AuditReview, expected, func(item map[string]types.AttributeValue) bool {
+		if item == nil || item["parent_id"] != nil || attrS(item, "status") != string(models.StatusComplete) {
+			return false
+		}
+		// COMPLETE claims finalized before review tracking count as SUBMITTED.
+		_, stored := item["review_status"]
+		for _, from := range sources {
+			if (stored && attrS(item, "review_status") == string(from)) || (!stored && from == models.ReviewSubmitted) {
+				return true
+			}
+		}
+		return false
+	}, set)
+	var cf *condFailed
+	if errors.As(err, &cf) {
+		if len(cf.Item) == 0 {
+			return models.Claim{}, ErrNotFound
+		}
+		return models.Claim{}, cf.conflict()
+	}
+	if err != nil {
+		return models.Claim{}, err
+	}
+	return m.GetClaim(ctx, userID, claimID)
+}
+
+// SetVendors replaces a claim's assigned vendors; see Repo.SetVendors.
+func (m *MemStore) SetVendors(ctx context.Context, userID, claimID string, expected int64, vendors []string) (models.Claim, error) {
+	set := fields{"vendors": nil}
+	if len(vendors) > 0 {
+		set["vendors"] = vendors
+	}
+	_, err := m.write(ctx, userID, claimID, models.AuditAssign, expected, func(item map[string]types.AttributeValue) bool {
+		return item != nil && item["parent_id"] == nil
+	}, set)
+	var cf *condFailed
+	if errors.As(err, &cf) {
+		if len(cf.Item) == 0 {
+			return models.Claim{}, ErrNotFound
+		}
+		return models.Claim{}, cf.conflict()
+	}
+	if err != nil {
+		return models.Claim{}, err
+	}
+	return m.GetClaim(ctx, userID, claimID)
+}
+
+// PutPendingAttachment stores a new attachment and bumps its claim's attachment count;
+// see Repo.PutPendingAttachment.
+func (m *MemStore) PutPendingAttachment(ctx context.Context, a models.Attachment) error {
+	a, item, set, err := pendingAttachment(a)
+	if err != nil {
+		return err
+	}
+	ev, err := newEvent(ctx, a.UserID, a.ItemID, models.AuditAttach, nil, set)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+	pk := memKey{a.UserID, a.ClaimID}
+	parent := m.items[pk]
+	count := attrN(parent, "attachment_count")
+	if parent == nil || !models.ClaimStatus(attrS(parent, "status")).AcceptsAttachments() ||
+		count >= models.MaxAttachments || m.items[memKey{a.UserID, a.ItemID}] != nil {
+		return &ConflictError{}
+	}
+
+	// The parent is read and written under one lock, so its version cannot move in between.
+	parent = clone(parent)
+	parent["attachments_status"] = &types.AttributeValueMemberS{Value: string(models.StatusUploading)}
+	parent["attachment_count"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(count+1, 10)}
+	parent["version"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(itemVersion(parent)+1, 10)}
+	m.items[pk] = parent
+	m.items[memKey{a.UserID, a.ItemID}] = item
+	m.events[ev.ClaimRef] = append(m.events[ev.ClaimRef], ev)
+	return nil
+}
+
+// RollUpAttachments recomputes a claim's attachments_status; see Repo.RollUpAttachments.
+// Like Repo, it versions but does not audit the claim, and recomputes if the claim's
+// version moves while the attachments are listed.
+func (m *MemStore) RollUpAttachments(ctx context.Context, userID, claimID string) (models.ClaimStatus, error) {
+	k := memKey{userID, claimID}
+	for attempt := 1; ; attempt++ {
+		parent := m.get(userID, claimID)
+		expected := itemVersion(parent)
+
+		atts, err := m.ListAttachments(ctx, userID, claimID)
+		if err != nil {
+			return "", err
+		}
+		statuses := make([]models.ClaimStatus, 0, len(atts))
+		for _, a := range atts {
+			statuses = append(statuses, a.Status)
+		}
+		status := models.RollUp(statuses)
+		switch {
+		case status == "":
+			return "", nil
+		case parent == nil:
+			return "", ErrNotFound
+		case attrS(parent, "attachments_status") == string(status):
+			return status, nil
+		}
+
+		m.mu.Lock()
+		item := m.items[k]
+		switch {
+		case item == nil:
+			m.mu.Unlock()
+			return "", ErrNotFound
+		case itemVersion(item) != expected:
+			m.mu.Unlock()
+			if attempt < parentAttempts {
+				continue
+			}
+			return "", &ConflictError{Version: itemVersion(item)}
+		}
+		item = clone(item)
+		item["attachments_status"] = &types.AttributeValueMemberS{Value: string(status)}
+		item["version"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(expected+1, 10)}
+		m.items[k] = item
+		m.mu.Unlock()
+		return status, nil
+	}
+}
+
+// write applies set to an item the way Repo.write does: cond stands in for the write's
+// condition expression, the item must be at version expected (or AnyVersion), and an
+// audit event is recorded with the change. It returns the new version.
+func (m *MemStore) write(ctx context.Context, userID, itemID, action string, expected int64, cond func(map[string]types.AttributeValue) bool, set fields) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+
+	k := memKey{userID, itemID}
+	before := m.items[k]
+	_, hasVer := before["version"]
+	cur := itemVersion(before)
+	if expected == AnyVersion {
+		expected = cur
+	}
+	if !cond(before) || (hasVer && cur != expected) || (!hasVer && expected != 0) {
+		return 0, &condFailed{Item: clone(before)}
+	}
+	next := expected + 1
+	set["version"] = next
+
+	ev, err := newEvent(ctx, userID, itemID, action, before, set)
+	if err != nil {
+		return 0, err
+	}
+	after := clone(before)
+	if after == nil {
+		after = make(map[string]types.AttributeValue, len(set))
+	}
+	for name, v := range set {
+		if ia, ok := v.(ifAbsent); ok {
+			if _, present := after[name]; present {
+				continue
+			}
+			v = ia.v
+		}
+		if v == nil {
+			delete(after, name)
+			continue
+		}
+		av, err := attributevalue.Marshal(v)
+		if err != nil {
+			return 0, err
+		}
+		after[name] = av
+	}
+	m.items[k] = after
+	m.events[ev.ClaimRef] = append(m.events[ev.ClaimRef], ev)
+	return next, nil
+}
+
+// --- reads ---
+
+// GetClaim loads a claim (or, by item ID, an attachment in claim shape).
+func (m *MemStore) GetClaim(_ context.Context, userID, claimID string) (models.Claim, error) {
+	item := m.get(userID, claimID)
+	if item == nil {
+		return models.Claim{}, ErrNotFound
+	}
+	var c models.Claim
+	err := attributevalue.UnmarshalMap(item, &c)
+	return c, err
+}
+
+// GetAttachment loads a single attachment.
+func (m *MemStore) GetAttachment(_ context.Context, userID, claimID, attachmentID string) (models.Attachment, error) {
+	item := m.get(userID, AttachmentItemID(claimID, attachmentID))
+	if item == nil {
+		return models.Attachment{}, ErrNotFound
+	}
+	var a models.Attachment
+	err := attributevalue.UnmarshalMap(item, &a)
+	return a, err
+}
+
+// ListAttachments returns a claim's attachments, oldest first.
+func (m *MemStore) ListAttachments(_ context.Context, userID, claimID string) ([]models.Attachment, error) {
+	var atts []models.Attachment
+	for _, item := range m.partition(userID, claimID+attachmentSep) {
+		var a models.Attachment
+		if err := attributevalue.UnmarshalMap(item, &a); err != nil {
+			return nil, err
+		}
+		atts = append(atts, a)
+	}
+	return atts, nil
+}
+
+// ListByUser pages through a user's claims newest first; see Repo.ListByUser. As with
+// DynamoDB, the date window bounds the items read, and the limit counts them before
+// attachments and the other filters are applied, so a page may be short.
+func (m *MemStore) ListByUser(_ context.Context, userID string, opts ListOptions) ([]models.Claim, string, error) {
+	limit := int(opts.Limit)
+	if limit <= 0 {
+		limit = 100
+	}
+	startKey, err := decodeCursor(m.CursorSecret, userID, opts.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	lo, hi, err := opts.idRange()
+	if err != nil {
+		return nil, "", err
+	}
+	after := ""
+	if av, ok := startKey["claim_id"].(*types.AttributeValueMemberS); ok {
+		if !inRange(av.Value, lo, hi) {
+			return nil, "", ErrBadCursor
+		}
+		after = av.Value
+	}
+
+	var page []map[string]types.AttributeValue
+	all := m.partition(userID, "")
+	for i := len(all) - 1; i >= 0; i-- { // ULID sorts by time → newest first
+		id := attrS(all[i], "claim_id")
+		if inRange(id, lo, hi) && (after == "" || id < after) {
+			page = append(page, all[i])
+		}
+	}
+	more := len(page) > limit
+	if more {
+		page = page[:limit]
+	}
+
+	var items []models.Claim
+	for _, item := range page {
+		var c models.Claim
+		if err := attributevalue.UnmarshalMap(item, &c); err != nil {
+			return nil, "", err
+		}
+		if opts.keep(c, item["parent_id"] != nil) {
+			items = append(items, c)
+		}
+	}
+
+	var lek map[string]types.AttributeValue
+	if more {
+		last := page[len(page)-1]
+		lek = map[string]types.AttributeValue{"user_id": last["user_id"], "claim_id": last["claim_id"]}
+	}
+	next, err := encodeCursor(m.CursorSecret, userID, lek)
+	if err != nil {
+		return nil, "", err
+	}
+	return items, next, nil
+}
+
+// ListStaleUploads returns up to limit UPLOADING items created before before, oldest
+// first, carrying only the index keys; see Repo.ListStaleUploads.
+func (m *MemStore) ListStaleUploads(_ context.Context, before string, limit int32) ([]models.Claim, error) {
+	var out []models.Claim
+	for _, item := range m.snapshot() {
+		if attrS(item, "status") == string(models.StatusUploading) && attrS(item, "created_at") < before {
+			out = append(out, models.Claim{
+				UserID:    attrS(item, "user_id"),
+				ClaimID:   attrS(item, "claim_id"),
+				Status:    models.StatusUploading,
+				CreatedAt: attrS(item, "created_at"),
+			})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt < out[j].CreatedAt })
+	if int32(len(out)) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+// ScanRecords calls fn for every claim and attachment item; see Repo.ScanRecords.
+func (m *MemStore) ScanRecords(_ context.Context, fn func(models.Claim) error) error {
+	for _, item := range m.snapshot() {
+		var c models.Claim
+		if err := attributevalue.UnmarshalMap(item, &c); err != nil {
+			return err
+		}
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListHistory returns a claim's audit events, oldest first.
+func (m *MemStore) ListHistory(_ context.Context, userID, claimID string) ([]models.AuditEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	evs := m.events[AuditRef(userID, claimID)]
+	return append([]models.AuditEvent(nil), evs...), nil
+}
+
+// --- helpers ---
+
+// init allocates the maps of a zero MemStore. Callers hold mu.
+func (m *MemStore) init() {
+	if m.items == nil {
+		m.items = make(map[memKey]map[string]types.AttributeValue)
+		m.events = make(map[string][]models.AuditEvent)
+	}
+}
+
+// get returns an item, nil if absent. Items are replaced, never modified, on write, so
+// the returned map is safe to read without the lock.
+func (m *MemStore) get(userID, itemID string) map[string]types.AttributeValue {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.items[memKey{userID, itemID}]
+}
+
+// partition returns a user's items whose item ID starts with prefix, in key order.
+func (m *MemStore) partition(userID, prefix string) []map[string]types.AttributeValue {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var keys []string
+	for k := range m.items {
+		if k.userID == userID && strings.HasPrefix(k.itemID, prefix) {
+			keys = append(keys, k.itemID)
+		}
+	}
+	sort.Strings(keys)
+	out := make([]map[string]types.AttributeValue, len(keys))
+	for i, id := range keys {
+		out[i] = m.items[memKey{userID, id}]
+	}
+	return out
+}
+
+// snapshot returns every item, in key order.
+func (m *MemStore) snapshot() []map[string]types.AttributeValue {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := make([]memKey, 0, len(m.items))
+	for k := range m.items {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].userID != keys[j].userID {
+			return keys[i].userID < keys[j].userID
+		}
+		return keys[i].itemID < keys[j].itemID
+	})
+	out := make([]map[string]types.AttributeValue, len(keys))
+	for i, k := range keys {
+		out[i] = m.items[k]
+	}
+	return out
+}
+
+// exists is the condition attribute_exists(claim_id).
+func exists(item map[string]types.AttributeValue) bool { return item != nil }
+
+// statusIn is the condition attribute_exists(claim_id) AND #s IN (statuses).
+func statusIn(statuses ...models.ClaimStatus) func(map[string]types.AttributeValue) bool {
+	return func(item map[string]types.AttributeValue) bool {
+		s := attrS(item, "status")
+		for _, want := range statuses {
+			if item != nil && s == string(want) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// attrS returns a string attribute, "" if it is missing or not a string.
+func attrS(item map[string]types.AttributeValue, name string) string {
+	if av, ok := item[name].(*types.AttributeValueMemberS); ok {
+		return av.Value
+	}
+	return ""
+}
+
+// attrN returns a number attribute, 0 if it is missing or not a number.
+func attrN(item map[string]types.AttributeValue, name string) int64 {
+	if av, ok := item[name].(*types.AttributeValueMemberN); ok {
+		n, _ := strconv.ParseInt(av.Value, 10, 64)
+		return n
+	}
+	return 0
+}
+
+// clone copies an item's attribute map; nil stays nil.
+func clone(item map[string]types.AttributeValue) map[string]types.AttributeValue {
+	if item == nil {
+		return nil
+	}
+	out := make(map[string]types.AttributeValue, len(item))
+	for k, v := range item {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,88 @@
+package ddb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/models"
+)
+
+// completeClaim stores a finalized claim for u-1 and returns it (at version 2).
+func completeClaim(t *testing.T, m *MemStore, claimID string) models.Claim {
+	t.Helper()
+	ctx := context.Background()
+	key := "user/u-1/" + claimID + ".txt"
+	if err := m.PutPending(ctx, models.Claim{UserID: "u-1", ClaimID: claimID, S3Key: key, Status: models.StatusUploading}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.UpsertComplete(ctx, "u-1", claimID, 1, key, 10, "etag", "", NowISO()); err != nil {
+		t.Fatal(err)
+	}
+	c, err := m.GetClaim(ctx, "u-1", claimID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestStaleVersionConflicts(t *testing.T) {
+	ctx := context.Background()
+	m := &MemStore{}
+	c := completeClaim(t, m, "c-1")
+	if c.Version != 2 {
+		t.Fatalf("version = %d, want 2", c.Version)
+	}
+
+	// A reviewer holding version 2 wins; a second one still holding 2 is now stale.
+	updated, err := m.SetReviewStatus(ctx, "u-1", "c-1", c.Version, models.ReviewUnderReview, "adj-1", "", NowISO())
+	if err != nil {
+		t.Fatalf("SetReviewStatus at current version: %v", err)
+	}
+	if updated.Version != 3 {
+		t.Fatalf("version after review = %d, want 3", updated.Version)
+	}
+
+	stale := []struct {
+		name  string
+		write func() error
+	}{
+		{"review", func() error {
+			_, err := m.SetReviewStatus(ctx, "u-1", "c-1", c.Version, models.ReviewUnderReview, "adj-2", "", NowISO())
+			return err
+		}},
+		{"vendors", func() error {
+			_, err := m.SetVendors(ctx, "u-1", "c-1", c.Version, []string{"v-1"})
+			return err
+		}},
+		{"withdraw", func() error {
+			_, err := m.Withdraw(ctx, "u-1", "c-1", c.Version, NowISO())
+			return err
+		}},
+	}
+	for _, tc := range stale {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.write()
+			if !errors.Is(err, ErrConflict) {
+				t.Fatalf("err = %v, want ErrConflict", err)
+			}
+			var ce *ConflictError
+			if !errors.As(err, &ce) || ce.Version != 3 {
+				t.Errorf("conflict = %v, want one reporting version 3", err)
+			}
+		})
+	}
+
+	got, err := m.GetClaim(ctx, "u-1", "c-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != 3 || got.ReviewedBy != "adj-1" || len(got.Vendors) != 0 || got.Status != models.StatusComplete {
+		t.Errorf("claim after stale writes = %+v, want it untouched at version 3", got)
+	}
+
+	// AnyVersion is last-writer-wins.
+	if _, err := m.SetVendors(ctx, "u-1", "c-1", AnyVersion, []string{"v-1"}); err != nil {
+		t.Errorf("SetVendors(AnyVersion): %v", err)
+	}
+}
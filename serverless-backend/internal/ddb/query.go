@@ -0,0 +1,143 @@
+package ddb
+
+import (
+	"strings"
+	"time"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/oklog/ulid/v2"
+)
+
+// listProjection is what ListByUser returns of each claim.
+const listProjection = "user_id, claim_id, filename, content_type, #tags, category, #cl, #s, uploaded_at, size_bytes, etag, s3_key, " +
+	"withdrawn_at, created_at, failure_reason, extracted, pii, attachment_count, attachments_status, " +
+	"review_status, review_note, reviewed_at, vendors, #ver"
+
+// idRange returns the claim ID bounds for the From/To window, "" for an open end. Claim IDs
+// are ULIDs whose first 48 bits are the creation time in ms, so a time window is a sort key
+// range: From with zero entropy up to the last ms before To with all-ones entropy.
+func (o ListOptions) idRange() (lo, hi string, err error) {
+	if !o.From.IsZero() {
+		var id ulid.ULID
+		if err := id.SetTime(ulid.Timestamp(o.From)); err != nil {
+			return "", "", err
+		}
+		lo = id.String()
+	}
+	if !o.To.IsZero() {
+		var id ulid.ULID
+		if err := id.SetTime(ulid.Timestamp(o.To.Add(-time.Millisecond))); err != nil {
+			return "", "", err
+		}
+		if err := id.SetEntropy([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}); err != nil {
+			return "", "", err
+		}
+		hi = id.String()
+	}
+	return lo, hi, nil
+}
+
+// inRange reports whether claimID falls within the bounds from idRange.
+func inRange(claimID, lo, hi string) bool {
+	return (lo == "" || claimID >= lo) && (hi == "" || claimID <= hi)
+}
+
+// keep reports whether an item passes the filters. It is the Go form of the
+// FilterExpression listQuery builds, for MemStore; the two must agree.
+func (o ListOptions) keep(c models.Claim, isAttachment bool) bool {
+	switch {
+	case isAttachment:
+		return false
+	case o.Status != "" && c.Status != o.Status:
+		return false
+	case o.Status == "" && !o.IncludeWithdrawn && c.Status == models.StatusWithdrawn:
+		return false
+	case o.Client != "" && c.Client != o.Client:
+		return false
+	}
+	if o.Tag == "" {
+		return true
+	}
+	for _, t := range c.Tags {
+		if t == o.Tag {
+			return true
+		}
+	}
+	return false
+}
+
+// listQuery builds the ListByUser query: the date window narrows the sort key range, the
+// other filters become a FilterExpression. Filters apply after Limit, so a page may be
+// short (even empty) while next_cursor still advances. A cursor from a query with a
+// different window is rejected with ErrBadCursor.
+func listQuery(table, userID string, o ListOptions, limit int32, startKey map[string]types.AttributeValue) (*dynamodb.QueryInput, error) {
+	lo, hi, err := o.idRange()
+	if err != nil {
+		return nil, err
+	}
+	if sk, ok := startKey["claim_id"].(*types.AttributeValueMemberS); ok && !inRange(sk.Value, lo, hi) {
+		return nil, ErrBadCursor
+	}
+
+	names := map[string]string{
+		"#uid":  "user_id",
+		"#s":    "status",
+		"#ver":  "version",
+		"#tags": "tags",
+		"#cl":   "client",
+	}
+	values := map[string]types.AttributeValue{
+		":u": &types.AttributeValueMemberS{Value: userID},
+	}
+
+	key := "#uid = :u"
+	switch {
+	case lo != "" && hi != "":
+		key += " AND claim_id BETWEEN :lo AND :hi"
+	case lo != "":
+		key += " AND claim_id >= :lo"
+	case hi != "":
+		key += " AND claim_id <= :hi"
+	}
+	if lo != "" {
+		values[":lo"] = &types.AttributeValueMemberS{Value: lo}
+	}
+	if hi != "" {
+		values[":hi"] = &types.AttributeValueMemberS{Value: hi}
+	}
+
+	// Attachment items share the partition; skip them.
+	filter := []string{"attribute_not_exists(parent_id)"}
+	switch {
+	case o.Status != "":
+		filter = append(filter, "#s = :st")
+		values[":st"] = &types.AttributeValueMemberS{Value: string(o.Status)}
+	case !o.IncludeWithdrawn:
+		filter = append(filter, "#s <> :w")
+		values[":w"] = &types.AttributeValueMemberS{Value: string(models.StatusWithdrawn)}
+	}
+	if o.Client != "" {
+		filter = append(filter, "#cl = :cl")
+		values[":cl"] = &types.AttributeValueMemberS{Value: o.Client}
+	}
+	if o.Tag != "" {
+		filter = append(filter, "contains(#tags, :tag)")
+		values[":tag"] = &types.AttributeValueMemberS{Value: o.Tag}
+	}
+
+	return &dynamodb.QueryInput{
+		TableName:                 aws.String(table),
+		KeyConditionExpression:    aws.String(key),
+		FilterExpression:          aws.String(strings.Join(filter, " AND ")),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+		ProjectionExpression:      aws.String(listProjection),
+		ScanIndexForward:          aws.Bool(false), // ULID sorts by time → newest first
+		Limit:                     aws.Int32(limit),
+		ExclusiveStartKey:         startKey,
+	}, nil
+}
@@ -3,6 +3,7 @@ package ddb
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
 	"time"
@@ -16,11 +17,66 @@ import (
 )
 
 // Repo wraps a DynamoDB client and table name for claim operations.
+//
+// Every update takes the item version the caller expects (models.Claim.Version, or
+// AnyVersion) and bumps it; a write against any other version fails with a *ConflictError.
 type Repo struct {
 	DB    *dynamodb.Client
 	Table string
+
+	// AuditTable receives an append-only event for every write (see audit.go).
+	AuditTable string
+
+	// CursorSecret signs pagination cursors returned by ListByUser.
+	CursorSecret []byte
+}
+
+// ListOptions controls paging and filtering for ListByUser.
+type ListOptions struct {
+	Limit            int32  // page size; <= 0 means 100
+	Cursor           string // opaque token from a previous page; empty for the first page
+	IncludeWithdrawn bool   // include WITHDRAWN claims (hidden by default)
+
+	// Filters; zero values match everything. See listQuery.
+	Status models.ClaimStatus // only claims in this status (WITHDRAWN included if asked for)
+	Tag    string             // only claims carrying this tag
+	Client string             // only claims for this client (exact match)
+	From   time.Time          // only claims created at or after From
+	To     time.Time          // only claims created before To
+}
+
+// ErrNotFound is returned when a claim does not exist for the given user.
+var ErrNotFound = errors.New("claim not found")
+
+// ErrConflict is returned when a conditional write loses to a concurrent state change.
+// The error returned is a *ConflictError; test for it with errors.Is.
+var ErrConflict = errors.New("claim state changed")
+
+// ErrRetryable is returned when DynamoDB cancels a transaction for reasons other than a
+// failed condition (throttling, a conflicting transaction in flight). Nothing was written;
+// the same request may simply be sent again.
+var ErrRetryable = errors.New("transaction cancelled; retry")
+
+// ConflictError reports a write refused because the item was not in the expected state:
+// another write got there first, or the caller's expected version is stale.
+type ConflictError struct {
+	Version int64 // the item's current version; 0 if it does not exist or predates versioning
 }
 
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%v (now at version %d)", ErrConflict, e.Version)
+}
+
+// Is makes errors.Is(err, ErrConflict) match.
+func (e *ConflictError) Is(target error) bool { return target == ErrConflict }
+
+// AnyVersion tells an update to skip the caller's version check. The write is still
+// conditioned on the version it reads just before writing, so nothing in between is lost.
+const AnyVersion int64 = -1
+
+// StatusCreatedIndex is the GSI keyed by (status, created_at), used to find stale uploads.
+const StatusCreatedIndex = "status-created_at-index"
+
 // awsStr is a helper to get a pointer to a string literal.
 func awsStr(s string) *string { return &s }
 
@@ -35,8 +91,29 @@ func MakeKeys(sub, claimID string) (pk, sk string) {
 // PutPending writes a new record with (user_id, claim_id). The condition only guards claim_id,
 // so you can have multiple claims per user.
 func (r *Repo) PutPending(ctx context.Context, c models.Claim) error {
+	set := pendingFields(c)
+	item, err := attributevalue.MarshalMap(map[string]any(set))
+	if err != nil {
+		return err
+	}
+
+	w := types.TransactWriteItem{Put: &types.Put{
+		TableName:           aws.String(r.Table),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(claim_id)"),
+	}}
+	_, err = r.write(ctx, c.UserID, c.ClaimID, models.AuditCreate, AnyVersion, w, set)
+	var cf *condFailed
+	if errors.As(err, &cf) {
+		return cf.conflict()
+	}
+	return err
+}
+
+// pendingFields lays out a new claim item for PutPending.
+func pendingFields(c models.Claim) fields {
 	// Store with explicit attribute names that match the table schema.
-	itemMap := map[string]any{
+	itemMap := fields{
 		"user_id":     c.UserID,
 		"claim_id":    c.ClaimID,
 		"filename":    c.Filename,
@@ -45,79 +122,386 @@ func (r *Repo) PutPending(ctx context.Context, c models.Claim) error {
 		"client":      c.Client,
 		"status":      c.Status,
 		"uploaded_at": time.Now().UTC().Format(time.RFC3339Nano), // optional
+		"created_at":  NowISO(),                                  // range key of StatusCreatedIndex
 	}
-	item, err := attributevalue.MarshalMap(itemMap)
-	if err != nil {
-		return err
+	if c.ContentType != "" {
+		itemMap["content_type"] = c.ContentType
 	}
-
-	_, err = r.DB.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName: aws.String(r.Table),
-		Item:      item,
-
-		ConditionExpression: aws.String("attribute_not_exists(claim_id)"),
-	})
-	return err
+	if c.UploadID != "" {
+		itemMap["upload_id"] = c.UploadID
+		itemMap["part_count"] = c.PartCount
+	}
+	return itemMap
 }
 
 // UpsertComplete updates an existing claim record to status COMPLETE with upload details.
+// It is idempotent per object: finalizing an item already COMPLETE with the same ETag is a
+// no-op that returns nil. versionID is recorded when the bucket is versioned (else "").
+// Returns ErrConflict if the item is withdrawn, quarantined, missing, or no longer at
+// version expected (a late event for a record that has since moved on).
 func (r *Repo) UpsertComplete(
 	ctx context.Context,
-	userID, claimID, s3Key string,
+	userID, claimID string,
+	expected int64,
+	s3Key string,
 	size int64,
-	etag, uploadedAt string,
+	etag, versionID, uploadedAt string,
 ) error {
-	_, err := r.DB.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+	update := "SET #s = :s, uploaded_at = :u, size_bytes = :b, etag = :e, s3_key = :k"
+	values := map[string]types.AttributeValue{
+		":s": &types.AttributeValueMemberS{Value: string(models.StatusComplete)},
+		":u": &types.AttributeValueMemberS{Value: uploadedAt},
+		":b": &types.AttributeValueMemberN{Value: strconv.FormatInt(size, 10)},
+		":e": &types.AttributeValueMemberS{Value: etag},
+		":k": &types.AttributeValueMemberS{Value: s3Key},
+		":w": &types.AttributeValueMemberS{Value: string(models.StatusWithdrawn)},
+		":q": &types.AttributeValueMemberS{Value: string(models.StatusQuarantined)},
+	}
+	set := fields{"status": models.StatusComplete, "uploaded_at": uploadedAt, "size_bytes": size, "etag": etag, "s3_key": s3Key}
+	if versionID != "" {
+		update += ", version_id = :v"
+		values[":v"] = &types.AttributeValueMemberS{Value: versionID}
+		set["version_id"] = versionID
+	}
+	if _, _, isAtt := SplitItemID(claimID); !isAtt {
+		// A finalized claim enters review; refinalizing never resets a review in progress.
+		update += ", review_status = if_not_exists(review_status, :rv)"
+		values[":rv"] = &types.AttributeValueMemberS{Value: string(models.ReviewSubmitted)}
+		set["review_status"] = ifAbsent{models.ReviewSubmitted}
+	}
+
+	_, err := r.write(ctx, userID, claimID, models.AuditComplete, expected, types.TransactWriteItem{Update: &types.Update{
 		TableName: &r.Table,
 		Key: map[string]types.AttributeValue{
 			"user_id":  &types.AttributeValueMemberS{Value: userID},
 			"claim_id": &types.AttributeValueMemberS{Value: claimID},
 		},
-		UpdateExpression: awsStr("SET #s = :s, uploaded_at = :u, size_bytes = :b, etag = :e, s3_key = :k"),
+		UpdateExpression: awsStr(update),
+		ExpressionAttributeNames: map[string]string{
+			"#s": "status",
+		},
+		ExpressionAttributeValues: values,
+		// A claim withdrawn before its upload landed must stay withdrawn, a quarantined
+		// object is never released, and a redelivered event must not rewrite the record.
+		ConditionExpression: awsStr("attribute_exists(user_id) AND attribute_exists(claim_id) AND #s <> :w AND #s <> :q " +
+			"AND NOT (#s = :s AND etag = :e)"),
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	}}, set)
+	var cf *condFailed
+	if errors.As(err, &cf) {
+		var old models.Claim
+		if uerr := attributevalue.UnmarshalMap(cf.Item, &old); uerr == nil &&
+			old.Status == models.StatusComplete && old.ETag == etag {
+			return nil
+		}
+		return cf.conflict()
+	}
+	return err
+}
+
+// GetClaim loads a single claim by (user_id, claim_id). Returns ErrNotFound if absent.
+func (r *Repo) GetClaim(ctx context.Context, userID, claimID string) (models.Claim, error) {
+	out, err := r.DB.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.Table),
+		Key: map[string]types.AttributeValue{
+			"user_id":  &types.AttributeValueMemberS{Value: userID},
+			"claim_id": &types.AttributeValueMemberS{Value: claimID},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return models.Claim{}, err
+	}
+	if len(out.Item) == 0 {
+		return models.Claim{}, ErrNotFound
+	}
+
+	var c models.Claim
+	if err := attributevalue.UnmarshalMap(out.Item, &c); err != nil {
+		return models.Claim{}, err
+	}
+	return c, nil
+}
+
+// Withdraw soft-deletes a claim by setting status WITHDRAWN. It is idempotent:
+// withdrawing an already-withdrawn claim succeeds and keeps the original withdrawn_at.
+// Returns the updated claim, ErrNotFound if the claim does not exist, or ErrConflict if
+// it is not at version expected.
+func (r *Repo) Withdraw(ctx context.Context, userID, claimID string, expected int64, at string) (models.Claim, error) {
+	set := fields{"status": models.StatusWithdrawn, "withdrawn_at": ifAbsent{at}}
+	_, err := r.write(ctx, userID, claimID, models.AuditWithdraw, expected, types.TransactWriteItem{Update: &types.Update{
+		TableName: &r.Table,
+		Key: map[string]types.AttributeValue{
+			"user_id":  &types.AttributeValueMemberS{Value: userID},
+			"claim_id": &types.AttributeValueMemberS{Value: claimID},
+		},
+		UpdateExpression: awsStr("SET #s = :w, withdrawn_at = if_not_exists(withdrawn_at, :t)"),
 		ExpressionAttributeNames: map[string]string{
 			"#s": "status",
 		},
 		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":s": &types.AttributeValueMemberS{Value: string(models.StatusComplete)},
-			":u": &types.AttributeValueMemberS{Value: uploadedAt},
-			":b": &types.AttributeValueMemberN{Value: strconv.FormatInt(size, 10)},
-			":e": &types.AttributeValueMemberS{Value: etag},
-			":k": &types.AttributeValueMemberS{Value: s3Key},
+			":w": &types.AttributeValueMemberS{Value: string(models.StatusWithdrawn)},
+			":t": &types.AttributeValueMemberS{Value: at},
 		},
 		ConditionExpression: awsStr("attribute_exists(user_id) AND attribute_exists(claim_id)"),
+	}}, set)
+	var cf *condFailed
+	if errors.As(err, &cf) {
+		if len(cf.Item) == 0 {
+			return models.Claim{}, ErrNotFound
+		}
+		return models.Claim{}, cf.conflict()
+	}
+	if err != nil {
+		return models.Claim{}, err
+	}
+	return r.GetClaim(ctx, userID, claimID)
+}
+
+// ListStaleUploads returns up to limit UPLOADING claims created before the given
+// ISO8601 timestamp, oldest first. Items carry only the index keys (user_id, claim_id,
+// status, created_at).
+func (r *Repo) ListStaleUploads(ctx context.Context, before string, limit int32) ([]models.Claim, error) {
+	p := dynamodb.NewQueryPaginator(r.DB, &dynamodb.QueryInput{
+		TableName:              aws.String(r.Table),
+		IndexName:              aws.String(StatusCreatedIndex),
+		KeyConditionExpression: aws.String("#s = :s AND created_at < :t"),
+		ExpressionAttributeNames: map[string]string{
+			"#s": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":s": &types.AttributeValueMemberS{Value: string(models.StatusUploading)},
+			":t": &types.AttributeValueMemberS{Value: before},
+		},
+		Limit: aws.Int32(limit),
 	})
+
+	var items []models.Claim
+	for p.HasMorePages() && int32(len(items)) < limit {
+		out, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var page []models.Claim
+		if err := attributevalue.UnmarshalListOfMaps(out.Items, &page); err != nil {
+			return nil, err
+		}
+		items = append(items, page...)
+	}
+	if int32(len(items)) > limit {
+		items = items[:limit]
+	}
+	return items, nil
+}
+
+// ScanRecords calls fn for every claim and attachment item in the table, page by page.
+// Items carry only their keys, status, s3_key, etag, created_at and version. It stops at the
+// first error fn returns.
+func (r *Repo) ScanRecords(ctx context.Context, fn func(models.Claim) error) error {
+	p := dynamodb.NewScanPaginator(r.DB, &dynamodb.ScanInput{
+		TableName:            aws.String(r.Table),
+		ProjectionExpression: aws.String("user_id, claim_id, #s, s3_key, etag, created_at, #ver"),
+		ExpressionAttributeNames: map[string]string{
+			"#s":   "status",
+			"#ver": "version",
+		},
+	})
+	for p.HasMorePages() {
+		out, err := p.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		var page []models.Claim
+		if err := attributevalue.UnmarshalListOfMaps(out.Items, &page); err != nil {
+			return err
+		}
+		for _, c := range page {
+			if err := fn(c); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// MarkFailed moves a claim from status from to FAILED with a machine-readable reason.
+// Returns ErrConflict if the claim is no longer in status from or at version expected.
+func (r *Repo) MarkFailed(ctx context.Context, userID, claimID string, expected int64, from models.ClaimStatus, reason, at string) error {
+	set := fields{"status": models.StatusFailed, "failure_reason": reason, "failed_at": at}
+	_, err := r.write(ctx, userID, claimID, models.AuditFail, expected, types.TransactWriteItem{Update: &types.Update{
+		TableName: &r.Table,
+		Key: map[string]types.AttributeValue{
+			"user_id":  &types.AttributeValueMemberS{Value: userID},
+			"claim_id": &types.AttributeValueMemberS{Value: claimID},
+		},
+		UpdateExpression: awsStr("SET #s = :f, failure_reason = :r, failed_at = :t"),
+		ExpressionAttributeNames: map[string]string{
+			"#s": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":f":  &types.AttributeValueMemberS{Value: string(models.StatusFailed)},
+			":r":  &types.AttributeValueMemberS{Value: reason},
+			":t":  &types.AttributeValueMemberS{Value: at},
+			":ex": &types.AttributeValueMemberS{Value: string(from)},
+		},
+		ConditionExpression: awsStr("attribute_exists(claim_id) AND #s = :ex"),
+	}}, set)
+	var cf *condFailed
+	if errors.As(err, &cf) {
+		return cf.conflict()
+	}
 	return err
 }
 
-// ListByUser queries the table by user_id (PK) and returns newest-first by ULID claim_id.
-func (r *Repo) ListByUser(ctx context.Context, userID string, limit int32) ([]models.Claim, error) {
-	if limit <= 0 {
-		limit = 100
+// Analysis is what the indexer derives from a text claim letter.
+type Analysis struct {
+	Extracted   models.Extracted
+	Category    string
+	PII         map[string]int // kind -> count; nil when none was found
+	RedactedKey string         // empty when no redacted copy was written
+}
+
+// PutAnalysis stores an Analysis on the claim item. Empty parts are not written.
+// It returns the claim's new version.
+func (r *Repo) PutAnalysis(ctx context.Context, userID, claimID string, expected int64, an Analysis) (int64, error) {
+	update := "SET category = :c"
+	values := map[string]types.AttributeValue{
+		":c": &types.AttributeValueMemberS{Value: an.Category},
 	}
+	set := fields{"category": an.Category}
+	if !an.Extracted.Empty() {
+		av, err := attributevalue.Marshal(an.Extracted)
+		if err != nil {
+			return 0, err
+		}
+		update += ", extracted = :x"
+		values[":x"] = av
+		set["extracted"] = an.Extracted
+	}
+	if an.RedactedKey != "" {
+		update += ", redacted_key = :rk"
+		values[":rk"] = &types.AttributeValueMemberS{Value: an.RedactedKey}
+		set["redacted_key"] = an.RedactedKey
+		if len(an.PII) > 0 {
+			av, err := attributevalue.Marshal(an.PII)
+			if err != nil {
+				return 0, err
+			}
+			update += ", pii = :p"
+			values[":p"] = av
+			set["pii"] = an.PII
+		}
+	}
+	ver, err := r.write(ctx, userID, claimID, models.AuditAnalyze, expected, types.TransactWriteItem{Update: &types.Update{
+		TableName: &r.Table,
+		Key: map[string]types.AttributeValue{
+			"user_id":  &types.AttributeValueMemberS{Value: userID},
+			"claim_id": &types.AttributeValueMemberS{Value: claimID},
+		},
+		UpdateExpression:          awsStr(update),
+		ExpressionAttributeValues: values,
+		ConditionExpression:       awsStr("attribute_exists(claim_id)"),
+	}}, set)
+	var cf *condFailed
+	if errors.As(err, &cf) {
+		if len(cf.Item) == 0 {
+			return 0, ErrNotFound
+		}
+		return 0, cf.conflict()
+	}
+	return ver, err
+}
 
-	pe := "user_id, claim_id, filename, tags, client, #s, uploaded_at, size_bytes, etag, s3_key"
+// MarkScanning moves a claim or attachment to SCANNING once its content has been verified.
+// A record already SCANNING is accepted so a retried event can rescan it. Returns
+// ErrConflict if the record has moved on (finalized, withdrawn, reaped, or past version
+// expected). It returns the record's new version.
+func (r *Repo) MarkScanning(ctx context.Context, userID, itemID string, expected int64) (int64, error) {
+	set := fields{"status": models.StatusScanning}
+	ver, err := r.write(ctx, userID, itemID, models.AuditScan, expected, types.TransactWriteItem{Update: &types.Update{
+		TableName: &r.Table,
+		Key: map[string]types.AttributeValue{
+			"user_id":  &types.AttributeValueMemberS{Value: userID},
+			"claim_id": &types.AttributeValueMemberS{Value: itemID},
+		},
+		UpdateExpression: awsStr("SET #s = :sc"),
+		ExpressionAttributeNames: map[string]string{
+			"#s": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":sc": &types.AttributeValueMemberS{Value: string(models.StatusScanning)},
+			":u":  &types.AttributeValueMemberS{Value: string(models.StatusUploading)},
+		},
+		ConditionExpression: awsStr("attribute_exists(claim_id) AND #s IN (:u, :sc)"),
+	}}, set)
+	var cf *condFailed
+	if errors.As(err, &cf) {
+		return 0, cf.conflict()
+	}
+	return ver, err
+}
 
-	out, err := r.DB.Query(ctx, &dynamodb.QueryInput{
-		TableName:              aws.String(r.Table),
-		KeyConditionExpression: aws.String("#uid = :u"),
+// MarkQuarantined flags a SCANNING record whose object the scanner matched, recording
+// where the object was moved and the signature it matched. Returns ErrConflict if the
+// record is no longer SCANNING or at version expected.
+func (r *Repo) MarkQuarantined(ctx context.Context, userID, itemID string, expected int64, quarantineKey, signature, at string) error {
+	set := fields{"status": models.StatusQuarantined, "quarantine_key": quarantineKey, "scan_signature": signature, "quarantined_at": at}
+	_, err := r.write(ctx, userID, itemID, models.AuditQuarantine, expected, types.TransactWriteItem{Update: &types.Update{
+		TableName: &r.Table,
+		Key: map[string]types.AttributeValue{
+			"user_id":  &types.AttributeValueMemberS{Value: userID},
+			"claim_id": &types.AttributeValueMemberS{Value: itemID},
+		},
+		UpdateExpression: awsStr("SET #s = :q, quarantine_key = :k, scan_signature = :sig, quarantined_at = :t"),
 		ExpressionAttributeNames: map[string]string{
-			"#uid": "user_id",
-			"#s":   "status",
+			"#s": "status",
 		},
 		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":u": &types.AttributeValueMemberS{Value: userID},
+			":q":   &types.AttributeValueMemberS{Value: string(models.StatusQuarantined)},
+			":k":   &types.AttributeValueMemberS{Value: quarantineKey},
+			":sig": &types.AttributeValueMemberS{Value: signature},
+			":t":   &types.AttributeValueMemberS{Value: at},
+			":sc":  &types.AttributeValueMemberS{Value: string(models.StatusScanning)},
 		},
-		ProjectionExpression: aws.String(pe),
-		ScanIndexForward:     aws.Bool(false), // ULID sorts by time → newest first
-		Limit:                aws.Int32(limit),
-	})
+		ConditionExpression: awsStr("attribute_exists(claim_id) AND #s = :sc"),
+	}}, set)
+	var cf *condFailed
+	if errors.As(err, &cf) {
+		return cf.conflict()
+	}
+	return err
+}
+
+// ListByUser queries the table by user_id (PK) and returns newest-first by ULID claim_id.
+// The second return value is an opaque cursor for the next page, or "" when there are no more items.
+func (r *Repo) ListByUser(ctx context.Context, userID string, opts ListOptions) ([]models.Claim, string, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+
+	startKey, err := decodeCursor(r.CursorSecret, userID, opts.Cursor)
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+
+	in, err := listQuery(r.Table, userID, opts, limit, startKey)
+	if err != nil {
+		return nil, "", err
+	}
+	out, err := r.DB.Query(ctx, in)
+	if err != nil {
+		return nil, "", err
 	}
 
 	var items []models.Claim
 	if err := attributevalue.UnmarshalListOfMaps(out.Items, &items); err != nil {
-		return nil, err
+		return nil, "", err
 	}
-	return items, nil
+
+	next, err := encodeCursor(r.CursorSecret, userID, out.LastEvaluatedKey)
+	if err != nil {
+		return nil, "", err
+	}
+	return items, next, nil
 }
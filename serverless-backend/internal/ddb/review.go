@@ -0,0 +1,124 @@
+package ddb
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// SetReviewStatus moves a claim's review to status to, recording who did it and an optional
+// note (an empty note clears the previous one). The state machine is enforced by the write's
+// condition: the claim's upload must be COMPLETE and its current review status one of
+// models.ReviewSources(to), so two reviewers racing cannot skip or repeat a step.
+// Returns the updated claim, ErrNotFound if there is no such claim, or ErrConflict if the
+// transition is not allowed from the claim's current state or the claim is not at version
+// expected.
+func (r *Repo) SetReviewStatus(ctx context.Context, userID, claimID string, expected int64, to models.ReviewStatus, actor, note, at string) (models.Claim, error) {
+	sources := models.ReviewSources(to)
+	if len(sources) == 0 {
+		return models.Claim{}, &ConflictError{}
+	}
+
+	values := map[string]types.AttributeValue{
+		":to": &types.AttributeValueMemberS{Value: string(to)},
+		":by": &types.AttributeValueMemberS{Value: actor},
+		":at": &types.AttributeValueMemberS{Value: at},
+		":c":  &types.AttributeValueMemberS{Value: string(models.StatusComplete)},
+	}
+	var in []string
+	legacy := false // COMPLETE claims finalized before review tracking count as SUBMITTED
+	for i, s := range sources {
+		k := ":f" + strconv.Itoa(i)
+		in = append(in, k)
+		values[k] = &types.AttributeValueMemberS{Value: string(s)}
+		legacy = legacy || s == models.ReviewSubmitted
+	}
+	from := "review_status IN (" + strings.Join(in, ", ") + ")"
+	if legacy {
+		from = "(" + from + " OR attribute_not_exists(review_status))"
+	}
+
+	update := "SET review_status = :to, reviewed_by = :by, reviewed_at = :at"
+	set := fields{"review_status": to, "reviewed_by": actor, "reviewed_at": at, "review_note": nil}
+	if note != "" {
+		update += ", review_note = :n"
+		values[":n"] = &types.AttributeValueMemberS{Value: note}
+		set["review_note"] = note
+	} else {
+		update += " REMOVE review_note"
+	}
+
+	_, err := r.write(ctx, userID, claimID, models.AuditReview, expected, types.TransactWriteItem{Update: &types.Update{
+		TableName: &r.Table,
+		Key: map[string]types.AttributeValue{
+			"user_id":  &types.AttributeValueMemberS{Value: userID},
+			"claim_id": &types.AttributeValueMemberS{Value: claimID},
+		},
+		UpdateExpression: awsStr(update),
+		ExpressionAttributeNames: map[string]string{
+			"#s": "status",
+		},
+		ExpressionAttributeValues: values,
+		ConditionExpression: awsStr("attribute_exists(claim_id) AND attribute_not_exists(parent_id) AND #s = :c AND " +
+			from),
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	}}, set)
+	var cf *condFailed
+	if errors.As(err, &cf) {
+		if len(cf.Item) == 0 {
+			return models.Claim{}, ErrNotFound
+		}
+		return models.Claim{}, cf.conflict()
+	}
+	if err != nil {
+		return models.Claim{}, err
+	}
+	return r.GetClaim(ctx, userID, claimID)
+}
+
+// SetVendors replaces the vendors assigned to a claim (an empty list unassigns them all).
+// Returns the updated claim, ErrNotFound if there is no such claim, or ErrConflict if the
+// claim is not at version expected.
+func (r *Repo) SetVendors(ctx context.Context, userID, claimID string, expected int64, vendors []string) (models.Claim, error) {
+	update := "REMOVE vendors"
+	values := map[string]types.AttributeValue(nil)
+	set := fields{"vendors": nil}
+	if len(vendors) > 0 {
+		av, err := attributevalue.Marshal(vendors)
+		if err != nil {
+			return models.Claim{}, err
+		}
+		update = "SET vendors = :v"
+		values = map[string]types.AttributeValue{":v": av}
+		set["vendors"] = vendors
+	}
+
+	_, err := r.write(ctx, userID, claimID, models.AuditAssign, expected, types.TransactWriteItem{Update: &types.Update{
+		TableName: &r.Table,
+		Key: map[string]types.AttributeValue{
+			"user_id":  &types.AttributeValueMemberS{Value: userID},
+			"claim_id": &types.AttributeValueMemberS{Value: claimID},
+		},
+		UpdateExpression:                    awsStr(update),
+		ExpressionAttributeValues:           values,
+		ConditionExpression:                 awsStr("attribute_exists(claim_id) AND attribute_not_exists(parent_id)"),
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	}}, set)
+	var cf *condFailed
+	if errors.As(err, &cf) {
+		if len(cf.Item) == 0 {
+			return models.Claim{}, ErrNotFound
+		}
+		return models.Claim{}, cf.conflict()
+	}
+	if err != nil {
+		return models.Claim{}, err
+	}
+	return r.GetClaim(ctx, userID, claimID)
+}
@@ -0,0 +1,43 @@
+package ddb
+
+import (
+	"context"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/models"
+)
+
+// ClaimStore is everything the handlers and the indexer do with claim records. Repo is the
+// DynamoDB implementation; MemStore keeps the same semantics (conditions, versions, audit
+// events, errors) in memory for running handlers without DynamoDB.
+type ClaimStore interface {
+	// Claims
+	PutPending(ctx context.Context, c models.Claim) error
+	GetClaim(ctx context.Context, userID, claimID string) (models.Claim, error)
+	ListByUser(ctx context.Context, userID string, opts ListOptions) ([]models.Claim, string, error)
+	UpsertComplete(ctx context.Context, userID, claimID string, expected int64, s3Key string, size int64, etag, versionID, uploadedAt string) error
+	Withdraw(ctx context.Context, userID, claimID string, expected int64, at string) (models.Claim, error)
+	MarkFailed(ctx context.Context, userID, claimID string, expected int64, from models.ClaimStatus, reason, at string) error
+	MarkScanning(ctx context.Context, userID, itemID string, expected int64) (int64, error)
+	MarkQuarantined(ctx context.Context, userID, itemID string, expected int64, quarantineKey, signature, at string) error
+	PutAnalysis(ctx context.Context, userID, claimID string, expected int64, an Analysis) (int64, error)
+	SetReviewStatus(ctx context.Context, userID, claimID string, expected int64, to models.ReviewStatus, actor, note, at string) (models.Claim, error)
+	SetVendors(ctx context.Context, userID, claimID string, expected int64, vendors []string) (models.Claim, error)
+
+	// Attachments
+	PutPendingAttachment(ctx context.Context, a models.Attachment) error
+	GetAttachment(ctx context.Context, userID, claimID, attachmentID string) (models.Attachment, error)
+	ListAttachments(ctx context.Context, userID, claimID string) ([]models.Attachment, error)
+	RollUpAttachments(ctx context.Context, userID, claimID string) (models.ClaimStatus, error)
+
+	// Maintenance
+	ListStaleUploads(ctx context.Context, before string, limit int32) ([]models.Claim, error)
+	ScanRecords(ctx context.Context, fn func(models.Claim) error) error
+
+	// Audit
+	ListHistory(ctx context.Context, userID, claimID string) ([]models.AuditEvent, error)
+}
+
+var (
+	_ ClaimStore = (*Repo)(nil)
+	_ ClaimStore = (*MemStore)(nil)
+)
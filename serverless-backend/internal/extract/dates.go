@@ -0,0 +1,75 @@
+package extract
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/models"
+)
+
+const isoDate = "2006-01-02"
+
+var (
+	letterDateRx = regexp.MustCompile(`(?im)^[ \t]*date[ \t]*:[ \t]*(.+)$`)
+
+	// monthDayRx matches "December 5th", "April 10, 2025" and "Feb 1st, 2025".
+	monthDayRx = regexp.MustCompile(`(?i)\b(january|february|march|april|may|june|july|august|september|october|november|december|jan|feb|mar|apr|jun|jul|aug|sept|sep|oct|nov|dec)\.?\s+(\d{1,2})(?:st|nd|rd|th)?\b(?:,?\s+(\d{4}))?`)
+
+	// headerLayouts are the formats accepted on a "Date:" line.
+	headerLayouts = []string{"January 2, 2006", "Jan 2, 2006", "2 January 2006", isoDate, "01/02/2006", "1/2/2006"}
+
+	months = map[string]time.Month{
+		"jan": time.January, "feb": time.February, "mar": time.March, "apr": time.April,
+		"may": time.May, "jun": time.June, "jul": time.July, "aug": time.August,
+		"sep": time.September, "oct": time.October, "nov": time.November, "dec": time.December,
+	}
+)
+
+// dates returns the letter date from the "Date:" header and the incident date, which is
+// the first month-day date in the letter body. An incident date without a year takes
+// the letter's year (the previous one if that would put it after the letter).
+func dates(text string) (letter, incident *models.ExtractedField) {
+	var letterDate time.Time
+	if m := letterDateRx.FindStringSubmatch(text); m != nil {
+		raw := strings.TrimSpace(m[1])
+		for _, layout := range headerLayouts {
+			if t, err := time.Parse(layout, raw); err == nil {
+				letterDate = t
+				letter = field(t.Format(isoDate), confLabeled)
+				break
+			}
+		}
+	}
+
+	for _, m := range monthDayRx.FindAllStringSubmatch(bodyOf(text), -1) {
+		mon := months[strings.ToLower(m[1][:3])]
+		day, _ := strconv.Atoi(m[2])
+		if m[3] != "" {
+			year, _ := strconv.Atoi(m[3])
+			if t, ok := makeDate(year, mon, day); ok {
+				return letter, field(t.Format(isoDate), confPattern)
+			}
+			continue
+		}
+		if letterDate.IsZero() {
+			continue // no year to anchor it to
+		}
+		t, ok := makeDate(letterDate.Year(), mon, day)
+		if !ok {
+			continue
+		}
+		if t.After(letterDate) {
+			t = t.AddDate(-1, 0, 0)
+		}
+		return letter, field(t.Format(isoDate), confInferred)
+	}
+	return letter, nil
+}
+
+// makeDate builds a date, rejecting days that do not exist in the month.
+func makeDate(year int, mon time.Month, day int) (time.Time, bool) {
+	t := time.Date(year, mon, day, 0, 0, 0, 0, time.UTC)
+	return t, t.Day() == day && t.Month() == mon
+}
@@ -0,0 +1,180 @@
+// Package extract parses structured fields out of plain-text claim letters.
+// Parsing is rule-based and offline; every field carries a confidence score so
+// consumers can decide how far to trust it.
+package extract
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/models"
+)
+
+// MaxBytes is how much of a document callers should parse; claim letters are far shorter.
+const MaxBytes = 256 << 10
+
+// Confidence scores for the rules below, highest for labeled values.
+const (
+	confLabeled  = 0.95 // value follows an explicit label ("Policy #:", "Date:", "Email:")
+	confPattern  = 0.9  // unlabeled but unambiguous pattern (email, phone, dollar amount)
+	confPlace    = 0.85 // "in <City>, <ST>"
+	confSubject  = 0.8  // free-form text lifted from the subject line
+	confInferred = 0.7  // missing parts filled in (e.g. year taken from the letter date)
+	confHedged   = 0.6  // qualified in the text ("approximately $5,200")
+	confWeak     = 0.5  // loose heuristic match
+	confGuess    = 0.4  // weakest fallback
+)
+
+var (
+	policyLabeledRx = regexp.MustCompile(`(?i)\bpolicy\s*(?:#|no\.?|number)\s*:?\s*([A-Z]{1,3}-?\d{3,12})\b`)
+	policyLooseRx   = regexp.MustCompile(`(?i)\bpolicy\b[^\n]{0,24}?\b([A-Z]{1,3}-?\d{4,12})\b`)
+
+	subjectRx      = regexp.MustCompile(`(?im)^[ \t]*(?:re|subject)[ \t]*:[ \t]*(.+)$`)
+	subjectSplitRx = regexp.MustCompile(`\s+[–—-]\s+`)
+
+	emailRx = regexp.MustCompile(`(?i)\b[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,}\b`)
+	phoneRx = regexp.MustCompile(`(?:\+?1[\s.-]?)?\(?\b(\d{3})\)?[\s.-]?(\d{3})[\s.-](\d{4})\b`)
+
+	amountRx = regexp.MustCompile(`(?i)(approximately|about|around|roughly|estimated)?\s*\$\s?(\d{1,3}(?:,\d{3})+|\d+)(?:\.(\d{2}))?`)
+
+	cityStateRx = regexp.MustCompile(`\b(?:in|at|near)\s+(?:my\s+)?((?:[A-Z][a-z]+\s+){0,3}[A-Z][a-z]+),\s+([A-Z]{2})\b`)
+	inMyRx      = regexp.MustCompile(`\bin my ([A-Z][a-z]+)\b`)
+	atTheRx     = regexp.MustCompile(`\bat the ((?:[A-Z][a-z]+\s+)*[A-Z][a-z]+)`)
+)
+
+// Parse extracts every field it can find in text. Fields it cannot find are nil.
+func Parse(text string) models.Extracted {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	ex := models.Extracted{
+		PolicyNumber:     policyNumber(text),
+		ClaimType:        claimType(text),
+		IncidentLocation: location(text),
+		Email:            email(text),
+		Phone:            phone(text),
+		Amounts:          amounts(text),
+	}
+	ex.LetterDate, ex.IncidentDate = dates(text)
+	return ex
+}
+
+// field builds an ExtractedField.
+func field(v string, conf float64) *models.ExtractedField {
+	return &models.ExtractedField{Value: v, Confidence: conf}
+}
+
+// policyNumber finds "Policy #A12345" / "Policy #: WC-44551", falling back to an
+// ID-shaped token shortly after the word "policy".
+func policyNumber(text string) *models.ExtractedField {
+	if m := policyLabeledRx.FindStringSubmatch(text); m != nil {
+		return field(strings.ToUpper(m[1]), confLabeled)
+	}
+	if m := policyLooseRx.FindStringSubmatch(text); m != nil {
+		return field(strings.ToUpper(m[1]), confWeak)
+	}
+	return nil
+}
+
+// claimType takes the incident description from the "Re:" / "Subject:" line, dropping
+// policy references and boilerplate such as "Claim for".
+func claimType(text string) *models.ExtractedField {
+	m := subjectRx.FindStringSubmatch(text)
+	if m == nil {
+		return nil
+	}
+	for _, part := range subjectSplitRx.Split(strings.TrimSpace(m[1]), -1) {
+		if strings.Contains(strings.ToLower(part), "policy") {
+			continue
+		}
+		part = strings.TrimPrefix(part, "Claim for ")
+		part = strings.TrimSuffix(part, " Claim")
+		part = strings.TrimSpace(part)
+		if part == "" || strings.EqualFold(part, "claim") {
+			continue
+		}
+		return field(part, confSubject)
+	}
+	return nil
+}
+
+// location finds "in <City>, <ST>", falling back to "in my <City>" and "at the <Place>".
+func location(text string) *models.ExtractedField {
+	body := bodyOf(text)
+	if m := cityStateRx.FindStringSubmatch(body); m != nil {
+		return field(m[1]+", "+m[2], confPlace)
+	}
+	if m := inMyRx.FindStringSubmatch(body); m != nil {
+		return field(m[1], confWeak)
+	}
+	if m := atTheRx.FindStringSubmatch(body); m != nil {
+		return field(m[1], confGuess)
+	}
+	return nil
+}
+
+// email returns the first email address, most confident when the line is labeled.
+func email(text string) *models.ExtractedField {
+	loc := emailRx.FindStringIndex(text)
+	if loc == nil {
+		return nil
+	}
+	conf := confPattern
+	if labeledLine(text, loc[0], "email", "contact", "e-mail") {
+		conf = confLabeled
+	}
+	return field(strings.ToLower(text[loc[0]:loc[1]]), conf)
+}
+
+// phone returns the first US phone number in E.164 form.
+func phone(text string) *models.ExtractedField {
+	loc := phoneRx.FindStringSubmatchIndex(text)
+	if loc == nil {
+		return nil
+	}
+	v := "+1" + text[loc[2]:loc[3]] + text[loc[4]:loc[5]] + text[loc[6]:loc[7]]
+	conf := confPattern
+	if labeledLine(text, loc[0], "phone", "contact", "tel") {
+		conf = confLabeled
+	}
+	return field(v, conf)
+}
+
+// amounts returns every dollar amount as a plain decimal string, in order of appearance.
+func amounts(text string) []models.ExtractedField {
+	var out []models.ExtractedField
+	for _, m := range amountRx.FindAllStringSubmatch(text, -1) {
+		cents := m[3]
+		if cents == "" {
+			cents = "00"
+		}
+		conf := confPattern
+		if m[1] != "" {
+			conf = confHedged
+		}
+		out = append(out, models.ExtractedField{
+			Value:      strings.ReplaceAll(m[2], ",", "") + "." + cents,
+			Confidence: conf,
+		})
+	}
+	return out
+}
+
+// labeledLine reports whether the line containing offset i starts with one of labels.
+func labeledLine(text string, i int, labels ...string) bool {
+	start := strings.LastIndexByte(text[:i], '\n') + 1
+	line := strings.ToLower(strings.TrimSpace(text[start:i]))
+	for _, l := range labels {
+		if strings.HasPrefix(line, l) {
+			return true
+		}
+	}
+	return false
+}
+
+// bodyOf drops the header lines ("Date:", "To:", "Re:", ...) before the salutation,
+// so header values are not mistaken for incident details.
+func bodyOf(text string) string {
+	if i := strings.Index(text, "\nDear "); i >= 0 {
+		return text[i:]
+	}
+	return text
+}
@@ -0,0 +1,59 @@
+package extract
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the .extracted.json golden files from the current output")
+
+// corpus is the repository's sample letters; each <name>.txt sits next to a
+// <name>.extracted.json holding the fields Parse must find in it.
+const corpus = "../../../data"
+
+func TestParseGolden(t *testing.T) {
+	letters, err := filepath.Glob(filepath.Join(corpus, "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(letters) == 0 {
+		t.Fatalf("no letters in %s", corpus)
+	}
+	for _, path := range letters {
+		name := strings.TrimSuffix(filepath.Base(path), ".txt")
+		t.Run(name, func(t *testing.T) {
+			text, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			enc := json.NewEncoder(&buf)
+			enc.SetEscapeHTML(false) // keep "Hit & Run" readable
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(Parse(string(text))); err != nil {
+				t.Fatal(err)
+			}
+			got := buf.Bytes()
+
+			golden := strings.TrimSuffix(path, ".txt") + ".extracted.json"
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run go test ./internal/extract -update to create it)", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("Parse(%s) differs from %s:\ngot:\n%s\nwant:\n%s", filepath.Base(path), filepath.Base(golden), got, want)
+			}
+		})
+	}
+}
@@ -3,7 +3,9 @@ package httpx
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/aws/aws-lambda-go/events"
@@ -25,6 +27,7 @@ func JSONV1(status int, v any) (events.APIGatewayProxyResponse, error) {
 			"Content-Type":                     "application/json",
 			"Access-Control-Allow-Origin":      allowOriginV1,
 			"Access-Control-Allow-Credentials": "true",
+			"Access-Control-Expose-Headers":    "ETag",
 			"Vary":                             "Origin",
 		},
 		Body: string(b),
@@ -35,3 +38,46 @@ func JSONV1(status int, v any) (events.APIGatewayProxyResponse, error) {
 func ErrorV1(status int, msg string) (events.APIGatewayProxyResponse, error) {
 	return JSONV1(status, map[string]string{"message": msg})
 }
+
+// TaggedJSONV1 is JSONV1 with an ETag header for the given item version.
+func TaggedJSONV1(status int, version int64, v any) (events.APIGatewayProxyResponse, error) {
+	resp, err := JSONV1(status, v)
+	resp.Headers["ETag"] = ETag(version)
+	return resp, err
+}
+
+//
+// -------- Conditional requests --------
+//
+
+// ErrBadIfMatch is returned by IfMatch for a header that is not one of our ETags.
+var ErrBadIfMatch = errors.New("invalid If-Match header")
+
+// ETag formats an item version as a strong entity tag.
+func ETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// IfMatch parses the If-Match request header (looked up case-insensitively) into the item
+// version it names. ok is false when the header is absent or "*", i.e. any version will do.
+// Only a single strong tag as produced by ETag is accepted.
+func IfMatch(headers map[string]string) (version int64, ok bool, err error) {
+	var v string
+	for k, hv := range headers {
+		if strings.EqualFold(k, "If-Match") {
+			v = strings.TrimSpace(hv)
+			break
+		}
+	}
+	if v == "" || v == "*" {
+		return 0, false, nil
+	}
+	if len(v) < 3 || v[0] != '"' || v[len(v)-1] != '"' {
+		return 0, false, ErrBadIfMatch
+	}
+	version, err = strconv.ParseInt(v[1:len(v)-1], 10, 64)
+	if err != nil || version < 0 {
+		return 0, false, ErrBadIfMatch
+	}
+	return version, true, nil
+}
@@ -0,0 +1,54 @@
+package httpx
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    int64
+		ok      bool
+		err     error
+	}{
+		{"absent", map[string]string{}, 0, false, nil},
+		{"nil headers", nil, 0, false, nil},
+		{"any version", map[string]string{"If-Match": "*"}, 0, false, nil},
+		{"strong tag", map[string]string{"If-Match": `"7"`}, 7, true, nil},
+		{"lower-case name", map[string]string{"if-match": ` "3" `}, 3, true, nil},
+		{"version zero", map[string]string{"If-Match": `"0"`}, 0, true, nil},
+		{"unquoted", map[string]string{"If-Match": "7"}, 0, false, ErrBadIfMatch},
+		{"weak tag", map[string]string{"If-Match": `W/"7"`}, 0, false, ErrBadIfMatch},
+		{"list", map[string]string{"If-Match": `"7", "8"`}, 0, false, ErrBadIfMatch},
+		{"not a version", map[string]string{"If-Match": `"abc"`}, 0, false, ErrBadIfMatch},
+		{"negative", map[string]string{"If-Match": `"-1"`}, 0, false, ErrBadIfMatch},
+		{"empty tag", map[string]string{"If-Match": `""`}, 0, false, ErrBadIfMatch},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			v, ok, err := IfMatch(tc.headers)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("err = %v, want %v", err, tc.err)
+			}
+			if v != tc.want || ok != tc.ok {
+				t.Errorf("IfMatch = (%d, %v), want (%d, %v)", v, ok, tc.want, tc.ok)
+			}
+		})
+	}
+}
+
+func TestETagRoundTrip(t *testing.T) {
+	resp, err := TaggedJSONV1(200, 42, map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Headers["ETag"] != `"42"` {
+		t.Fatalf("ETag = %q, want %q", resp.Headers["ETag"], `"42"`)
+	}
+	v, ok, err := IfMatch(map[string]string{"If-Match": resp.Headers["ETag"]})
+	if err != nil || !ok || v != 42 {
+		t.Errorf("IfMatch(ETag(42)) = (%d, %v, %v), want (42, true, nil)", v, ok, err)
+	}
+}
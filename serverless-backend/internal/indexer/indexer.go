@@ -0,0 +1,613 @@
+// Package indexer finalizes uploaded objects: it verifies their content, scans them for
+// malware, parses, classifies and redacts text letters, marks the claim or attachment
+// COMPLETE (or QUARANTINED), and adds letters to the search index. The indexer Lambda
+// drives it from S3 events delivered through SQS, and cmd/replay re-drives dead-lettered
+// events through the same path, so every step is idempotent.
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/classify"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/config"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/ddb"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/extract"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/models"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/pii"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/s3io"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/scan"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/search"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/validate"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// S3API is the part of the S3 client the indexer uses: it heads and reads uploads, moves
+// infected ones to quarantine, and writes redacted copies and search shards. *s3.Client
+// and s3io.Fake implement it.
+type S3API interface {
+	HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error)
+	s3io.Getter
+	s3io.Putter
+	s3io.Copier
+}
+
+// Processor finalizes S3 records. It is safe for concurrent use.
+type Processor struct {
+	env     config.Env
+	types   validate.Allowlist
+	scanner scan.Scanner // nil when SCANNER=none
+	rules   *classify.Classifier
+	pii     []pii.Detector
+	search  search.Index // nil when SEARCH_BACKEND=none
+	s3c     S3API
+	repo    ddb.ClaimStore
+}
+
+// New builds a Processor from env: the type allowlist, malware scanner, classification
+// rules, PII detectors and search index it names.
+func New(env config.Env, s3c S3API, repo ddb.ClaimStore) (*Processor, error) {
+	types, err := validate.NewAllowlist(env.AllowedTypes)
+	if err != nil {
+		return nil, err
+	}
+	scanner, err := scan.New(env.Scanner, env.ClamAVAddr, env.ScanTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if scanner == nil {
+		log.Printf("indexer: SCANNER=%s; uploads are not scanned for malware", env.Scanner)
+	}
+	rules, err := classify.Load(env.ClassifyRules)
+	if err != nil {
+		return nil, err
+	}
+	detectors, err := pii.Detectors(env.PIIDetectors)
+	if err != nil {
+		return nil, err
+	}
+	index, err := search.New(env.SearchBackend, env.SearchDir, s3c, env.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	if index == nil {
+		log.Printf("indexer: SEARCH_BACKEND=%s; letters are not indexed for search", env.SearchBackend)
+	}
+	return &Processor{
+		env:     env,
+		types:   types,
+		scanner: scanner,
+		rules:   rules,
+		pii:     detectors,
+		search:  index,
+		s3c:     s3c,
+		repo:    repo,
+	}, nil
+}
+
+// Eligible reports whether rec is an event the indexer finalizes. Every ObjectCreated
+// variant (Put, Post, CompleteMultipartUpload, Copy) is; quarantined objects are not.
+func Eligible(rec events.S3EventRecord) bool {
+	return strings.HasPrefix(rec.EventName, "ObjectCreated:") &&
+		!strings.HasPrefix(rec.S3.Object.Key, s3io.QuarantinePrefix)
+}
+
+// ProcessEvent finalizes every eligible record of ev. A transient error stops the event
+// so it can be redelivered whole; records already finalized are skipped on redelivery.
+// Records that fail permanently are logged and passed over.
+func (p *Processor) ProcessEvent(ctx context.Context, ev events.S3Event) error {
+	for _, rec := range ev.Records { // s3:TestEvent has no records
+		if !Eligible(rec) {
+			log.Printf("indexer: skipping %s for %s", rec.EventName, rec.S3.Object.Key)
+			continue
+		}
+		err := p.ProcessRecord(ctx, rec)
+		if IsPermanent(err) {
+			log.Printf("indexer: giving up on %s: %v", rec.S3.Object.Key, err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// permanentError marks a failure that redelivery cannot fix.
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// permanent wraps err so callers drop the event instead of retrying it.
+func permanent(err error) error { return permanentError{err: err} }
+
+// IsPermanent reports whether err is one redelivery cannot fix (a missing object or
+// record, a malformed key, a record that has moved on).
+func IsPermanent(err error) bool {
+	var p permanentError
+	return errors.As(err, &p)
+}
+
+// ProcessRecord finalizes a single S3 event record. Claim documents and attachments
+// are finalized the same way; attachments then roll up into their claim.
+func (p *Processor) ProcessRecord(ctx context.Context, record events.S3EventRecord) error {
+	bucket := record.S3.Bucket.Name
+	keyEsc := record.S3.Object.Key
+	key, _ := url.QueryUnescape(keyEsc)
+
+	meta, err := p.getObjectMetadata(ctx, bucket, key)
+	var nf *s3types.NotFound
+	if errors.As(err, &nf) { // withdrawn, quarantined, or replaced since the event
+		return permanent(fmt.Errorf("head %s: %w", key, err))
+	}
+	if err != nil {
+		return fmt.Errorf("head %s: %w", key, err)
+	}
+
+	// itemID is the claim ID, or "<claimID>#ATT#<attachmentID>" for an attachment.
+	userID, itemID, err := p.extractIDs(key, meta)
+	if err != nil {
+		return permanent(err)
+	}
+
+	// ver is the record's version as read here; each write below is conditioned on it, so
+	// a record that moves on while the object is checked (withdrawn, reaped, finalized by
+	// another delivery) is left alone. status tracks what the record should still be.
+	rec, done, err := p.alreadyFinal(ctx, userID, itemID, meta)
+	if err != nil || done {
+		return err
+	}
+	ver, status := rec.Version, rec.Status
+
+	// The IDs came from the object's metadata; a key that is not a claim or attachment key
+	// can never be finalized, so the record it was meant for is failed rather than left
+	// UPLOADING for the reaper.
+	if _, _, ok := ddb.ItemForKey(key); !ok {
+		log.Printf("indexer: %s is not a claim or attachment key", key)
+		return p.failRecord(ctx, userID, itemID, ver, models.StatusUploading, models.FailureBadKey)
+	}
+
+	ft, ok := p.types.ByExt(strings.ToLower(path.Ext(key)))
+	if !ok {
+		log.Printf("indexer: %s has no allowed extension", key)
+		return p.failRecord(ctx, userID, itemID, ver, models.StatusUploading, models.FailureUnsupportedType)
+	}
+	if meta.ContentType != "" && meta.ContentType != ft.ContentType {
+		// Be tolerant: the bytes are checked below; log the header mismatch only
+		log.Printf("indexer: warning content-type=%s for %s (expected %s)", meta.ContentType, key, ft.ContentType)
+	}
+
+	limit := p.sizeLimit(meta)
+	if meta.Size > limit {
+		log.Printf("indexer: %s is %d bytes (max %d)", key, meta.Size, limit)
+		return p.failRecord(ctx, userID, itemID, ver, models.StatusUploading, models.FailureTooLarge)
+	}
+
+	reason, err := p.verifyContent(ctx, bucket, key, ft, meta.Size, limit)
+	if err != nil {
+		return fmt.Errorf("verify %s: %w", key, err)
+	}
+	if reason != "" {
+		return p.failRecord(ctx, userID, itemID, ver, models.StatusUploading, reason)
+	}
+
+	if p.scanner != nil {
+		var released bool
+		ver, released, err = p.scanRecord(ctx, bucket, key, userID, itemID, ver, status, meta.Size, limit)
+		if err != nil || !released {
+			return err
+		}
+		status = models.StatusScanning
+	}
+
+	var text string
+	if ft.IsText() && meta.Size > 0 {
+		ver, text = p.analyzeText(ctx, bucket, key, userID, itemID, ver, status, meta.Size)
+	}
+
+	if err := p.finalizeRecord(ctx, userID, itemID, key, ver, status, meta); err != nil {
+		return err
+	}
+	p.indexText(ctx, userID, itemID, text)
+
+	log.Printf("finalized %s/%s status=%s size=%d etag=%s",
+		userID, itemID, models.StatusComplete, meta.Size, meta.ETag)
+	return nil
+}
+
+// ---- Helpers ----
+
+// objectMetadata holds S3 object metadata and user-defined metadata.
+type objectMetadata struct {
+	Size        int64
+	ETag        string
+	VersionID   string // empty unless the bucket is versioned
+	ContentType string
+	Meta        map[string]string // lowercased user metadata
+}
+
+// getObjectMetadata fetches S3 object metadata including user-defined metadata.
+func (p *Processor) getObjectMetadata(ctx context.Context, bucket, key string) (*objectMetadata, error) {
+	ho, err := p.s3c.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: &bucket,
+		Key:    &key,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return p.buildObjectMetadata(ho, key), nil
+}
+
+// buildObjectMetadata constructs objectMetadata from S3 HeadObjectOutput.
+func (p *Processor) buildObjectMetadata(ho *s3.HeadObjectOutput, key string) *objectMetadata {
+	m := &objectMetadata{
+		Meta: make(map[string]string, len(ho.Metadata)),
+	}
+
+	p.setBasicFields(m, ho)
+	p.setContentType(m, ho)
+	p.setUserMetadata(m, ho)
+
+	return m
+}
+
+// setBasicFields sets size and etag from HeadObjectOutput.
+func (p *Processor) setBasicFields(m *objectMetadata, ho *s3.HeadObjectOutput) {
+	if ho.ContentLength != nil {
+		m.Size = *ho.ContentLength
+	}
+	if ho.ETag != nil {
+		m.ETag = strings.Trim(*ho.ETag, "\"")
+	}
+	if ho.VersionId != nil && *ho.VersionId != "null" {
+		m.VersionID = *ho.VersionId
+	}
+}
+
+// setContentType sets the lowercased content type without parameters.
+func (p *Processor) setContentType(m *objectMetadata, ho *s3.HeadObjectOutput) {
+	if ho.ContentType == nil {
+		return
+	}
+
+	ct, _, _ := strings.Cut(*ho.ContentType, ";")
+	m.ContentType = strings.ToLower(strings.TrimSpace(ct))
+}
+
+// setUserMetadata normalizes and copies user metadata keys to lowercase.
+func (p *Processor) setUserMetadata(m *objectMetadata, ho *s3.HeadObjectOutput) {
+	for k, v := range ho.Metadata {
+		m.Meta[strings.ToLower(k)] = v
+	}
+}
+
+// extractIDs gets the user ID and item ID from metadata or the S3 key path.
+// For attachments the item ID is ddb.AttachmentItemID(claimID, attachmentID).
+func (p *Processor) extractIDs(key string, meta *objectMetadata) (userID, itemID string, err error) {
+	userID = strings.TrimSpace(meta.Meta["user_id"])
+	claimID := strings.TrimSpace(meta.Meta["claim_id"])
+	attID := strings.TrimSpace(meta.Meta["attachment_id"])
+
+	if userID == "" || claimID == "" {
+		userID, claimID, attID, err = p.extractIDsFromPath(key, userID, claimID, attID)
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	if attID != "" {
+		return userID, ddb.AttachmentItemID(claimID, attID), nil
+	}
+	return userID, claimID, nil
+}
+
+// extractIDsFromPath parses IDs from S3 key path as fallback. A malformed key is an error:
+// without metadata there is no record to fail.
+func (p *Processor) extractIDsFromPath(key, userID, claimID, attID string) (string, string, string, error) {
+	u2, c2, _, ok := s3io.ParseKey(key)
+	a2 := ""
+	if !ok {
+		u2, c2, a2, _, ok = s3io.ParseAttachmentKey(key)
+	}
+	if !ok {
+		return "", "", "", fmt.Errorf("bad key %q", key)
+	}
+
+	if userID == "" {
+		userID = u2
+	}
+	if claimID == "" {
+		claimID = c2
+	}
+	if attID == "" {
+		attID = a2
+	}
+
+	return userID, claimID, attID, nil
+}
+
+// alreadyFinal reports whether a redelivered event can be skipped: the record is withdrawn,
+// or already COMPLETE for this exact object (same ETag, and version ID when versioned).
+// A missing record is a permanent error; the object has nothing to finalize.
+// It also returns the record as read.
+func (p *Processor) alreadyFinal(ctx context.Context, userID, itemID string, meta *objectMetadata) (models.Claim, bool, error) {
+	rec, err := p.repo.GetClaim(ctx, userID, itemID) // attachments share the item shape
+	if errors.Is(err, ddb.ErrNotFound) {
+		return rec, false, permanent(fmt.Errorf("no record for %s/%s", userID, itemID))
+	}
+	if err != nil {
+		return rec, false, fmt.Errorf("load %s/%s: %w", userID, itemID, err)
+	}
+
+	switch {
+	case rec.Status == models.StatusWithdrawn:
+		log.Printf("indexer: %s/%s is withdrawn; skipping", userID, itemID)
+		return rec, true, nil
+	case rec.Status == models.StatusComplete && rec.ETag == meta.ETag &&
+		(rec.VersionID == "" || meta.VersionID == "" || rec.VersionID == meta.VersionID):
+		log.Printf("indexer: %s/%s already finalized for etag=%s; skipping", userID, itemID, meta.ETag)
+		return rec, true, p.rollUp(ctx, userID, itemID) // in case the first delivery failed after finalizing
+	}
+	return rec, false, nil
+}
+
+// sizeLimit returns the size cap for an object: multipart uploads (ETag "<md5>-<n>")
+// get MaxMultipartBytes, everything else MaxUploadBytes.
+func (p *Processor) sizeLimit(meta *objectMetadata) int64 {
+	if s3io.IsMultipartETag(meta.ETag) {
+		return p.env.MaxMultipartBytes
+	}
+	return p.env.MaxUploadBytes
+}
+
+// verifyContent checks the object against its declared type: text is streamed (bounded by
+// limit) and checked for plain UTF-8, other types only have their magic bytes read.
+// It returns a failure reason for bad content, or an error if the object could not be read.
+func (p *Processor) verifyContent(ctx context.Context, bucket, key string, ft validate.FileType, size, limit int64) (string, error) {
+	if size == 0 { // a ranged GET of an empty object fails with InvalidRange
+		if ft.IsText() {
+			return "", nil
+		}
+		return models.FailureTypeMismatch, nil
+	}
+	if !ft.IsText() {
+		limit = validate.SniffLen
+	}
+	body, err := s3io.OpenRange(ctx, p.s3c, bucket, key, limit)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	if !ft.IsText() {
+		err = ft.CheckMagic(body)
+	} else {
+		err = validate.PlainText(body)
+	}
+	switch {
+	case errors.Is(err, validate.ErrTypeMismatch):
+		return models.FailureTypeMismatch, nil
+	case errors.Is(err, validate.ErrNotUTF8):
+		return models.FailureInvalidUTF8, nil
+	case errors.Is(err, validate.ErrBinaryContent):
+		return models.FailureBinaryContent, nil
+	}
+	return "", err
+}
+
+// finalizeRecord completes the record in DynamoDB, provided it is still at version ver
+// (or only its version moved; see pinned).
+func (p *Processor) finalizeRecord(ctx context.Context, userID, itemID, key string, ver int64, status models.ClaimStatus, meta *objectMetadata) error {
+	err := p.pinned(ctx, userID, itemID, ver, status, func(ver int64) error {
+		return p.repo.UpsertComplete(ctx, userID, itemID, ver, key, meta.Size, meta.ETag, meta.VersionID, ddb.NowISO())
+	})
+	if errors.Is(err, ddb.ErrConflict) {
+		return permanent(fmt.Errorf("finalize %s/%s: %w", userID, itemID, err))
+	}
+	if err != nil {
+		return fmt.Errorf("finalize %s/%s: %w", userID, itemID, err)
+	}
+	return p.rollUp(ctx, userID, itemID)
+}
+
+// failRecord marks a claim or attachment in status from (at version ver) as FAILED. One
+// that has already moved on (withdrawn, reaped) is left alone.
+func (p *Processor) failRecord(ctx context.Context, userID, itemID string, ver int64, from models.ClaimStatus, reason string) error {
+	err := p.pinned(ctx, userID, itemID, ver, from, func(ver int64) error {
+		return p.repo.MarkFailed(ctx, userID, itemID, ver, from, reason, ddb.NowISO())
+	})
+	if errors.Is(err, ddb.ErrConflict) {
+		log.Printf("indexer: %s/%s no longer %s; not marking %s", userID, itemID, from, reason)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("fail %s/%s: %w", userID, itemID, err)
+	}
+	log.Printf("failed %s/%s reason=%s", userID, itemID, reason)
+	return p.rollUp(ctx, userID, itemID)
+}
+
+// scanRecord moves the record to SCANNING and streams the object (bounded by limit) to the
+// scanner. It reports the record's new version and whether the object is clean and may be
+// finalized. Infected objects are moved under s3io.QuarantinePrefix and the record flagged
+// QUARANTINED. Objects over ScanMaxBytes, or that clamd refuses as too long, are marked
+// FAILED: no redelivery could scan them. If the scanner fails otherwise the record stays
+// SCANNING and a transient error is returned, so the event is redelivered (and, once its
+// retries run out, left in the dead-letter queue for cmd/replay); nothing is released unscanned.
+func (p *Processor) scanRecord(ctx context.Context, bucket, key, userID, itemID string, ver int64, status models.ClaimStatus, size, limit int64) (int64, bool, error) {
+	err := p.pinned(ctx, userID, itemID, ver, status, func(at int64) (err error) {
+		ver, err = p.repo.MarkScanning(ctx, userID, itemID, at)
+		return err
+	})
+	if errors.Is(err, ddb.ErrConflict) {
+		log.Printf("indexer: %s/%s no longer UPLOADING; not scanning", userID, itemID)
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("scanning %s/%s: %w", userID, itemID, err)
+	}
+	if err := p.rollUp(ctx, userID, itemID); err != nil {
+		return 0, false, err
+	}
+
+	if size == 0 { // nothing to scan, and a ranged GET of an empty object fails
+		return ver, true, nil
+	}
+	// Checked once SCANNING so a record left there by an earlier delivery is failed too.
+	if p.env.ScanMaxBytes > 0 && size > p.env.ScanMaxBytes {
+		log.Printf("indexer: %s is %d bytes (scan max %d)", key, size, p.env.ScanMaxBytes)
+		return 0, false, p.failRecord(ctx, userID, itemID, ver, models.StatusScanning, models.FailureScanTooLarge)
+	}
+	res, err := p.scanObject(ctx, bucket, key, limit)
+	if errors.Is(err, scan.ErrTooLarge) {
+		log.Printf("indexer: %s: %v", key, err)
+		return 0, false, p.failRecord(ctx, userID, itemID, ver, models.StatusScanning, models.FailureScanTooLarge)
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("scan %s: %w", key, err)
+	}
+	if !res.Infected {
+		return ver, true, nil
+	}
+
+	qkey := s3io.QuarantineKey(key)
+	if err := s3io.Move(ctx, p.s3c, bucket, key, qkey); err != nil {
+		return 0, false, fmt.Errorf("quarantine %s: %w", key, err)
+	}
+	err = p.pinned(ctx, userID, itemID, ver, models.StatusScanning, func(ver int64) error {
+		return p.repo.MarkQuarantined(ctx, userID, itemID, ver, qkey, res.Signature, ddb.NowISO())
+	})
+	if errors.Is(err, ddb.ErrConflict) {
+		log.Printf("indexer: %s/%s no longer SCANNING; object quarantined at %s", userID, itemID, qkey)
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("quarantine %s/%s: %w", userID, itemID, err)
+	}
+	log.Printf("quarantined %s/%s signature=%q key=%s", userID, itemID, res.Signature, qkey)
+	return 0, false, p.rollUp(ctx, userID, itemID)
+}
+
+// scanObject streams the object to the scanner.
+func (p *Processor) scanObject(ctx context.Context, bucket, key string, limit int64) (scan.Result, error) {
+	body, err := s3io.OpenRange(ctx, p.s3c, bucket, key, limit)
+	if err != nil {
+		return scan.Result{}, err
+	}
+	defer body.Close()
+	return p.scanner.Scan(ctx, body)
+}
+
+// analyzeText parses and classifies a text claim letter, writes a PII-redacted copy under
+// s3io.RedactedPrefix, and stores the results on its item. It is best effort: a failure is
+// logged and the upload is finalized regardless (without a redacted copy, vendors simply
+// cannot download it). Letters over MaxUploadBytes are parsed but not redacted.
+// Attachments are supporting evidence, not letters, and are skipped.
+// It returns the item's version afterwards (ver unless the analysis was stored) and the
+// letter's text, "" if it could not be read.
+func (p *Processor) analyzeText(ctx context.Context, bucket, key, userID, itemID string, ver int64, status models.ClaimStatus, size int64) (int64, string) {
+	if _, _, ok := ddb.SplitItemID(itemID); ok {
+		return ver, ""
+	}
+	body, err := s3io.OpenRange(ctx, p.s3c, bucket, key, p.env.MaxUploadBytes)
+	if err != nil {
+		log.Printf("indexer: extract %s: %v", key, err)
+		return ver, ""
+	}
+	defer body.Close()
+
+	b, err := io.ReadAll(body)
+	if err != nil {
+		log.Printf("indexer: extract %s: %v", key, err)
+		return ver, ""
+	}
+	text := string(b)
+	head := text
+	if len(head) > extract.MaxBytes {
+		head = head[:extract.MaxBytes]
+	}
+	cat := p.rules.Classify(head)
+	an := ddb.Analysis{Extracted: extract.Parse(head), Category: cat.Category}
+
+	if size <= p.env.MaxUploadBytes {
+		matches := pii.Find(text, p.pii)
+		rkey := s3io.RedactedKey(key)
+		if err := s3io.PutText(ctx, p.s3c, bucket, rkey, pii.Redact(text, matches)); err != nil {
+			log.Printf("indexer: write redacted %s: %v", rkey, err)
+		} else {
+			an.PII, an.RedactedKey = pii.Inventory(matches), rkey
+		}
+	}
+
+	next := ver
+	err = p.pinned(ctx, userID, itemID, ver, status, func(at int64) (err error) {
+		next, err = p.repo.PutAnalysis(ctx, userID, itemID, at, an)
+		return err
+	})
+	if err != nil {
+		log.Printf("indexer: store analysis %s/%s: %v", userID, itemID, err)
+		return ver, text
+	}
+	log.Printf("analyzed %s/%s category=%s score=%d pii=%v", userID, itemID, cat.Category, cat.Score, an.PII)
+	return next, text
+}
+
+// indexText adds a finalized letter's text to its owner's search shard. Like analyzeText
+// it is best effort: the claim is already COMPLETE, and a redelivery would be skipped as
+// finalized, so a failure is logged rather than retried.
+func (p *Processor) indexText(ctx context.Context, userID, claimID, text string) {
+	if p.search == nil || text == "" {
+		return
+	}
+	if err := p.search.Add(ctx, search.Doc{UserID: userID, ClaimID: claimID, Text: text}); err != nil {
+		log.Printf("indexer: search index %s/%s: %v", userID, claimID, err)
+	}
+}
+
+// pinnedAttempts bounds how often pinned retries a write after its record's version moved.
+const pinnedAttempts = 5
+
+// pinned runs write at version ver. A claim's version also moves when an attachment is
+// added or rolls up, which leaves its status alone; if write loses to such a change (the
+// record is still in status, only at a newer version) it is retried at that version, so
+// a claim is not abandoned because an attachment arrived while it was being checked. Any
+// other error, including a conflict with a record that has moved on, is returned.
+func (p *Processor) pinned(ctx context.Context, userID, itemID string, ver int64, status models.ClaimStatus, write func(ver int64) error) error {
+	for attempt := 1; ; attempt++ {
+		err := write(ver)
+		if !errors.Is(err, ddb.ErrConflict) || attempt == pinnedAttempts {
+			return err
+		}
+		rec, gerr := p.repo.GetClaim(ctx, userID, itemID)
+		if gerr != nil || rec.Status != status || rec.Version == ver {
+			return err
+		}
+		ver = rec.Version
+	}
+}
+
+// rollUp refreshes the owning claim's attachments_status after an attachment changes.
+// It is a no-op for claim documents.
+func (p *Processor) rollUp(ctx context.Context, userID, itemID string) error {
+	claimID, _, ok := ddb.SplitItemID(itemID)
+	if !ok {
+		return nil
+	}
+	status, err := p.repo.RollUpAttachments(ctx, userID, claimID)
+	if err != nil {
+		return fmt.Errorf("roll up %s/%s: %w", userID, claimID, err)
+	}
+	log.Printf("rolled up %s/%s attachments_status=%s", userID, claimID, status)
+	return nil
+}
@@ -0,0 +1,375 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/config"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/ddb"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/models"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/s3io"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/scan"
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/search"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+const testBucket = "claims-bucket"
+
+// newTestProcessor returns a Processor over an empty fake bucket and MemStore, with no
+// malware scanner and search shards kept in the bucket.
+func newTestProcessor(t *testing.T) (*Processor, *s3io.Fake, *ddb.MemStore) {
+	t.Helper()
+	objects, store := &s3io.Fake{}, &ddb.MemStore{}
+	p, err := New(config.Env{
+		Bucket:            testBucket,
+		MaxUploadBytes:    1 << 20,
+		MaxMultipartBytes: 1 << 30,
+		Scanner:           scan.BackendNone,
+		SearchBackend:     search.BackendS3,
+	}, objects, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p, objects, store
+}
+
+// upload records a pending claim the way presign does, puts body at its key the way the
+// client does, and returns the S3 event record for it.
+func upload(t *testing.T, objects *s3io.Fake, store *ddb.MemStore, userID, claimID, body string) events.S3EventRecord {
+	t.Helper()
+	key := s3io.BuildKey(userID, claimID, s3io.ExtText)
+	err := store.PutPending(context.Background(), models.Claim{
+		UserID: userID, ClaimID: claimID, Filename: claimID + ".txt",
+		ContentType: s3io.ContentTypeText, S3Key: key, Status: models.StatusUploading,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	objects.Put(testBucket, key, s3io.ContentTypeText, []byte(body), map[string]string{"user_id": userID, "claim_id": claimID})
+	return s3Record(key)
+}
+
+// s3Record is the ObjectCreated record S3 sends for key.
+func s3Record(key string) events.S3EventRecord {
+	return events.S3EventRecord{
+		EventName: "ObjectCreated:Put",
+		S3: events.S3Entity{
+			Bucket: events.S3Bucket{Name: testBucket},
+			Object: events.S3Object{Key: key},
+		},
+	}
+}
+
+// letter reads a sample claim letter from the repository's data/ directory.
+func letter(t *testing.T, name string) string {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join("..", "..", "..", "data", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestProcessRecordFinalizesLetter(t *testing.T) {
+	ctx := context.Background()
+	p, objects, store := newTestProcessor(t)
+	rec := upload(t, objects, store, "u-1", "c-1", letter(t, "auto_accident_john_smith.txt"))
+
+	if err := p.ProcessRecord(ctx, rec); err != nil {
+		t.Fatalf("ProcessRecord: %v", err)
+	}
+
+	c, err := store.GetClaim(ctx, "u-1", "c-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Status != models.StatusComplete {
+		t.Fatalf("status = %s, want COMPLETE", c.Status)
+	}
+	if c.Extracted == nil || c.Extracted.PolicyNumber == nil || c.Extracted.PolicyNumber.Value != "A12345" {
+		t.Errorf("extracted = %+v, want policy A12345", c.Extracted)
+	}
+	redacted, ok := objects.Object(testBucket, c.RedactedKey)
+	if c.RedactedKey == "" || !ok {
+		t.Fatalf("no redacted copy at %q", c.RedactedKey)
+	}
+	if strings.Contains(string(redacted), "A12345") {
+		t.Error("redacted copy still contains the policy number")
+	}
+
+	hits, err := p.search.Search(ctx, "u-1", "camry", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 || hits[0].ClaimID != "c-1" {
+		t.Errorf("search hits = %+v, want c-1", hits)
+	}
+}
+
+func TestProcessRecordRejectsBadContent(t *testing.T) {
+	ctx := context.Background()
+	p, objects, store := newTestProcessor(t)
+	rec := upload(t, objects, store, "u-1", "c-1", "binary\x00data")
+
+	if err := p.ProcessRecord(ctx, rec); err != nil {
+		t.Fatalf("ProcessRecord: %v", err)
+	}
+	c, err := store.GetClaim(ctx, "u-1", "c-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Status != models.StatusFailed || c.FailureReason != models.FailureBinaryContent {
+		t.Errorf("status = %s (%s), want FAILED (%s)", c.Status, c.FailureReason, models.FailureBinaryContent)
+	}
+}
+
+func TestProcessRecordMissingObjectIsPermanent(t *testing.T) {
+	p, _, _ := newTestProcessor(t)
+	err := p.ProcessRecord(context.Background(), s3Record(s3io.BuildKey("u-1", "c-1", s3io.ExtText)))
+	if !IsPermanent(err) {
+		t.Errorf("err = %v, want a permanent error", err)
+	}
+}
+
+func TestProcessRecordMalformedKey(t *testing.T) {
+	ctx := context.Background()
+	for _, key := range []string{"user/u-1/c-1", "user/u-1/c-1/a-1/extra.txt", "uploads/c-1.txt"} {
+		t.Run(key, func(t *testing.T) {
+			p, objects, store := newTestProcessor(t)
+			upload(t, objects, store, "u-1", "c-1", "Dear Claims Adjuster, my car was hit.")
+
+			// Without metadata nothing names the record, so the event is dropped.
+			objects.Put(testBucket, key, s3io.ContentTypeText, []byte("Dear Claims Adjuster"), nil)
+			if err := p.ProcessRecord(ctx, s3Record(key)); !IsPermanent(err) {
+				t.Fatalf("without metadata: err = %v, want a permanent error", err)
+			}
+			if c, _ := store.GetClaim(ctx, "u-1", "c-1"); c.Status != models.StatusUploading {
+				t.Fatalf("status = %s, want UPLOADING", c.Status)
+			}
+
+			// With it, the record the upload was meant for is failed.
+			objects.Put(testBucket, key, s3io.ContentTypeText, []byte("Dear Claims Adjuster"), map[string]string{"user_id": "u-1", "claim_id": "c-1"})
+			if err := p.ProcessRecord(ctx, s3Record(key)); err != nil {
+				t.Fatalf("with metadata: %v", err)
+			}
+			c, err := store.GetClaim(ctx, "u-1", "c-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Status != models.StatusFailed || c.FailureReason != models.FailureBadKey {
+				t.Errorf("status = %s (%s), want FAILED (%s)", c.Status, c.FailureReason, models.FailureBadKey)
+			}
+		})
+	}
+}
+
+func TestProcessRecordScanVerdicts(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("clean", func(t *testing.T) {
+		p, objects, store := newTestProcessor(t)
+		fake := &scan.Fake{}
+		p.scanner = fake
+		rec := upload(t, objects, store, "u-1", "c-1", "Dear Claims Adjuster, my car was hit.")
+
+		if err := p.ProcessRecord(ctx, rec); err != nil {
+			t.Fatalf("ProcessRecord: %v", err)
+		}
+		c, err := store.GetClaim(ctx, "u-1", "c-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Status != models.StatusComplete || fake.Calls() != 1 {
+			t.Errorf("status = %s after %d scans, want COMPLETE after 1", c.Status, fake.Calls())
+		}
+	})
+
+	t.Run("infected", func(t *testing.T) {
+		p, objects, store := newTestProcessor(t)
+		p.scanner = &scan.Fake{}
+		rec := upload(t, objects, store, "u-1", "c-1", "attached: "+scan.EICAR)
+		key := rec.S3.Object.Key
+
+		if err := p.ProcessRecord(ctx, rec); err != nil {
+			t.Fatalf("ProcessRecord: %v", err)
+		}
+		c, err := store.GetClaim(ctx, "u-1", "c-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Status != models.StatusQuarantined || c.ScanSignature != "Eicar-Test-Signature" {
+			t.Errorf("status = %s (%q), want QUARANTINED (Eicar-Test-Signature)", c.Status, c.ScanSignature)
+		}
+		if c.QuarantineKey != s3io.QuarantineKey(key) || !strings.HasPrefix(c.QuarantineKey, s3io.QuarantinePrefix) {
+			t.Errorf("quarantine_key = %q, want %q", c.QuarantineKey, s3io.QuarantineKey(key))
+		}
+		if _, ok := objects.Object(testBucket, key); ok {
+			t.Error("infected object still at its upload key")
+		}
+		if _, ok := objects.Object(testBucket, c.QuarantineKey); !ok {
+			t.Error("infected object not under quarantine/")
+		}
+		if c.RedactedKey != "" || c.Extracted != nil {
+			t.Error("infected letter was analyzed")
+		}
+	})
+
+	t.Run("scanner error retries", func(t *testing.T) {
+		p, objects, store := newTestProcessor(t)
+		fake := &scan.Fake{Err: errors.New("clamd: connection refused")}
+		p.scanner = fake
+		rec := upload(t, objects, store, "u-1", "c-1", "Dear Claims Adjuster, my car was hit.")
+
+		err := p.ProcessRecord(ctx, rec)
+		if err == nil || IsPermanent(err) {
+			t.Fatalf("err = %v, want a transient error", err)
+		}
+		c, err := store.GetClaim(ctx, "u-1", "c-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Status != models.StatusScanning {
+			t.Fatalf("status = %s, want SCANNING until the scan succeeds", c.Status)
+		}
+		if _, ok := objects.Object(testBucket, rec.S3.Object.Key); !ok {
+			t.Fatal("object moved after a failed scan")
+		}
+
+		fake.Err = nil // clamd is back; the redelivery goes through
+		if err := p.ProcessRecord(ctx, rec); err != nil {
+			t.Fatalf("redelivery: %v", err)
+		}
+		if c, _ = store.GetClaim(ctx, "u-1", "c-1"); c.Status != models.StatusComplete {
+			t.Errorf("status after redelivery = %s, want COMPLETE", c.Status)
+		}
+	})
+
+	t.Run("over scan max fails", func(t *testing.T) {
+		p, objects, store := newTestProcessor(t)
+		fake := &scan.Fake{}
+		p.scanner = fake
+		p.env.ScanMaxBytes = 16
+		rec := upload(t, objects, store, "u-1", "c-1", "Dear Claims Adjuster, my car was hit.")
+
+		if err := p.ProcessRecord(ctx, rec); err != nil {
+			t.Fatalf("ProcessRecord: %v", err)
+		}
+		c, err := store.GetClaim(ctx, "u-1", "c-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Status != models.StatusFailed || c.FailureReason != models.FailureScanTooLarge || fake.Calls() != 0 {
+			t.Errorf("status = %s (%s) after %d scans, want FAILED (%s) unscanned",
+				c.Status, c.FailureReason, fake.Calls(), models.FailureScanTooLarge)
+		}
+	})
+
+	t.Run("scanner size limit fails", func(t *testing.T) {
+		p, objects, store := newTestProcessor(t)
+		p.scanner = &scan.Fake{Err: fmt.Errorf("%w: INSTREAM size limit exceeded. ERROR", scan.ErrTooLarge)}
+		rec := upload(t, objects, store, "u-1", "c-1", "Dear Claims Adjuster, my car was hit.")
+
+		if err := p.ProcessRecord(ctx, rec); err != nil {
+			t.Fatalf("ProcessRecord: %v, want the record failed rather than retried", err)
+		}
+		c, err := store.GetClaim(ctx, "u-1", "c-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Status != models.StatusFailed || c.FailureReason != models.FailureScanTooLarge {
+			t.Errorf("status = %s (%s), want FAILED (%s)", c.Status, c.FailureReason, models.FailureScanTooLarge)
+		}
+	})
+}
+
+// attachingStore attaches a file to a claim just before the indexer marks it SCANNING and
+// again before it finalizes it, the way a client adding photos mid-scan would. Each
+// attachment bumps the claim's version under the indexer.
+type attachingStore struct {
+	*ddb.MemStore
+	scanning, complete sync.Once
+}
+
+func (s *attachingStore) attach(ctx context.Context, userID, claimID, attachmentID string) {
+	err := s.PutPendingAttachment(ctx, models.Attachment{UserID: userID, ClaimID: claimID, AttachmentID: attachmentID, Filename: "photo.png"})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (s *attachingStore) MarkScanning(ctx context.Context, userID, itemID string, expected int64) (int64, error) {
+	s.scanning.Do(func() { s.attach(ctx, userID, itemID, "a-1") })
+	return s.MemStore.MarkScanning(ctx, userID, itemID, expected)
+}
+
+func (s *attachingStore) UpsertComplete(ctx context.Context, userID, itemID string, expected int64, key string, size int64, etag, versionID, at string) error {
+	s.complete.Do(func() { s.attach(ctx, userID, itemID, "a-2") })
+	return s.MemStore.UpsertComplete(ctx, userID, itemID, expected, key, size, etag, versionID, at)
+}
+
+func TestProcessRecordAttachmentMidScan(t *testing.T) {
+	ctx := context.Background()
+	p, objects, store := newTestProcessor(t)
+	p.repo = &attachingStore{MemStore: store}
+	p.scanner = &scan.Fake{}
+	rec := upload(t, objects, store, "u-1", "c-1", "Dear Claims Adjuster, my car was hit.")
+
+	if err := p.ProcessRecord(ctx, rec); err != nil {
+		t.Fatalf("ProcessRecord: %v", err)
+	}
+	c, err := store.GetClaim(ctx, "u-1", "c-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Status != models.StatusComplete || c.AttachmentCount != 2 {
+		t.Errorf("claim = %s with %d attachments, want COMPLETE with 2", c.Status, c.AttachmentCount)
+	}
+}
+
+func TestProcessRecordTwiceIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	p, objects, store := newTestProcessor(t)
+	fake := &scan.Fake{}
+	p.scanner = fake
+	rec := upload(t, objects, store, "u-1", "c-1", letter(t, "dog_bite_sarah_jones.txt"))
+
+	if err := p.ProcessRecord(ctx, rec); err != nil {
+		t.Fatalf("first delivery: %v", err)
+	}
+	first, err := store.GetClaim(ctx, "u-1", "c-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	history, err := store.ListHistory(ctx, "u-1", "c-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.ProcessRecord(ctx, rec); err != nil {
+		t.Fatalf("second delivery: %v", err)
+	}
+	second, err := store.GetClaim(ctx, "u-1", "c-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := store.ListHistory(ctx, "u-1", "c-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Version != first.Version || second.Status != models.StatusComplete {
+		t.Errorf("after redelivery: version %d status %s, want version %d COMPLETE", second.Version, second.Status, first.Version)
+	}
+	if len(again) != len(history) {
+		t.Errorf("redelivery added %d audit events", len(again)-len(history))
+	}
+	if fake.Calls() != 1 {
+		t.Errorf("scanned %d times, want once", fake.Calls())
+	}
+}
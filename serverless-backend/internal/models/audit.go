@@ -0,0 +1,35 @@
+package models
+
+// Actions recorded in the audit trail.
+const (
+	AuditCreate     = "create"     // presign wrote the pending claim
+	AuditAttach     = "attach"     // presign wrote a pending attachment
+	AuditScan       = "scan"       // indexer verified the content and started the malware scan
+	AuditQuarantine = "quarantine" // scanner flagged the object
+	AuditComplete   = "complete"   // indexer finalized the upload
+	AuditFail       = "fail"       // upload failed (validation, scan error, reaper, abort, reconcile)
+	AuditAnalyze    = "analyze"    // indexer stored extracted fields, category and PII findings
+	AuditWithdraw   = "withdraw"   // claimant or admin withdrew the claim
+	AuditReview     = "review"     // adjuster changed the review status
+	AuditAssign     = "assign"     // claims team changed the assigned vendors
+)
+
+// AuditEvent is one immutable entry in a claim's history. Events live in the audit table,
+// partitioned by claim and sorted by EventID (a ULID, so the timeline sorts by time).
+// Attachment events are filed under their claim with ItemID naming the attachment item.
+type AuditEvent struct {
+	ClaimRef  string            `dynamodbav:"claim_ref" json:"-"` // "<user_id>#<claim_id>", see ddb.AuditRef
+	EventID   string            `dynamodbav:"event_id" json:"event_id"`
+	ItemID    string            `dynamodbav:"item_id" json:"item_id"` // claim ID, or "<claimID>#ATT#<attachmentID>"
+	Action    string            `dynamodbav:"action" json:"action"`
+	Actor     string            `dynamodbav:"actor" json:"actor"` // user sub, or "system:<component>"
+	RequestID string            `dynamodbav:"request_id,omitempty" json:"request_id,omitempty"`
+	At        string            `dynamodbav:"at" json:"at"`
+	Changes   map[string]Change `dynamodbav:"changes,omitempty" json:"changes,omitempty"` // attribute -> before/after
+}
+
+// Change is one attribute's value before and after a write; nil means absent.
+type Change struct {
+	Before any `dynamodbav:"before" json:"before"`
+	After  any `dynamodbav:"after" json:"after"`
+}
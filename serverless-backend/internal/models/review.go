@@ -0,0 +1,78 @@
+package models
+
+// ReviewStatus is where a claim stands in adjudication. It is separate from ClaimStatus,
+// which only tracks the upload: a claim enters review once its document is COMPLETE.
+type ReviewStatus string
+
+// Possible values for ReviewStatus
+const (
+	ReviewSubmitted   ReviewStatus = "SUBMITTED"    // upload complete; waiting for an adjuster
+	ReviewUnderReview ReviewStatus = "UNDER_REVIEW" // an adjuster is working on it
+	ReviewNeedsInfo   ReviewStatus = "NEEDS_INFO"   // waiting on the claimant; see the review note
+	ReviewApproved    ReviewStatus = "APPROVED"
+	ReviewDenied      ReviewStatus = "DENIED"
+	ReviewClosed      ReviewStatus = "CLOSED" // terminal
+)
+
+// reviewStatuses lists every ReviewStatus in lifecycle order.
+var reviewStatuses = []ReviewStatus{
+	ReviewSubmitted, ReviewUnderReview, ReviewNeedsInfo, ReviewApproved, ReviewDenied, ReviewClosed,
+}
+
+// reviewTransitions is the adjudication state machine: each status maps to the statuses
+// it may move to. Anything not listed is illegal.
+//
+//	SUBMITTED -> UNDER_REVIEW -> NEEDS_INFO -> UNDER_REVIEW (loop)
+//	                          -> APPROVED | DENIED -> CLOSED
+var reviewTransitions = map[ReviewStatus][]ReviewStatus{
+	ReviewSubmitted:   {ReviewUnderReview},
+	ReviewUnderReview: {ReviewNeedsInfo, ReviewApproved, ReviewDenied},
+	ReviewNeedsInfo:   {ReviewUnderReview},
+	ReviewApproved:    {ReviewClosed},
+	ReviewDenied:      {ReviewClosed},
+}
+
+// Valid reports whether s is a known review status.
+func (s ReviewStatus) Valid() bool {
+	for _, v := range reviewStatuses {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// CanTransition reports whether a claim in review status from may move to to.
+func CanTransition(from, to ReviewStatus) bool {
+	for _, next := range reviewTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ReviewSources returns the statuses that may move to to, in lifecycle order.
+// It is empty for SUBMITTED, which is only ever the starting point.
+func ReviewSources(to ReviewStatus) []ReviewStatus {
+	var out []ReviewStatus
+	for _, from := range reviewStatuses {
+		if CanTransition(from, to) {
+			out = append(out, from)
+		}
+	}
+	return out
+}
+
+// Review returns the claim's review status. A COMPLETE claim finalized before review
+// tracking existed has none stored and counts as SUBMITTED; a claim whose upload is
+// not COMPLETE is not in review at all ("").
+func (c Claim) Review() ReviewStatus {
+	if c.ReviewStatus != "" {
+		return c.ReviewStatus
+	}
+	if c.Status == StatusComplete {
+		return ReviewSubmitted
+	}
+	return ""
+}
@@ -1,14 +1,57 @@
 // Package models defines the data models used in the application.
 package models
 
+import "strings"
+
 // ClaimStatus represents the status of an insurance claim.
 type ClaimStatus string
 
 // Possible values for ClaimStatus
 const (
-	StatusUploading ClaimStatus = "UPLOADING"
-	StatusComplete  ClaimStatus = "COMPLETE"
-	StatusFailed    ClaimStatus = "FAILED"
+	StatusUploading   ClaimStatus = "UPLOADING"
+	StatusScanning    ClaimStatus = "SCANNING" // content verified; malware scan in progress
+	StatusComplete    ClaimStatus = "COMPLETE"
+	StatusFailed      ClaimStatus = "FAILED"
+	StatusQuarantined ClaimStatus = "QUARANTINED" // scanner flagged the object; moved under the quarantine prefix
+	StatusWithdrawn   ClaimStatus = "WITHDRAWN"   // soft-deleted by the claimant or an admin
+)
+
+// claimStatuses lists every ClaimStatus.
+var claimStatuses = []ClaimStatus{
+	StatusUploading, StatusScanning, StatusComplete, StatusFailed, StatusQuarantined, StatusWithdrawn,
+}
+
+// Valid reports whether s is a known claim status.
+func (s ClaimStatus) Valid() bool {
+	for _, v := range claimStatuses {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// AcceptsAttachments reports whether a claim in status s may take new attachments: one
+// still uploading, being scanned or complete. Failed, quarantined and withdrawn claims may not.
+func (s ClaimStatus) AcceptsAttachments() bool {
+	return s == StatusUploading || s == StatusScanning || s == StatusComplete
+}
+
+// Machine-readable values for Claim.FailureReason.
+const (
+	FailureUploadExpired = "upload_expired" // presigned upload never arrived
+	FailureTooLarge      = "too_large"      // object exceeds MAX_UPLOAD_BYTES
+	FailureInvalidUTF8   = "invalid_utf8"   // bytes are not valid UTF-8
+	FailureBinaryContent = "binary_content" // control/binary bytes in a text upload
+	FailureUploadAborted = "upload_aborted" // client aborted a multipart upload
+	FailureScanTooLarge  = "scan_too_large" // object exceeds SCAN_MAX_BYTES, so it cannot be scanned
+
+	FailureUnsupportedType = "unsupported_type" // key extension is not an allowed type
+	FailureTypeMismatch    = "type_mismatch"    // magic bytes do not match the declared type
+	FailureBadKey          = "bad_key"          // object key is not a claim or attachment key
+
+	FailureObjectMissing = "object_missing" // COMPLETE record whose object is gone (found by reconcile)
+	FailureObjectChanged = "object_changed" // object replaced after it was finalized; the new bytes were never checked
 )
 
 // Claim represents an insurance claim uploaded by a user.
@@ -17,42 +60,258 @@ type Claim struct {
 	PK string `dynamodbav:"PK" json:"-"` // USER#<sub>
 	SK string `dynamodbav:"SK" json:"-"` // CLAIM#<claimID> (ULID)
 
-	ClaimID    string      `dynamodbav:"claim_id"`
-	UserID     string      `dynamodbav:"user_id"`
-	Filename   string      `dynamodbav:"filename"`
-	S3Key      string      `dynamodbav:"s3_key"`
-	Tags       []string    `dynamodbav:"tags"`
-	Client     string      `dynamodbav:"client"`
-	Status     ClaimStatus `dynamodbav:"status"`
-	UploadedAt string      `dynamodbav:"uploaded_at"` // ISO8601; set by indexer on finalize
-	SizeBytes  int64       `dynamodbav:"size_bytes"`
-	ETag       string      `dynamodbav:"etag"`
+	ClaimID     string      `dynamodbav:"claim_id"`
+	UserID      string      `dynamodbav:"user_id"`
+	Filename    string      `dynamodbav:"filename"`
+	ContentType string      `dynamodbav:"content_type,omitempty"` // empty for text claims predating the allowlist
+	S3Key       string      `dynamodbav:"s3_key"`
+	Tags        []string    `dynamodbav:"tags"`               // free-form, entered by the user
+	Category    string      `dynamodbav:"category,omitempty"` // canonical type assigned by the indexer
+	Client      string      `dynamodbav:"client"`
+	Status      ClaimStatus `dynamodbav:"status"`
+	UploadedAt  string      `dynamodbav:"uploaded_at"` // ISO8601; set by indexer on finalize
+	SizeBytes   int64       `dynamodbav:"size_bytes"`
+	ETag        string      `dynamodbav:"etag"`
+	VersionID   string      `dynamodbav:"version_id,omitempty"` // S3 object version, when the bucket is versioned
+	WithdrawnAt string      `dynamodbav:"withdrawn_at,omitempty"`
+
+	CreatedAt     string `dynamodbav:"created_at,omitempty"` // ISO8601; set by presign
+	FailureReason string `dynamodbav:"failure_reason,omitempty"`
+
+	// Set when the malware scan flags the object.
+	QuarantineKey string `dynamodbav:"quarantine_key,omitempty"`
+	ScanSignature string `dynamodbav:"scan_signature,omitempty"`
+
+	// Parsed from text claim letters by the indexer; nil when nothing was extracted.
+	Extracted *Extracted `dynamodbav:"extracted,omitempty"`
+
+	// PII found in text claim letters (kind -> count) and the redacted copy's S3 key.
+	PII         map[string]int `dynamodbav:"pii,omitempty"`
+	RedactedKey string         `dynamodbav:"redacted_key,omitempty"`
+
+	// Multipart uploads only.
+	UploadID  string `dynamodbav:"upload_id,omitempty"`
+	PartCount int32  `dynamodbav:"part_count,omitempty"`
+
+	// Maintained as attachments are added and finalized.
+	AttachmentCount   int         `dynamodbav:"attachment_count,omitempty"`
+	AttachmentsStatus ClaimStatus `dynamodbav:"attachments_status,omitempty"` // RollUp of the attachments
+
+	// Adjudication, once the upload is COMPLETE (see review.go). Use Review() to read the status.
+	ReviewStatus ReviewStatus `dynamodbav:"review_status,omitempty"`
+	ReviewNote   string       `dynamodbav:"review_note,omitempty"` // shown to the claimant, e.g. what info is needed
+	ReviewedBy   string       `dynamodbav:"reviewed_by,omitempty"` // sub of the adjuster who made the last transition
+	ReviewedAt   string       `dynamodbav:"reviewed_at,omitempty"`
+
+	// Subs of the vendors the claims team assigned to the claim; only they may download
+	// its redacted copy (see authz.Can).
+	Vendors []string `dynamodbav:"vendors,omitempty"`
+
+	// Incremented by every write to the item (not by attachment bookkeeping); 0 for items
+	// written before versioning. Conditional writes check it, see ddb.Repo.
+	Version int64 `dynamodbav:"version,omitempty"`
+}
+
+// ExtractedField is one value parsed from a claim letter with a 0..1 confidence score.
+type ExtractedField struct {
+	Value      string  `dynamodbav:"value" json:"value"`
+	Confidence float64 `dynamodbav:"confidence" json:"confidence"`
+}
+
+// Extracted holds the structured fields parsed from a claim letter (see internal/extract).
+// Dates are YYYY-MM-DD, phones E.164, amounts plain decimals ("5200.00").
+type Extracted struct {
+	PolicyNumber     *ExtractedField  `dynamodbav:"policy_number,omitempty" json:"policy_number,omitempty"`
+	LetterDate       *ExtractedField  `dynamodbav:"letter_date,omitempty" json:"letter_date,omitempty"`
+	IncidentDate     *ExtractedField  `dynamodbav:"incident_date,omitempty" json:"incident_date,omitempty"`
+	IncidentLocation *ExtractedField  `dynamodbav:"incident_location,omitempty" json:"incident_location,omitempty"`
+	ClaimType        *ExtractedField  `dynamodbav:"claim_type,omitempty" json:"claim_type,omitempty"` // subject line, free-form
+	Email            *ExtractedField  `dynamodbav:"email,omitempty" json:"email,omitempty"`
+	Phone            *ExtractedField  `dynamodbav:"phone,omitempty" json:"phone,omitempty"`
+	Amounts          []ExtractedField `dynamodbav:"amounts,omitempty" json:"amounts,omitempty"`
+}
+
+// Empty reports whether no field was extracted.
+func (e Extracted) Empty() bool {
+	return e.PolicyNumber == nil && e.LetterDate == nil && e.IncidentDate == nil &&
+		e.IncidentLocation == nil && e.ClaimType == nil && e.Email == nil && e.Phone == nil &&
+		len(e.Amounts) == 0
+}
+
+// MaxAttachments caps how many attachments a single claim can carry.
+const MaxAttachments = 20
+
+// Attachment is an additional document on an existing claim. It lives in the claim's
+// partition under claim_id "<claimID>#ATT#<attachmentID>", so it sorts beside its claim.
+type Attachment struct {
+	UserID       string      `dynamodbav:"user_id"`
+	ItemID       string      `dynamodbav:"claim_id"`  // composite sort key, see ddb.AttachmentItemID
+	ClaimID      string      `dynamodbav:"parent_id"` // owning claim; marks the item as an attachment
+	AttachmentID string      `dynamodbav:"attachment_id"`
+	Filename     string      `dynamodbav:"filename"`
+	ContentType  string      `dynamodbav:"content_type"`
+	S3Key        string      `dynamodbav:"s3_key"`
+	Status       ClaimStatus `dynamodbav:"status"`
+	CreatedAt    string      `dynamodbav:"created_at"`
+
+	UploadedAt    string `dynamodbav:"uploaded_at,omitempty"`
+	SizeBytes     int64  `dynamodbav:"size_bytes,omitempty"`
+	ETag          string `dynamodbav:"etag,omitempty"`
+	VersionID     string `dynamodbav:"version_id,omitempty"`
+	FailureReason string `dynamodbav:"failure_reason,omitempty"`
+	QuarantineKey string `dynamodbav:"quarantine_key,omitempty"`
+	ScanSignature string `dynamodbav:"scan_signature,omitempty"`
+	Version       int64  `dynamodbav:"version,omitempty"`
+}
+
+// rollUpOrder ranks statuses for RollUp; the highest-ranked status present wins.
+var rollUpOrder = map[ClaimStatus]int{
+	StatusComplete:    1,
+	StatusFailed:      2,
+	StatusQuarantined: 3,
+	StatusScanning:    4,
+	StatusUploading:   5,
+}
+
+// RollUp summarizes attachment statuses into one: UPLOADING while any upload is
+// outstanding, then SCANNING while any scan is, then QUARANTINED if any was flagged,
+// FAILED if any failed, else COMPLETE. WITHDRAWN entries are ignored; an empty
+// result means there is nothing to summarize.
+func RollUp(statuses []ClaimStatus) ClaimStatus {
+	var out ClaimStatus
+	for _, s := range statuses {
+		if rollUpOrder[s] > rollUpOrder[out] {
+			out = s
+		}
+	}
+	return out
 }
 
+// Role is an application role derived from Cognito group membership.
+type Role string
+
+// Possible values for Role
+const (
+	RoleClaimant Role = "claimant" // every authenticated user
+	RoleAdjuster Role = "adjuster"
+	RoleAdmin    Role = "admin"
+	RoleVendor   Role = "vendor" // third party; may only download redacted copies
+)
+
 // UserClaims represents the JWT claims extracted from the user's authentication token.
 type UserClaims struct {
-	Sub   string
-	Email string
+	Sub    string
+	Email  string
+	Groups []string // cognito:groups
+	Roles  []Role   // derived from Groups; always includes RoleClaimant
+}
+
+// RolesFromGroups maps Cognito group names (case-insensitive) to roles.
+// Unknown groups are ignored; RoleClaimant is always granted.
+func RolesFromGroups(groups []string) []Role {
+	roles := []Role{RoleClaimant}
+	for _, g := range groups {
+		switch Role(strings.ToLower(strings.TrimSpace(g))) {
+		case RoleAdjuster:
+			roles = append(roles, RoleAdjuster)
+		case RoleAdmin:
+			roles = append(roles, RoleAdmin)
+		case RoleVendor:
+			roles = append(roles, RoleVendor)
+		}
+	}
+	return roles
+}
+
+// HasRole reports whether the user holds role r.
+func (u UserClaims) HasRole(r Role) bool {
+	for _, have := range u.Roles {
+		if have == r {
+			return true
+		}
+	}
+	return false
 }
 
 // ClaimView is a sanitized view of Claim for API responses.
+// It omits storage internals (owner sub, S3 key); downloads go through the download endpoint.
 type ClaimView struct {
-	ClaimID    string   `json:"claim_id"`
-	Filename   string   `json:"filename"`
-	Tags       []string `json:"tags"`
-	Client     string   `json:"client"`
-	Status     string   `json:"status"`
-	UploadedAt string   `json:"uploaded_at"`
-	SizeBytes  int64    `json:"size_bytes"`
-	ETag       string   `json:"etag"`
-	S3Key      string   `json:"s3_key"`
+	ClaimID     string   `json:"claim_id"`
+	Filename    string   `json:"filename"`
+	ContentType string   `json:"content_type"`
+	Tags        []string `json:"tags"`
+	Category    string   `json:"category,omitempty"`
+	Client      string   `json:"client"`
+	Status      string   `json:"status"`
+	UploadedAt  string   `json:"uploaded_at"`
+	SizeBytes   int64    `json:"size_bytes"`
+	ETag        string   `json:"etag"`
+	WithdrawnAt string   `json:"withdrawn_at,omitempty"`
+
+	CreatedAt     string `json:"created_at,omitempty"`
+	FailureReason string `json:"failure_reason,omitempty"`
+
+	Extracted *Extracted     `json:"extracted,omitempty"`
+	PII       map[string]int `json:"pii,omitempty"`
+
+	AttachmentCount   int              `json:"attachment_count,omitempty"`
+	AttachmentsStatus string           `json:"attachments_status,omitempty"`
+	Attachments       []AttachmentView `json:"attachments,omitempty"` // detail endpoint only
+
+	ReviewStatus string `json:"review_status,omitempty"`
+	ReviewNote   string `json:"review_note,omitempty"`
+	ReviewedAt   string `json:"reviewed_at,omitempty"`
+
+	Vendors []string `json:"vendors,omitempty"` // staff only; see authz.ClaimView
+
+	Version int64 `json:"version"` // send back as If-Match to modify the claim
+}
+
+// AttachmentView is the API representation of an Attachment.
+type AttachmentView struct {
+	AttachmentID  string `json:"attachment_id"`
+	Filename      string `json:"filename"`
+	ContentType   string `json:"content_type"`
+	Status        string `json:"status"`
+	CreatedAt     string `json:"created_at"`
+	UploadedAt    string `json:"uploaded_at,omitempty"`
+	SizeBytes     int64  `json:"size_bytes,omitempty"`
+	ETag          string `json:"etag,omitempty"`
+	FailureReason string `json:"failure_reason,omitempty"`
+}
+
+// View returns an AttachmentView representation of the Attachment.
+func (a Attachment) View() AttachmentView {
+	return AttachmentView{
+		AttachmentID: a.AttachmentID, Filename: a.Filename, ContentType: a.ContentType,
+		Status: string(a.Status), CreatedAt: a.CreatedAt, UploadedAt: a.UploadedAt,
+		SizeBytes: a.SizeBytes, ETag: a.ETag, FailureReason: a.FailureReason,
+	}
+}
+
+// AssignedTo reports whether vendorSub is one of the claim's assigned vendors.
+func (c Claim) AssignedTo(vendorSub string) bool {
+	for _, v := range c.Vendors {
+		if v == vendorSub {
+			return true
+		}
+	}
+	return false
 }
 
 // View returns a ClaimView representation of the Claim.
 func (c Claim) View() ClaimView {
+	ct := c.ContentType
+	if ct == "" {
+		ct = "text/plain"
+	}
 	return ClaimView{
-		ClaimID: c.ClaimID, Filename: c.Filename, Tags: c.Tags, Client: c.Client,
+		ClaimID: c.ClaimID, Filename: c.Filename, ContentType: ct, Tags: c.Tags, Category: c.Category, Client: c.Client,
 		Status: string(c.Status), UploadedAt: c.UploadedAt, SizeBytes: c.SizeBytes,
-		ETag: c.ETag, S3Key: c.S3Key,
+		ETag: c.ETag, WithdrawnAt: c.WithdrawnAt,
+		CreatedAt: c.CreatedAt, FailureReason: c.FailureReason, Extracted: c.Extracted, PII: c.PII,
+		AttachmentCount: c.AttachmentCount, AttachmentsStatus: string(c.AttachmentsStatus),
+		ReviewStatus: string(c.Review()), ReviewNote: c.ReviewNote, ReviewedAt: c.ReviewedAt,
+		Vendors: c.Vendors, Version: c.Version,
 	}
 }
@@ -0,0 +1,141 @@
+// Package pii finds personal data in claim text and produces redacted copies.
+// Detectors are pluggable: anything implementing Detector can be added to a scan.
+package pii
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Kinds reported by the built-in detectors.
+const (
+	KindEmail  = "email"
+	KindPhone  = "phone"
+	KindSSN    = "ssn"
+	KindPolicy = "policy_number"
+)
+
+// Match is one detected span of text[Start:End].
+type Match struct {
+	Kind       string
+	Start, End int
+}
+
+// Detector finds one kind of personal data.
+type Detector interface {
+	Kind() string
+	Detect(text string) []Match
+}
+
+// RegexDetector reports every match of Rx. If Rx has a capture group, only the first
+// group is reported, so labels around the value ("Policy #") are kept.
+type RegexDetector struct {
+	Name string
+	Rx   *regexp.Regexp
+}
+
+// Kind returns the detector's kind.
+func (d RegexDetector) Kind() string { return d.Name }
+
+// Detect returns the spans of every match in text.
+func (d RegexDetector) Detect(text string) []Match {
+	var out []Match
+	for _, loc := range d.Rx.FindAllStringSubmatchIndex(text, -1) {
+		start, end := loc[0], loc[1]
+		if len(loc) >= 4 && loc[2] >= 0 {
+			start, end = loc[2], loc[3]
+		}
+		out = append(out, Match{Kind: d.Name, Start: start, End: end})
+	}
+	return out
+}
+
+// builtin lists the detectors selectable by name, in default order.
+var builtin = []Detector{
+	RegexDetector{Name: KindEmail, Rx: regexp.MustCompile(`(?i)\b[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,}\b`)},
+	RegexDetector{Name: KindSSN, Rx: regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`)},
+	RegexDetector{Name: KindPhone, Rx: regexp.MustCompile(`(?:\+?1[\s.-]?)?(?:\(\d{3}\)|\b\d{3})[\s.-]?\d{3}[\s.-]\d{4}\b`)},
+	RegexDetector{Name: KindPolicy, Rx: regexp.MustCompile(`(?i)\bpolicy\s*(?:#|no\.?|number)\s*:?\s*([A-Z]{1,3}-?\d{3,12})\b`)},
+}
+
+// Detectors returns the built-in detectors named in csv (e.g. "email,ssn").
+// An empty csv means all of them; unknown names are an error.
+func Detectors(csv string) ([]Detector, error) {
+	if strings.TrimSpace(csv) == "" {
+		return builtin, nil
+	}
+	var out []Detector
+	for _, name := range strings.Split(csv, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		d, ok := byKind(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown PII detector %q", name)
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+// byKind finds a built-in detector by kind.
+func byKind(kind string) (Detector, bool) {
+	for _, d := range builtin {
+		if d.Kind() == kind {
+			return d, true
+		}
+	}
+	return nil, false
+}
+
+// Find runs every detector over text and returns the matches in text order.
+// Overlapping matches are resolved in favour of the earliest, then longest.
+func Find(text string, detectors []Detector) []Match {
+	var all []Match
+	for _, d := range detectors {
+		all = append(all, d.Detect(text)...)
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].Start != all[j].Start {
+			return all[i].Start < all[j].Start
+		}
+		return all[i].End > all[j].End
+	})
+
+	out := all[:0]
+	end := -1
+	for _, m := range all {
+		if m.Start < end {
+			continue
+		}
+		out = append(out, m)
+		end = m.End
+	}
+	return out
+}
+
+// Redact replaces each match (as returned by Find) with "[REDACTED <KIND>]".
+func Redact(text string, matches []Match) string {
+	var b strings.Builder
+	b.Grow(len(text))
+	last := 0
+	for _, m := range matches {
+		b.WriteString(text[last:m.Start])
+		b.WriteString("[REDACTED " + strings.ToUpper(m.Kind) + "]")
+		last = m.End
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// Inventory counts matches by kind. It returns nil when there are none.
+func Inventory(matches []Match) map[string]int {
+	if len(matches) == 0 {
+		return nil
+	}
+	inv := make(map[string]int)
+	for _, m := range matches {
+		inv[m.Kind]++
+	}
+	return inv
+}
@@ -0,0 +1,214 @@
+package pii
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+// found returns the text of each match, as "kind:value".
+func found(text string, matches []Match) []string {
+	var out []string
+	for _, m := range matches {
+		out = append(out, m.Kind+":"+text[m.Start:m.End])
+	}
+	return out
+}
+
+func TestDetectors(t *testing.T) {
+	tests := []struct {
+		kind string
+		text string
+		want []string // matched values
+	}{
+		{KindEmail, "write to jane.doe+claims@example.co.uk today", []string{"jane.doe+claims@example.co.uk"}},
+		{KindEmail, "JOHN_SMITH@Mail.Example.COM", []string{"JOHN_SMITH@Mail.Example.COM"}},
+		{KindEmail, "no address at example dot com, or @example.com, or jane@localhost", nil},
+		{KindSSN, "SSN 123-45-6789.", []string{"123-45-6789"}},
+		{KindSSN, "123-45-67890 and 1234-56-7890 and 123456789", nil},
+		{KindPhone, "call 555-123-4567 or (555) 123-4567", []string{"555-123-4567", "(555) 123-4567"}},
+		{KindPhone, "+1 555.123.4567 and 1-555-123-4567", []string{"+1 555.123.4567", "1-555-123-4567"}},
+		{KindPhone, "5551234567 and 555-1234", nil},
+		{KindPolicy, "Policy #: AB-123456 covers it", []string{"AB-123456"}},
+		{KindPolicy, "policy number HO12345678, POLICY NO. X-987", []string{"HO12345678", "X-987"}},
+		{KindPolicy, "policy AB-123456 and policy # 12345", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind+"/"+tt.text, func(t *testing.T) {
+			d, ok := byKind(tt.kind)
+			if !ok {
+				t.Fatalf("no detector %s", tt.kind)
+			}
+			var got []string
+			for _, m := range d.Detect(tt.text) {
+				if m.Kind != tt.kind {
+					t.Errorf("kind = %s, want %s", m.Kind, tt.kind)
+				}
+				got = append(got, tt.text[m.Start:m.End])
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Detect = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectorsByName(t *testing.T) {
+	tests := []struct {
+		csv     string
+		want    []string
+		wantErr bool
+	}{
+		{"", []string{KindEmail, KindSSN, KindPhone, KindPolicy}, false},
+		{" ", []string{KindEmail, KindSSN, KindPhone, KindPolicy}, false},
+		{"ssn", []string{KindSSN}, false},
+		{" Phone , EMAIL", []string{KindPhone, KindEmail}, false},
+		{"email,address", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.csv, func(t *testing.T) {
+			ds, err := Detectors(tt.csv)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, d := range ds {
+				got = append(got, d.Kind())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("kinds = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"none", "Dear Claims Adjuster, my car was hit.", nil},
+		{
+			"text order across detectors",
+			"Policy # AB-1234, SSN 123-45-6789, email jane@example.com, phone 555-123-4567.",
+			[]string{"policy_number:AB-1234", "ssn:123-45-6789", "email:jane@example.com", "phone:555-123-4567"},
+		},
+		// The phone pattern also matches the digits inside each address.
+		{"earlier wins", "mail jane.555-123-4567@example.com", []string{"email:jane.555-123-4567@example.com"}},
+		{"longer wins at the same start", "mail 555-123-4567@example.com", []string{"email:555-123-4567@example.com"}},
+		{"adjacent matches both kept", "123-45-6789 555-123-4567", []string{"ssn:123-45-6789", "phone:555-123-4567"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := found(tt.text, Find(tt.text, builtin)); !slices.Equal(got, tt.want) {
+				t.Errorf("Find = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// fixed reports the same spans whatever the text, to drive Find's overlap handling.
+type fixed struct {
+	kind  string
+	spans [][2]int
+}
+
+func (f fixed) Kind() string { return f.kind }
+
+func (f fixed) Detect(string) []Match {
+	var out []Match
+	for _, s := range f.spans {
+		out = append(out, Match{Kind: f.kind, Start: s[0], End: s[1]})
+	}
+	return out
+}
+
+func TestFindOverlaps(t *testing.T) {
+	tests := []struct {
+		name      string
+		detectors []Detector
+		want      []Match
+	}{
+		{
+			"nested span dropped",
+			[]Detector{fixed{"a", [][2]int{{2, 10}}}, fixed{"b", [][2]int{{4, 6}}}},
+			[]Match{{"a", 2, 10}},
+		},
+		{
+			"same start, longer kept",
+			[]Detector{fixed{"a", [][2]int{{2, 5}}}, fixed{"b", [][2]int{{2, 8}}}},
+			[]Match{{"b", 2, 8}},
+		},
+		{
+			"same span, first detector kept",
+			[]Detector{fixed{"a", [][2]int{{2, 5}}}, fixed{"b", [][2]int{{2, 5}}}},
+			[]Match{{"a", 2, 5}},
+		},
+		{
+			"partial overlap, earlier kept",
+			[]Detector{fixed{"a", [][2]int{{6, 12}}}, fixed{"b", [][2]int{{3, 8}}}},
+			[]Match{{"b", 3, 8}},
+		},
+		{
+			"touching spans both kept",
+			[]Detector{fixed{"a", [][2]int{{5, 9}}}, fixed{"b", [][2]int{{0, 5}}}},
+			[]Match{{"b", 0, 5}, {"a", 5, 9}},
+		},
+		{
+			"chain resolved against the kept span",
+			[]Detector{fixed{"a", [][2]int{{0, 4}, {6, 9}}}, fixed{"b", [][2]int{{3, 7}}}},
+			[]Match{{"a", 0, 4}, {"a", 6, 9}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Find("0123456789abcdef", tt.detectors); !slices.Equal(got, tt.want) {
+				t.Errorf("Find = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		matches []Match
+		want    string
+	}{
+		{"no matches", "nothing here", nil, "nothing here"},
+		{"middle", "SSN 123-45-6789.", []Match{{KindSSN, 4, 15}}, "SSN [REDACTED SSN]."},
+		{"whole text", "jane@example.com", []Match{{KindEmail, 0, 16}}, "[REDACTED EMAIL]"},
+		{
+			"several, ends of text",
+			"555-123-4567 or AB-1234",
+			[]Match{{KindPhone, 0, 12}, {KindPolicy, 16, 23}},
+			"[REDACTED PHONE] or [REDACTED POLICY_NUMBER]",
+		},
+		{"multibyte text around a match", "café 123-45-6789 ☕", []Match{{KindSSN, 6, 17}}, "café [REDACTED SSN] ☕"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Redact(tt.text, tt.matches); got != tt.want {
+				t.Errorf("Redact = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactFound(t *testing.T) {
+	text := "Policy # AB-1234. Reach me at jane@example.com or 555-123-4567; SSN 123-45-6789."
+	matches := Find(text, builtin)
+	want := "Policy # [REDACTED POLICY_NUMBER]. Reach me at [REDACTED EMAIL] or [REDACTED PHONE]; SSN [REDACTED SSN]."
+	if got := Redact(text, matches); got != want {
+		t.Errorf("Redact = %q\nwant     %q", got, want)
+	}
+	wantInv := map[string]int{KindPolicy: 1, KindEmail: 1, KindPhone: 1, KindSSN: 1}
+	if inv := Inventory(matches); !maps.Equal(inv, wantInv) {
+		t.Errorf("Inventory = %v, want %v", inv, wantInv)
+	}
+	if inv := Inventory(nil); inv != nil {
+		t.Errorf("Inventory(nil) = %v, want nil", inv)
+	}
+}
@@ -0,0 +1,317 @@
+package s3io
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// Fake is an in-memory object store for tests. It implements the S3 calls the handlers
+// and the indexer make (head, ranged get, conditional put, copy, delete, list, multipart)
+// and the presigners, which return fake URLs. Errs injects a failure for every call on a
+// key. The zero value is an empty store; it is safe for concurrent use.
+type Fake struct {
+	Errs map[string]error // object key -> error returned by any call on it
+
+	mu      sync.Mutex
+	objects map[string]fakeObject // by bucket + "/" + key
+	uploads map[string]fakeUpload // in-progress multipart uploads by upload ID
+	nextID  int
+}
+
+// fakeUpload is an in-progress multipart upload. Parts go straight to presigned URLs, so
+// only what CreateMultipartUpload was given is kept.
+type fakeUpload struct {
+	bucket, key string
+	contentType string
+	meta        map[string]string
+}
+
+// fakeObject is one stored object.
+type fakeObject struct {
+	body        []byte
+	etag        string // quoted, as S3 returns it
+	contentType string
+	meta        map[string]string
+}
+
+// Put stores an object directly, as a client upload would.
+func (f *Fake) Put(bucket, key, contentType string, body []byte, meta map[string]string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.put(bucket, key, contentType, body, meta)
+}
+
+// Object returns an object's body and whether it exists.
+func (f *Fake) Object(bucket, key string) ([]byte, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	o, ok := f.objects[bucket+"/"+key]
+	return o.body, ok
+}
+
+// Keys returns the keys stored in bucket, sorted.
+func (f *Fake) Keys(bucket string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var keys []string
+	for k := range f.objects {
+		if key, ok := strings.CutPrefix(k, bucket+"/"); ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// StartUpload records an in-progress multipart upload, as presign would, and returns its ID.
+func (f *Fake) StartUpload(bucket, key, contentType string, meta map[string]string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.uploads == nil {
+		f.uploads = make(map[string]fakeUpload)
+	}
+	f.nextID++
+	id := fmt.Sprintf("upload-%d", f.nextID)
+	f.uploads[id] = fakeUpload{bucket: bucket, key: key, contentType: contentType, meta: meta}
+	return id
+}
+
+// HasUpload reports whether a multipart upload is still in progress.
+func (f *Fake) HasUpload(uploadID string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.uploads[uploadID]
+	return ok
+}
+
+// HeadObject returns an object's size, ETag, content type and metadata.
+func (f *Fake) HeadObject(_ context.Context, in *s3.HeadObjectInput, _ ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	o, err := f.get(in.Bucket, in.Key)
+	if err != nil {
+		var nsk *types.NoSuchKey
+		if errors.As(err, &nsk) {
+			return nil, &types.NotFound{}
+		}
+		return nil, err
+	}
+	return &s3.HeadObjectOutput{
+		ContentLength: aws.Int64(int64(len(o.body))),
+		ETag:          aws.String(o.etag),
+		ContentType:   aws.String(o.contentType),
+		Metadata:      o.meta,
+	}, nil
+}
+
+// GetObject returns an object's body, honoring a "bytes=first-last" Range.
+func (f *Fake) GetObject(_ context.Context, in *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	o, err := f.get(in.Bucket, in.Key)
+	if err != nil {
+		return nil, err
+	}
+	body := o.body
+	if r := aws.ToString(in.Range); r != "" {
+		var first, last int
+		if _, err := fmt.Sscanf(r, "bytes=%d-%d", &first, &last); err != nil || first >= len(body) {
+			return nil, &smithy.GenericAPIError{Code: "InvalidRange", Message: r}
+		}
+		body = body[first:min(last+1, len(body))]
+	}
+	return &s3.GetObjectOutput{
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: aws.Int64(int64(len(body))),
+		ETag:          aws.String(o.etag),
+		ContentType:   aws.String(o.contentType),
+		Metadata:      o.meta,
+	}, nil
+}
+
+// PutObject stores an object, honoring IfMatch and IfNoneMatch "*".
+func (f *Fake) PutObject(_ context.Context, in *s3.PutObjectInput, _ ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	key := aws.ToString(in.Key)
+	if err := f.Errs[key]; err != nil {
+		return nil, err
+	}
+	var body []byte
+	if in.Body != nil {
+		var err error
+		if body, err = io.ReadAll(in.Body); err != nil {
+			return nil, err
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	cur, exists := f.objects[aws.ToString(in.Bucket)+"/"+key]
+	switch {
+	case aws.ToString(in.IfNoneMatch) == "*" && exists,
+		in.IfMatch != nil && (!exists || strings.Trim(*in.IfMatch, `"`) != strings.Trim(cur.etag, `"`)):
+		return nil, &smithy.GenericAPIError{Code: "PreconditionFailed", Message: key}
+	}
+	o := f.put(aws.ToString(in.Bucket), key, aws.ToString(in.ContentType), body, in.Metadata)
+	return &s3.PutObjectOutput{ETag: aws.String(o.etag)}, nil
+}
+
+// CopyObject copies the object named by CopySource ("bucket/escaped-key").
+func (f *Fake) CopyObject(_ context.Context, in *s3.CopyObjectInput, _ ...func(*s3.Options)) (*s3.CopyObjectOutput, error) {
+	srcBucket, srcKey, _ := strings.Cut(aws.ToString(in.CopySource), "/")
+	srcKey, err := url.PathUnescape(srcKey)
+	if err != nil {
+		return nil, err
+	}
+	o, err := f.get(&srcBucket, &srcKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Errs[aws.ToString(in.Key)]; err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.put(aws.ToString(in.Bucket), aws.ToString(in.Key), o.contentType, o.body, o.meta)
+	return &s3.CopyObjectOutput{}, nil
+}
+
+// DeleteObject removes an object; deleting a missing key succeeds, as in S3.
+func (f *Fake) DeleteObject(_ context.Context, in *s3.DeleteObjectInput, _ ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	if err := f.Errs[aws.ToString(in.Key)]; err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.objects, aws.ToString(in.Bucket)+"/"+aws.ToString(in.Key))
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+// ListObjectsV2 lists the keys under Prefix in one page.
+func (f *Fake) ListObjectsV2(_ context.Context, in *s3.ListObjectsV2Input, _ ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	out := &s3.ListObjectsV2Output{IsTruncated: aws.Bool(false)}
+	for _, key := range f.Keys(aws.ToString(in.Bucket)) {
+		if !strings.HasPrefix(key, aws.ToString(in.Prefix)) {
+			continue
+		}
+		o, _ := f.get(in.Bucket, aws.String(key))
+		out.Contents = append(out.Contents, types.Object{
+			Key:  aws.String(key),
+			Size: aws.Int64(int64(len(o.body))),
+			ETag: aws.String(o.etag),
+		})
+	}
+	out.KeyCount = aws.Int32(int32(len(out.Contents)))
+	return out, nil
+}
+
+// CreateMultipartUpload starts a multipart upload.
+func (f *Fake) CreateMultipartUpload(_ context.Context, in *s3.CreateMultipartUploadInput, _ ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error) {
+	if err := f.Errs[aws.ToString(in.Key)]; err != nil {
+		return nil, err
+	}
+	id := f.StartUpload(aws.ToString(in.Bucket), aws.ToString(in.Key), aws.ToString(in.ContentType), in.Metadata)
+	return &s3.CreateMultipartUploadOutput{UploadId: aws.String(id)}, nil
+}
+
+// CompleteMultipartUpload stores the object with a multipart ETag ("<md5>-<parts>") and
+// ends the upload. The fake holds no part bodies, so the object is empty.
+func (f *Fake) CompleteMultipartUpload(_ context.Context, in *s3.CompleteMultipartUploadInput, _ ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error) {
+	if err := f.Errs[aws.ToString(in.Key)]; err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	u, ok := f.uploads[aws.ToString(in.UploadId)]
+	if !ok || u.bucket != aws.ToString(in.Bucket) || u.key != aws.ToString(in.Key) {
+		return nil, &types.NoSuchUpload{}
+	}
+	delete(f.uploads, aws.ToString(in.UploadId))
+	o := f.put(u.bucket, u.key, u.contentType, nil, u.meta)
+	var parts int
+	if in.MultipartUpload != nil {
+		parts = len(in.MultipartUpload.Parts)
+	}
+	o.etag = fmt.Sprintf(`"%s-%d"`, strings.Trim(o.etag, `"`), parts)
+	f.objects[u.bucket+"/"+u.key] = o
+	return &s3.CompleteMultipartUploadOutput{ETag: aws.String(o.etag)}, nil
+}
+
+// AbortMultipartUpload ends an upload; an unknown upload ID is NoSuchUpload, as in S3.
+func (f *Fake) AbortMultipartUpload(_ context.Context, in *s3.AbortMultipartUploadInput, _ ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error) {
+	if err := f.Errs[aws.ToString(in.Key)]; err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.uploads[aws.ToString(in.UploadId)]; !ok {
+		return nil, &types.NoSuchUpload{}
+	}
+	delete(f.uploads, aws.ToString(in.UploadId))
+	return &s3.AbortMultipartUploadOutput{}, nil
+}
+
+// PresignPutObject returns a fake presigned PUT URL.
+func (f *Fake) PresignPutObject(_ context.Context, in *s3.PutObjectInput, _ ...func(*s3.PresignOptions)) (*v4.PresignedHTTPRequest, error) {
+	return &v4.PresignedHTTPRequest{Method: "PUT", URL: fakeURL(in.Bucket, in.Key, "put")}, nil
+}
+
+// PresignGetObject returns a fake presigned GET URL.
+func (f *Fake) PresignGetObject(_ context.Context, in *s3.GetObjectInput, _ ...func(*s3.PresignOptions)) (*v4.PresignedHTTPRequest, error) {
+	return &v4.PresignedHTTPRequest{Method: "GET", URL: fakeURL(in.Bucket, in.Key, "get")}, nil
+}
+
+// PresignPostObject returns a fake form upload target.
+func (f *Fake) PresignPostObject(_ context.Context, in *s3.PutObjectInput, _ ...func(*s3.PresignPostOptions)) (*s3.PresignedPostRequest, error) {
+	return &s3.PresignedPostRequest{URL: fakeURL(in.Bucket, nil, "post"), Values: map[string]string{"key": aws.ToString(in.Key)}}, nil
+}
+
+// PresignUploadPart returns a fake presigned part URL.
+func (f *Fake) PresignUploadPart(_ context.Context, in *s3.UploadPartInput, _ ...func(*s3.PresignOptions)) (*v4.PresignedHTTPRequest, error) {
+	return &v4.PresignedHTTPRequest{Method: "PUT", URL: fakeURL(in.Bucket, in.Key, fmt.Sprintf("part=%d", aws.ToInt32(in.PartNumber)))}, nil
+}
+
+// get returns a stored object, NoSuchKey, or the error injected for its key.
+func (f *Fake) get(bucket, key *string) (fakeObject, error) {
+	if err := f.Errs[aws.ToString(key)]; err != nil {
+		return fakeObject{}, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	o, ok := f.objects[aws.ToString(bucket)+"/"+aws.ToString(key)]
+	if !ok {
+		return fakeObject{}, &types.NoSuchKey{}
+	}
+	return o, nil
+}
+
+// put stores an object; the caller holds mu.
+func (f *Fake) put(bucket, key, contentType string, body []byte, meta map[string]string) fakeObject {
+	if f.objects == nil {
+		f.objects = make(map[string]fakeObject)
+	}
+	sum := md5.Sum(body)
+	o := fakeObject{
+		body:        bytes.Clone(body),
+		etag:        `"` + hex.EncodeToString(sum[:]) + `"`,
+		contentType: contentType,
+		meta:        meta,
+	}
+	f.objects[bucket+"/"+key] = o
+	return o
+}
+
+// fakeURL builds a recognizable URL for a presigned request.
+func fakeURL(bucket, key *string, op string) string {
+	return "https://" + aws.ToString(bucket) + ".s3.fake/" + aws.ToString(key) + "?" + op
+}
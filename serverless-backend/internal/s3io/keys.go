@@ -9,37 +9,87 @@ import (
 // Common S3 key patterns and helper functions.
 const (
 	ContentTypeText = "text/plain"
+	ExtText         = ".txt" // extension of claims created before other types were allowed
 )
 
-// BuildKey constructs the S3 key for a given userID and claimID.
-func BuildKey(userID, claimID string) string {
-	return fmt.Sprintf("user/%s/%s.txt", userID, claimID)
+// BuildKey constructs the S3 key for a claim; ext includes the dot (e.g. ".pdf").
+func BuildKey(userID, claimID, ext string) string {
+	return fmt.Sprintf("user/%s/%s%s", userID, claimID, strings.ToLower(ext))
 }
 
-// ParseKey extracts userID and claimID from the S3 key path.
-func ParseKey(key string) (userID, claimID string, ok bool) {
-	if strings.ToLower(filepath.Ext(key)) != ".txt" {
-		return "", "", false
-	}
+// ParseKey extracts userID, claimID and the lowercased extension from the S3 key path.
+// Whether the extension is an accepted type is left to the caller.
+func ParseKey(key string) (userID, claimID, ext string, ok bool) {
 	parts := strings.Split(key, "/")
 	if len(parts) != 3 || parts[0] != "user" {
-		return "", "", false
+		return "", "", "", false
+	}
+	ext = filepath.Ext(parts[2])
+	claimID = strings.TrimSuffix(parts[2], ext)
+	if ext == "" || claimID == "" || parts[1] == "" {
+		return "", "", "", false
+	}
+	return parts[1], claimID, strings.ToLower(ext), true
+}
+
+// AttachmentKey constructs the S3 key for a claim attachment: user/<sub>/<claim>/<att><ext>.
+func AttachmentKey(userID, claimID, attachmentID, ext string) string {
+	return fmt.Sprintf("user/%s/%s/%s%s", userID, claimID, attachmentID, strings.ToLower(ext))
+}
+
+// ParseAttachmentKey extracts userID, claimID, attachmentID and the lowercased extension
+// from an attachment key.
+func ParseAttachmentKey(key string) (userID, claimID, attachmentID, ext string, ok bool) {
+	parts := strings.Split(key, "/")
+	if len(parts) != 4 || parts[0] != "user" || parts[1] == "" || parts[2] == "" {
+		return "", "", "", "", false
 	}
-	return parts[1], strings.TrimSuffix(parts[2], ".txt"), true
+	ext = filepath.Ext(parts[3])
+	attachmentID = strings.TrimSuffix(parts[3], ext)
+	if ext == "" || attachmentID == "" {
+		return "", "", "", "", false
+	}
+	return parts[1], parts[2], attachmentID, strings.ToLower(ext), true
+}
+
+// QuarantinePrefix holds objects the malware scanner flagged. It sits outside "user/",
+// so the indexer's event notification never fires for it.
+const QuarantinePrefix = "quarantine/"
+
+// QuarantineKey returns where a flagged object is moved: the original key under QuarantinePrefix.
+func QuarantineKey(key string) string {
+	return QuarantinePrefix + key
+}
+
+// RedactedPrefix holds PII-redacted copies of claim letters, mirroring the original keys.
+const RedactedPrefix = "redacted/"
+
+// RedactedKey returns where the redacted copy of key is stored.
+func RedactedKey(key string) string {
+	return RedactedPrefix + key
+}
+
+// SearchPrefix holds the per-user full-text index shards (see internal/search). Like the
+// other derived prefixes it sits outside "user/", so writing a shard fires no event.
+const SearchPrefix = "search/"
+
+// SearchKey returns where userID's search shard is stored.
+func SearchKey(userID string) string {
+	return SearchPrefix + userID + ".json"
 }
 
 // UploadHeaders builds the required headers for uploading to S3.
-// Headers the client must send on PUT (matches your bucket/WAF rules).
-func UploadHeaders(userID, claimID, contentType string, tagsCSV, client string) map[string]string {
+// Headers the client must send on PUT: they must match what PresignPut signed.
+func UploadHeaders(contentType string, meta map[string]string) map[string]string {
 	if contentType == "" {
 		contentType = ContentTypeText
 	}
-	return map[string]string{
+	h := map[string]string{
 		"Content-Type":                 contentType,
 		"x-amz-server-side-encryption": "aws:kms",
-		"x-amz-meta-claim_id":          claimID,
-		"x-amz-meta-user_id":           userID,
-		"x-amz-meta-tags":              tagsCSV,
-		"x-amz-meta-client":            client,
 	}
+	for k, v := range meta {
+		h["x-amz-meta-"+k] = v
+	}
+	return h
 }
@@ -0,0 +1,120 @@
+package s3io
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// S3 multipart limits.
+const (
+	MinPartSize = 5 << 20 // every part but the last must be at least 5 MiB
+	MaxParts    = 10000
+)
+
+// MultipartAPI defines the S3 calls used to drive a multipart upload.
+type MultipartAPI interface {
+	CreateMultipartUpload(ctx context.Context, params *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error)
+	CompleteMultipartUpload(ctx context.Context, params *s3.CompleteMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error)
+	AbortMultipartUpload(ctx context.Context, params *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error)
+}
+
+// PartPresigner defines the interface for presigning multipart part uploads.
+type PartPresigner interface {
+	PresignUploadPart(ctx context.Context, params *s3.UploadPartInput, optFns ...func(*s3.PresignOptions)) (*v4.PresignedHTTPRequest, error)
+}
+
+// Part identifies an uploaded part by number and the ETag S3 returned for it.
+type Part struct {
+	Number int32  `json:"part_number"`
+	ETag   string `json:"etag"`
+}
+
+// PartURL is a presigned URL for uploading one part.
+type PartURL struct {
+	Number int32  `json:"part_number"`
+	URL    string `json:"url"`
+}
+
+// PartCount returns how many parts of partSize are needed for size bytes.
+func PartCount(size, partSize int64) int32 {
+	if size <= 0 || partSize <= 0 {
+		return 0
+	}
+	return int32((size + partSize - 1) / partSize)
+}
+
+// CreateMultipart starts a multipart upload with the same encryption and metadata as PresignPut.
+func CreateMultipart(ctx context.Context, c MultipartAPI, bucket, key, contentType string, meta map[string]string) (string, error) {
+	out, err := c.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:               aws.String(bucket),
+		Key:                  aws.String(key),
+		ContentType:          aws.String(contentType),
+		Metadata:             meta,
+		ServerSideEncryption: types.ServerSideEncryptionAwsKms,
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(out.UploadId), nil
+}
+
+// PresignParts returns one presigned PUT URL per part number 1..count.
+func PresignParts(ctx context.Context, p PartPresigner, bucket, key, uploadID string, count int32, ttl time.Duration) ([]PartURL, error) {
+	urls := make([]PartURL, 0, count)
+	for n := int32(1); n <= count; n++ {
+		req, err := p.PresignUploadPart(ctx, &s3.UploadPartInput{
+			Bucket:     aws.String(bucket),
+			Key:        aws.String(key),
+			UploadId:   aws.String(uploadID),
+			PartNumber: aws.Int32(n),
+		}, func(o *s3.PresignOptions) { o.Expires = ttl })
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, PartURL{Number: n, URL: req.URL})
+	}
+	return urls, nil
+}
+
+// CompleteMultipart assembles the uploaded parts. S3 then emits ObjectCreated:CompleteMultipartUpload.
+func CompleteMultipart(ctx context.Context, c MultipartAPI, bucket, key, uploadID string, parts []Part) error {
+	sorted := append([]Part(nil), parts...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Number < sorted[j].Number })
+
+	completed := make([]types.CompletedPart, 0, len(sorted))
+	for _, p := range sorted {
+		completed = append(completed, types.CompletedPart{
+			PartNumber: aws.Int32(p.Number),
+			ETag:       aws.String(`"` + strings.Trim(p.ETag, `"`) + `"`),
+		})
+	}
+	_, err := c.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(bucket),
+		Key:             aws.String(key),
+		UploadId:        aws.String(uploadID),
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: completed},
+	})
+	return err
+}
+
+// AbortMultipart discards an in-progress multipart upload and its parts.
+func AbortMultipart(ctx context.Context, c MultipartAPI, bucket, key, uploadID string) error {
+	_, err := c.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(bucket),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+	})
+	return err
+}
+
+// IsMultipartETag reports whether an ETag came from a multipart upload ("<md5>-<parts>").
+func IsMultipartETag(etag string) bool {
+	return strings.Contains(strings.Trim(etag, `"`), "-")
+}
@@ -3,6 +3,10 @@ package s3io
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -16,6 +20,23 @@ type Presigner interface {
 	PresignPutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.PresignOptions)) (*v4.PresignedHTTPRequest, error)
 }
 
+// GetPresigner defines the interface for presigning S3 GET requests.
+type GetPresigner interface {
+	PresignGetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.PresignOptions)) (*v4.PresignedHTTPRequest, error)
+}
+
+// PostPresigner defines the interface for presigning S3 POST (browser form upload) requests.
+type PostPresigner interface {
+	PresignPostObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.PresignPostOptions)) (*s3.PresignedPostRequest, error)
+}
+
+// PresignedPost is a form upload target: POST Fields as multipart form fields to URL,
+// with the file itself as the last field named "file".
+type PresignedPost struct {
+	URL    string
+	Fields map[string]string
+}
+
 // PresignPut generates a presigned URL for uploading an object to S3 with the specified parameters.
 func PresignPut(ctx context.Context, p Presigner, bucket, key, contentType string, meta map[string]string, ttl time.Duration) (string, time.Duration, error) {
 	input := &s3.PutObjectInput{
@@ -35,3 +56,189 @@ func PresignPut(ctx context.Context, p Presigner, bucket, key, contentType strin
 	}
 	return req.URL, ttl, nil
 }
+
+// PresignPost generates a presigned POST policy for key. Unlike PresignPut, S3 enforces the
+// policy server-side: the body must be 1..maxBytes, Content-Type must equal contentType, and
+// every metadata entry must be sent exactly as x-amz-meta-<k>.
+func PresignPost(ctx context.Context, p PostPresigner, bucket, key, contentType string, meta map[string]string, maxBytes int64, ttl time.Duration) (PresignedPost, time.Duration, error) {
+	fields := map[string]string{
+		"Content-Type":                 contentType,
+		"x-amz-server-side-encryption": string(types.ServerSideEncryptionAwsKms),
+	}
+	for k, v := range meta {
+		fields["x-amz-meta-"+k] = v
+	}
+
+	conds := []interface{}{
+		[]interface{}{"content-length-range", 1, maxBytes},
+	}
+	for k, v := range fields {
+		conds = append(conds, map[string]string{k: v})
+	}
+
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	req, err := p.PresignPostObject(ctx, input, func(o *s3.PresignPostOptions) {
+		o.Expires = ttl
+		o.Conditions = conds
+	})
+	if err != nil {
+		return PresignedPost{}, 0, err
+	}
+
+	// The SDK returns only the signing fields; the client must also echo our exact-match fields.
+	for k, v := range req.Values {
+		fields[k] = v
+	}
+	return PresignedPost{URL: req.URL, Fields: fields}, ttl, nil
+}
+
+// PresignGet generates a presigned URL for downloading an object, forcing a
+// Content-Disposition attachment with the given filename.
+func PresignGet(ctx context.Context, p GetPresigner, bucket, key, filename string, ttl time.Duration) (string, time.Duration, error) {
+	input := &s3.GetObjectInput{
+		Bucket:                     aws.String(bucket),
+		Key:                        aws.String(key),
+		ResponseContentDisposition: aws.String(ContentDisposition(filename)),
+	}
+
+	req, err := p.PresignGetObject(ctx, input, func(o *s3.PresignOptions) { o.Expires = ttl })
+	if err != nil {
+		return "", 0, err
+	}
+	return req.URL, ttl, nil
+}
+
+// ContentDisposition builds an attachment header with an ASCII fallback name
+// and an RFC 5987 filename* for non-ASCII names.
+func ContentDisposition(filename string) string {
+	ascii := strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e || r == '"' || r == '\\' {
+			return '_'
+		}
+		return r
+	}, filename)
+	if ascii == "" {
+		ascii = "download"
+	}
+	return fmt.Sprintf(`attachment; filename="%s"; filename*=UTF-8''%s`, ascii, url.PathEscape(filename))
+}
+
+// Deleter defines the interface for deleting S3 objects.
+type Deleter interface {
+	DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
+}
+
+// Delete removes an object. S3 treats deleting a missing key as success, so this is safe to retry.
+func Delete(ctx context.Context, d Deleter, bucket, key string) error {
+	_, err := d.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	return err
+}
+
+// Getter defines the interface for reading S3 objects.
+type Getter interface {
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+// OpenRange streams at most maxBytes from the start of an object using a ranged GET.
+// The caller must close the returned reader.
+func OpenRange(ctx context.Context, g Getter, bucket, key string, maxBytes int64) (io.ReadCloser, error) {
+	out, err := g.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Range:  aws.String(fmt.Sprintf("bytes=0-%d", maxBytes-1)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Body, nil
+}
+
+// Copier defines the interface for copying and deleting S3 objects.
+type Copier interface {
+	Deleter
+	CopyObject(ctx context.Context, params *s3.CopyObjectInput, optFns ...func(*s3.Options)) (*s3.CopyObjectOutput, error)
+}
+
+// Move copies src to dst within bucket, keeping metadata and KMS encryption, then deletes src.
+// If the delete fails, both copies exist; retrying the move is safe.
+func Move(ctx context.Context, c Copier, bucket, src, dst string) error {
+	_, err := c.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:               aws.String(bucket),
+		Key:                  aws.String(dst),
+		CopySource:           aws.String(url.PathEscape(bucket) + "/" + escapeKey(src)),
+		ServerSideEncryption: types.ServerSideEncryptionAwsKms,
+	})
+	if err != nil {
+		return err
+	}
+	return Delete(ctx, c, bucket, src)
+}
+
+// escapeKey URL-encodes each segment of an object key for use in CopySource.
+func escapeKey(key string) string {
+	segs := strings.Split(key, "/")
+	for i, s := range segs {
+		segs[i] = url.PathEscape(s)
+	}
+	return strings.Join(segs, "/")
+}
+
+// Putter defines the interface for writing S3 objects.
+type Putter interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+}
+
+// PutText writes a small plain-text object with the same KMS encryption as uploads.
+func PutText(ctx context.Context, p Putter, bucket, key, body string) error {
+	_, err := p.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:               aws.String(bucket),
+		Key:                  aws.String(key),
+		Body:                 strings.NewReader(body),
+		ContentType:          aws.String(ContentTypeText + "; charset=utf-8"),
+		ServerSideEncryption: types.ServerSideEncryptionAwsKms,
+	})
+	return err
+}
+
+// Lister defines the interface for listing S3 objects.
+type Lister interface {
+	ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error)
+}
+
+// Object is one entry of a bucket listing.
+type Object struct {
+	Key  string
+	Size int64
+	ETag string // without quotes, like HeadObject's as the indexer stores it
+}
+
+// ListObjects calls fn for every object under prefix, page by page. It stops at the
+// first error fn returns.
+func ListObjects(ctx context.Context, l Lister, bucket, prefix string, fn func(Object) error) error {
+	p := s3.NewListObjectsV2Paginator(l, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	})
+	for p.HasMorePages() {
+		out, err := p.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		for _, o := range out.Contents {
+			obj := Object{Key: aws.ToString(o.Key), ETag: strings.Trim(aws.ToString(o.ETag), "\"")}
+			if o.Size != nil {
+				obj.Size = *o.Size
+			}
+			if err := fn(obj); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
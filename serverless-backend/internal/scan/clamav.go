@@ -0,0 +1,104 @@
+package scan
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// chunkSize is the INSTREAM chunk length; clamd accepts chunks up to its StreamMaxLength.
+const chunkSize = 64 << 10
+
+// ErrTooLarge is returned when clamd refuses a stream longer than its StreamMaxLength.
+// Unlike other scanner errors it is permanent: resending the same object fails the same way.
+var ErrTooLarge = errors.New("clamd: stream exceeds StreamMaxLength")
+
+// ClamAV scans streams with a clamd daemon using the INSTREAM command over TCP.
+type ClamAV struct {
+	Addr    string        // host:port of clamd
+	Timeout time.Duration // whole-scan deadline; <= 0 means the context deadline only
+}
+
+// Scan streams r to clamd and parses its single-line reply:
+// "stream: OK", "stream: <signature> FOUND" or "<message> ERROR".
+func (c *ClamAV) Scan(ctx context.Context, r io.Reader) (Result, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", c.Addr)
+	if err != nil {
+		return Result{}, fmt.Errorf("clamd dial: %w", err)
+	}
+	defer conn.Close()
+
+	if dl, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(dl)
+	}
+	if c.Timeout > 0 {
+		dl := time.Now().Add(c.Timeout)
+		if cur, ok := ctx.Deadline(); !ok || dl.Before(cur) {
+			_ = conn.SetDeadline(dl)
+		}
+	}
+
+	if err := sendStream(conn, r); err != nil {
+		return Result{}, err
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && reply == "" {
+		return Result{}, fmt.Errorf("clamd read: %w", err)
+	}
+	return parseReply(reply)
+}
+
+// sendStream writes the INSTREAM command, r as length-prefixed chunks, and the zero-length terminator.
+func sendStream(w io.Writer, r io.Reader) error {
+	if _, err := io.WriteString(w, "zINSTREAM\x00"); err != nil {
+		return fmt.Errorf("clamd write: %w", err)
+	}
+	buf := make([]byte, chunkSize)
+	var size [4]byte
+	for {
+		n, rerr := r.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size[:], uint32(n))
+			if _, err := w.Write(size[:]); err != nil {
+				return fmt.Errorf("clamd write: %w", err)
+			}
+			if _, err := w.Write(buf[:n]); err != nil {
+				return fmt.Errorf("clamd write: %w", err)
+			}
+		}
+		if rerr == io.EOF {
+			break
+		}
+		if rerr != nil {
+			return fmt.Errorf("read object: %w", rerr)
+		}
+	}
+	binary.BigEndian.PutUint32(size[:], 0)
+	if _, err := w.Write(size[:]); err != nil {
+		return fmt.Errorf("clamd write: %w", err)
+	}
+	return nil
+}
+
+// parseReply turns a clamd INSTREAM reply into a Result.
+func parseReply(reply string) (Result, error) {
+	reply = strings.TrimSpace(strings.TrimRight(reply, "\x00"))
+	msg := strings.TrimSpace(strings.TrimPrefix(reply, "stream:"))
+	switch {
+	case msg == "OK":
+		return Result{}, nil
+	case strings.HasSuffix(msg, " FOUND"):
+		return Result{Infected: true, Signature: strings.TrimSuffix(msg, " FOUND")}, nil
+	case strings.HasPrefix(msg, "INSTREAM size limit exceeded"):
+		return Result{}, fmt.Errorf("%w: %s", ErrTooLarge, reply)
+	}
+	return Result{}, fmt.Errorf("clamd: %s", reply)
+}
@@ -0,0 +1,197 @@
+package scan
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClamd accepts one INSTREAM connection, decodes its chunks, and answers with reply.
+// It returns the listener's address and a channel delivering the chunk lengths and the
+// reassembled stream once the terminator arrives.
+func fakeClamd(t *testing.T, reply string) (string, <-chan clamdRequest) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	got := make(chan clamdRequest, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		req := readInstream(conn)
+		got <- req
+		if req.err == nil {
+			io.WriteString(conn, reply+"\x00")
+		}
+	}()
+	return ln.Addr().String(), got
+}
+
+// clamdRequest is what fakeClamd received.
+type clamdRequest struct {
+	chunks []int // length of each chunk, excluding the zero terminator
+	body   []byte
+	err    error
+}
+
+// readInstream reads a "zINSTREAM\x00" command and its length-prefixed chunks up to the
+// zero-length terminator.
+func readInstream(r io.Reader) clamdRequest {
+	cmd := make([]byte, len("zINSTREAM\x00"))
+	if _, err := io.ReadFull(r, cmd); err != nil {
+		return clamdRequest{err: err}
+	}
+	if string(cmd) != "zINSTREAM\x00" {
+		return clamdRequest{err: errors.New("bad command " + string(cmd))}
+	}
+	var req clamdRequest
+	var size [4]byte
+	for {
+		if _, err := io.ReadFull(r, size[:]); err != nil {
+			req.err = err
+			return req
+		}
+		n := binary.BigEndian.Uint32(size[:])
+		if n == 0 {
+			return req
+		}
+		buf := make([]byte, n)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			req.err = err
+			return req
+		}
+		req.chunks = append(req.chunks, int(n))
+		req.body = append(req.body, buf...)
+	}
+}
+
+func TestClamAVStreamsChunks(t *testing.T) {
+	tests := []struct {
+		name   string
+		size   int
+		chunks []int
+	}{
+		{"empty", 0, nil},
+		{"one chunk", 100, []int{100}},
+		{"exact chunk", chunkSize, []int{chunkSize}},
+		{"several chunks", 2*chunkSize + 10, []int{chunkSize, chunkSize, 10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, got := fakeClamd(t, "stream: OK")
+			body := bytes.Repeat([]byte("a"), tt.size)
+			c := &ClamAV{Addr: addr, Timeout: 5 * time.Second}
+
+			res, err := c.Scan(context.Background(), bytes.NewReader(body))
+			if err != nil {
+				t.Fatalf("Scan: %v", err)
+			}
+			if res.Infected {
+				t.Errorf("result = %+v, want clean", res)
+			}
+			req := <-got
+			if req.err != nil {
+				t.Fatalf("clamd read: %v", req.err)
+			}
+			if !slices.Equal(req.chunks, tt.chunks) {
+				t.Errorf("chunks = %v, want %v", req.chunks, tt.chunks)
+			}
+			if !bytes.Equal(req.body, body) {
+				t.Errorf("clamd got %d bytes, want %d", len(req.body), len(body))
+			}
+		})
+	}
+}
+
+func TestClamAVReplies(t *testing.T) {
+	tests := []struct {
+		reply   string
+		want    Result
+		tooBig  bool
+		wantErr bool
+	}{
+		{reply: "stream: OK", want: Result{}},
+		{reply: "stream: Eicar-Test-Signature FOUND", want: Result{Infected: true, Signature: "Eicar-Test-Signature"}},
+		{reply: "INSTREAM size limit exceeded. ERROR", tooBig: true, wantErr: true},
+		{reply: "Can't allocate memory ERROR", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.reply, func(t *testing.T) {
+			addr, _ := fakeClamd(t, tt.reply)
+			c := &ClamAV{Addr: addr, Timeout: 5 * time.Second}
+
+			res, err := c.Scan(context.Background(), strings.NewReader("Dear Claims Adjuster"))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrTooLarge) != tt.tooBig {
+				t.Errorf("errors.Is(%v, ErrTooLarge) = %v, want %v", err, !tt.tooBig, tt.tooBig)
+			}
+			if res != tt.want {
+				t.Errorf("result = %+v, want %+v", res, tt.want)
+			}
+		})
+	}
+}
+
+func TestClamAVDialError(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close() // nothing listens here now
+
+	c := &ClamAV{Addr: addr, Timeout: time.Second}
+	if _, err := c.Scan(context.Background(), strings.NewReader("x")); err == nil || errors.Is(err, ErrTooLarge) {
+		t.Errorf("err = %v, want a transient dial error", err)
+	}
+}
+
+func TestParseReply(t *testing.T) {
+	tests := []struct {
+		reply   string
+		want    Result
+		wantErr error // nil: no error; errAny: any error
+	}{
+		{"stream: OK\x00", Result{}, nil},
+		{"stream: OK\n", Result{}, nil},
+		{"stream: Win.Test.EICAR_HDB-1 FOUND\x00", Result{Infected: true, Signature: "Win.Test.EICAR_HDB-1"}, nil},
+		{"stream: Multi Word Sig FOUND", Result{Infected: true, Signature: "Multi Word Sig"}, nil},
+		{"INSTREAM size limit exceeded. ERROR\x00", Result{}, ErrTooLarge},
+		{"stream: lstat() failed ERROR", Result{}, errAny},
+		{"", Result{}, errAny},
+	}
+	for _, tt := range tests {
+		t.Run(strings.TrimRight(tt.reply, "\x00\n"), func(t *testing.T) {
+			got, err := parseReply(tt.reply)
+			switch {
+			case tt.wantErr == nil && err != nil:
+				t.Fatalf("err = %v, want nil", err)
+			case tt.wantErr == errAny && (err == nil || errors.Is(err, ErrTooLarge)):
+				t.Fatalf("err = %v, want a transient error", err)
+			case tt.wantErr == ErrTooLarge && !errors.Is(err, ErrTooLarge):
+				t.Fatalf("err = %v, want ErrTooLarge", err)
+			}
+			if got != tt.want {
+				t.Errorf("result = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// errAny stands for "some error other than ErrTooLarge" in TestParseReply.
+var errAny = errors.New("any error")
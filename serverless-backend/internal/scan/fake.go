@@ -0,0 +1,54 @@
+package scan
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+)
+
+// EICAR is the standard antivirus test string; every real scanner flags it.
+const EICAR = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// Fake is an in-memory Scanner for tests. A stream is infected if it contains any key
+// of Signatures; nil Signatures flags only EICAR. Err, when set, is returned instead.
+type Fake struct {
+	Signatures map[string]string // content substring -> signature name
+	Err        error
+
+	mu    sync.Mutex
+	calls int
+}
+
+// Scan reads r fully and matches it against the configured signatures.
+func (f *Fake) Scan(_ context.Context, r io.Reader) (Result, error) {
+	f.mu.Lock()
+	f.calls++
+	f.mu.Unlock()
+
+	if f.Err != nil {
+		return Result{}, f.Err
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return Result{}, err
+	}
+
+	sigs := f.Signatures
+	if sigs == nil {
+		sigs = map[string]string{EICAR: "Eicar-Test-Signature"}
+	}
+	for pat, name := range sigs {
+		if bytes.Contains(b, []byte(pat)) {
+			return Result{Infected: true, Signature: name}, nil
+		}
+	}
+	return Result{}, nil
+}
+
+// Calls reports how many times Scan has been called.
+func (f *Fake) Calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
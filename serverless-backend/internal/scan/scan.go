@@ -0,0 +1,42 @@
+// Package scan checks uploaded documents for malware before they are released to adjusters.
+package scan
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Scanner backends selectable with SCANNER.
+const (
+	BackendClamAV = "clamav" // clamd INSTREAM over TCP
+	BackendNone   = "none"   // no scanning; local development only
+)
+
+// Result is the verdict for one scanned stream.
+type Result struct {
+	Infected  bool
+	Signature string // name of the matched signature when Infected
+}
+
+// Scanner scans a stream of bytes. An error means no verdict was reached; callers
+// must not treat the content as clean.
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) (Result, error)
+}
+
+// New returns the Scanner for backend. addr is the clamd host:port for BackendClamAV.
+// BackendNone returns nil: the caller skips the scanning stage.
+func New(backend, addr string, timeout time.Duration) (Scanner, error) {
+	switch backend {
+	case BackendClamAV:
+		if addr == "" {
+			return nil, fmt.Errorf("CLAMAV_ADDR is required when SCANNER=%s", BackendClamAV)
+		}
+		return &ClamAV{Addr: addr, Timeout: timeout}, nil
+	case BackendNone:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unknown SCANNER %q", backend)
+}
@@ -0,0 +1,80 @@
+package search
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// NewDir returns a Store keeping shards as <userID>.json files under root, which is
+// created on first write. Writes are serialized within the process only; do not share
+// root between processes that write.
+func NewDir(root string) *Store {
+	return &Store{blobs: &dirBlobs{root: root}}
+}
+
+// dirBlobs keeps shards as files; the tag is a hash of the file's contents.
+type dirBlobs struct {
+	root string
+	mu   sync.Mutex
+}
+
+func (b *dirBlobs) get(_ context.Context, userID string) ([]byte, string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.read(userID)
+}
+
+// read returns the shard file and its tag; the caller holds mu. An empty file (left by a
+// crash or a truncating tool) counts as no shard, so the next write replaces it.
+func (b *dirBlobs) read(userID string) ([]byte, string, error) {
+	data, err := os.ReadFile(b.path(userID))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) == 0 {
+		return nil, "", nil
+	}
+	sum := sha256.Sum256(data)
+	return data, hex.EncodeToString(sum[:]), nil
+}
+
+func (b *dirBlobs) put(_ context.Context, userID string, data []byte, tag string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, cur, err := b.read(userID); err != nil {
+		return err
+	} else if cur != tag {
+		return errStale
+	}
+	if err := os.MkdirAll(b.root, 0o700); err != nil {
+		return err
+	}
+	// Write then rename, so a reader never sees half a shard.
+	tmp, err := os.CreateTemp(b.root, ".shard-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), b.path(userID))
+}
+
+// path is the shard file for userID.
+func (b *dirBlobs) path(userID string) string {
+	return filepath.Join(b.root, userID+".json")
+}
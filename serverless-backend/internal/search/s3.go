@@ -0,0 +1,77 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/s3io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// S3API is the part of the S3 client the S3 backend uses.
+type S3API interface {
+	s3io.Getter
+	s3io.Putter
+}
+
+// NewS3 returns a Store keeping shards at s3io.SearchKey in bucket. Writes are conditional
+// on the ETag read (or on the key being absent), so concurrent indexers never lose updates.
+func NewS3(c S3API, bucket string) *Store {
+	return &Store{blobs: s3Blobs{c: c, bucket: bucket}}
+}
+
+// s3Blobs keeps shards as S3 objects; the tag is the object's ETag.
+type s3Blobs struct {
+	c      S3API
+	bucket string
+}
+
+func (b s3Blobs) get(ctx context.Context, userID string) ([]byte, string, error) {
+	out, err := b.c.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(s3io.SearchKey(userID)),
+	})
+	var nsk *types.NoSuchKey
+	if errors.As(err, &nsk) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	defer out.Body.Close()
+	data, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, aws.ToString(out.ETag), nil
+}
+
+func (b s3Blobs) put(ctx context.Context, userID string, data []byte, tag string) error {
+	in := &s3.PutObjectInput{
+		Bucket:               aws.String(b.bucket),
+		Key:                  aws.String(s3io.SearchKey(userID)),
+		Body:                 bytes.NewReader(data),
+		ContentType:          aws.String("application/json"),
+		ServerSideEncryption: types.ServerSideEncryptionAwsKms, // shards hold letter text
+	}
+	if tag == "" {
+		in.IfNoneMatch = aws.String("*")
+	} else {
+		in.IfMatch = aws.String(tag)
+	}
+	_, err := b.c.PutObject(ctx, in)
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "PreconditionFailed", "ConditionalRequestConflict":
+			return errStale
+		}
+	}
+	return err
+}
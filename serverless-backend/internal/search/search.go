@@ -0,0 +1,68 @@
+// Package search is a full-text index over claim letters. The indexer adds each letter's
+// text once the claim is finalized, DELETE /claims/{id} removes it, and GET /claims/search
+// queries it.
+//
+// The index is sharded by user: each claimant's letters live in one inverted index, stored
+// as a single object and loaded whole to answer a query. A query can only ever read the
+// shard of the user it is scoped to, so search inherits the partition-per-user access rules
+// of the claims table. Shards are small (one claimant's letters), which is what makes
+// load-modify-save per update affordable.
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Index backends selectable with SEARCH_BACKEND.
+const (
+	BackendS3   = "s3"   // shards under s3io.SearchPrefix in the claims bucket
+	BackendDir  = "dir"  // shards as files under SEARCH_DIR; local development and offline runs
+	BackendNone = "none" // no search; letters are not indexed and search is unavailable
+)
+
+// Doc is the text of one claim, as added to its owner's shard.
+type Doc struct {
+	UserID  string
+	ClaimID string
+	Text    string
+}
+
+// Hit is a claim matching a query.
+type Hit struct {
+	ClaimID string
+	Score   float64 // BM25; only meaningful relative to other hits of the same query
+}
+
+// ErrEmptyQuery is returned by Search for a query with no searchable terms.
+var ErrEmptyQuery = errors.New("query has no searchable terms")
+
+// Index adds, removes and finds claim text. Every operation is scoped to one user's shard.
+type Index interface {
+	// Add indexes d, replacing any earlier text for the same claim.
+	Add(ctx context.Context, d Doc) error
+	// Remove drops a claim from its owner's shard; removing an unknown claim is a no-op.
+	Remove(ctx context.Context, userID, claimID string) error
+	// Search returns up to limit of userID's claims containing every term of query,
+	// best match first.
+	Search(ctx context.Context, userID, query string, limit int) ([]Hit, error)
+}
+
+// New returns the Index for backend: dir is the root directory for BackendDir, c and
+// bucket the S3 client and bucket for BackendS3. BackendNone returns nil: callers skip
+// indexing and report search as unavailable.
+func New(backend, dir string, c S3API, bucket string) (Index, error) {
+	switch backend {
+	case BackendS3:
+		return NewS3(c, bucket), nil
+	case BackendDir:
+		if dir == "" {
+			return nil, fmt.Errorf("SEARCH_DIR is required when SEARCH_BACKEND=%s", BackendDir)
+		}
+		return NewDir(dir), nil
+	case BackendNone:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unknown SEARCH_BACKEND %q", backend)
+}
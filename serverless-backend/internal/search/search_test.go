@@ -0,0 +1,238 @@
+package search
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/s3io"
+)
+
+// corpus is the repository's sample claim letters.
+const corpus = "../../../data"
+
+func TestTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Austin, TX", []string{"austin", "tx"}},
+		{"Re: Claim for Rear-End Collision – Policy #A12345", []string{"re", "claim", "rear", "end", "collision", "policy", "a12345"}},
+		{"I was stopped at a red light", []string{"stopped", "red", "light"}},
+		{"Main St. and 3rd Ave", []string{"main", "st", "3rd", "ave"}},
+		{"unit 4 of 5", []string{"unit", "4", "5"}},
+		{"Café crème", []string{"café", "crème"}},
+		{"john.smith@example.com | (512) 555-1234", []string{"john", "smith", "example", "com", "512", "555", "1234"}},
+		{strings.Repeat("x", maxTokenLen+1) + " ok", []string{"ok"}},
+		{"the and of", nil},
+		{"", nil},
+	}
+	for _, tc := range tests {
+		if got := Tokens(tc.text); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Tokens(%q) = %q, want %q", tc.text, got, tc.want)
+		}
+	}
+}
+
+// indexCorpus adds every letter under data/ to u-1's shard in a fresh directory index,
+// with the file's base name as its claim ID.
+func indexCorpus(t *testing.T) *Store {
+	t.Helper()
+	letters, err := filepath.Glob(filepath.Join(corpus, "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(letters) == 0 {
+		t.Fatalf("no letters in %s", corpus)
+	}
+	idx := NewDir(t.TempDir())
+	for _, path := range letters {
+		text, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		id := strings.TrimSuffix(filepath.Base(path), ".txt")
+		if err := idx.Add(context.Background(), Doc{UserID: "u-1", ClaimID: id, Text: string(text)}); err != nil {
+			t.Fatalf("Add(%s): %v", id, err)
+		}
+	}
+	return idx
+}
+
+// ids returns the claim IDs of hits, in order.
+func ids(hits []Hit) []string {
+	out := []string{}
+	for _, h := range hits {
+		out = append(out, h.ClaimID)
+	}
+	return out
+}
+
+func TestSearchCorpus(t *testing.T) {
+	idx := indexCorpus(t)
+	tests := []struct {
+		query string
+		want  []string // sorted
+	}{
+		{"Austin, TX", []string{"auto_accident_john_smith"}},
+		{"policy A12345", []string{"auto_accident_john_smith"}},
+		{"a12345", []string{"auto_accident_john_smith"}},
+		{"water", []string{"fire_damage_robert_thomas", "property_damage_karen_lee"}},
+		{"police", []string{"auto_accident_john_smith", "car_theft_angelica_moore"}},
+		{"police whiplash", []string{"auto_accident_john_smith"}}, // every term must match
+		{"claim", []string{"auto_accident_john_smith", "bike_accident_lisa_nguyen", "car_theft_angelica_moore", "injury_at_work_michael_brown", "property_damage_karen_lee"}},
+		{"austin spaceship", []string{}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.query, func(t *testing.T) {
+			hits, err := idx.Search(context.Background(), "u-1", tc.query, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := ids(hits)
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Search(%q) = %v, want %v", tc.query, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSearchRanking(t *testing.T) {
+	idx := indexCorpus(t)
+	ctx := context.Background()
+
+	// "claim" appears twice in these two letters and once in the other three.
+	hits, err := idx.Search(ctx, "u-1", "claim", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 5 {
+		t.Fatalf("hits = %v, want 5", ids(hits))
+	}
+	top := ids(hits[:2])
+	sort.Strings(top)
+	if want := []string{"auto_accident_john_smith", "car_theft_angelica_moore"}; !reflect.DeepEqual(top, want) {
+		t.Errorf("top hits = %v, want %v", top, want)
+	}
+	for i := 1; i < len(hits); i++ {
+		if hits[i].Score > hits[i-1].Score {
+			t.Errorf("hits not sorted by score: %+v", hits)
+		}
+	}
+
+	// limit keeps the best hits.
+	limited, err := idx.Search(ctx, "u-1", "claim", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ids(limited), ids(hits[:2])) {
+		t.Errorf("limit 2 = %v, want %v", ids(limited), ids(hits[:2]))
+	}
+}
+
+func TestSearchScopedToUser(t *testing.T) {
+	idx := indexCorpus(t)
+	hits, err := idx.Search(context.Background(), "u-2", "Austin", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 0 {
+		t.Errorf("u-2 found u-1's letters: %v", ids(hits))
+	}
+	if _, err := idx.Search(context.Background(), "../u-1", "Austin", 10); err == nil {
+		t.Error("a user ID naming another directory was accepted")
+	}
+	if _, err := idx.Search(context.Background(), "u-1", "the of", 10); err != ErrEmptyQuery {
+		t.Errorf("stopword-only query: err = %v, want ErrEmptyQuery", err)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	idx := indexCorpus(t)
+	ctx := context.Background()
+
+	if err := idx.Remove(ctx, "u-1", "auto_accident_john_smith"); err != nil {
+		t.Fatal(err)
+	}
+	hits, err := idx.Search(ctx, "u-1", "Austin", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 0 {
+		t.Errorf("removed letter still found: %v", ids(hits))
+	}
+	hits, err = idx.Search(ctx, "u-1", "police", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(hits); len(got) != 1 || got[0] != "car_theft_angelica_moore" {
+		t.Errorf("police = %v, want only the other letter", got)
+	}
+
+	// Removing again, or something never indexed, is a no-op.
+	for _, id := range []string{"auto_accident_john_smith", "never-indexed"} {
+		if err := idx.Remove(ctx, "u-1", id); err != nil {
+			t.Errorf("Remove(%s): %v", id, err)
+		}
+	}
+	if err := idx.Remove(ctx, "u-9", "c-1"); err != nil {
+		t.Errorf("Remove from a user with no shard: %v", err)
+	}
+}
+
+func TestEmptyShardFile(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "u-1.json"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	idx := NewDir(root)
+
+	hits, err := idx.Search(ctx, "u-1", "austin", 10)
+	if err != nil || len(hits) != 0 {
+		t.Fatalf("Search over an empty shard = %v, %v; want no hits", hits, err)
+	}
+	if err := idx.Add(ctx, Doc{UserID: "u-1", ClaimID: "c-1", Text: "Austin, TX"}); err != nil {
+		t.Fatalf("Add over an empty shard: %v", err)
+	}
+	if hits, err = idx.Search(ctx, "u-1", "austin", 10); err != nil || len(hits) != 1 {
+		t.Errorf("Search after Add = %v, %v; want c-1", hits, err)
+	}
+}
+
+func TestS3Backend(t *testing.T) {
+	ctx := context.Background()
+	objects := &s3io.Fake{}
+	idx := NewS3(objects, "claims-bucket")
+
+	// An empty object at the shard key is an empty shard, and the first Add replaces it.
+	objects.Put("claims-bucket", s3io.SearchKey("u-1"), "application/json", nil, nil)
+	for _, d := range []Doc{
+		{UserID: "u-1", ClaimID: "c-1", Text: "rear-end collision in Austin, TX"},
+		{UserID: "u-1", ClaimID: "c-2", Text: "burst pipe in Dallas, TX"},
+	} {
+		if err := idx.Add(ctx, d); err != nil {
+			t.Fatalf("Add(%s): %v", d.ClaimID, err)
+		}
+	}
+	if err := idx.Remove(ctx, "u-1", "c-2"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A second Store over the same bucket sees the writes.
+	hits, err := NewS3(objects, "claims-bucket").Search(ctx, "u-1", "tx", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(hits); len(got) != 1 || got[0] != "c-1" {
+		t.Errorf("hits = %v, want [c-1]", got)
+	}
+	if keys := objects.Keys("claims-bucket"); len(keys) != 1 || keys[0] != s3io.SearchKey("u-1") {
+		t.Errorf("bucket keys = %v, want only u-1's shard", keys)
+	}
+}
@@ -0,0 +1,115 @@
+package search
+
+import (
+	"math"
+	"sort"
+)
+
+// BM25 parameters: term frequency saturation and document length normalization.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// shard is one user's inverted index, stored as JSON.
+type shard struct {
+	Docs     map[string]int            `json:"docs"`     // claim ID -> number of terms
+	Postings map[string]map[string]int `json:"postings"` // term -> claim ID -> occurrences
+}
+
+// newShard returns an empty shard.
+func newShard() *shard {
+	return &shard{Docs: map[string]int{}, Postings: map[string]map[string]int{}}
+}
+
+// add indexes terms under claimID, replacing what was there.
+func (s *shard) add(claimID string, terms []string) {
+	s.remove(claimID)
+	s.Docs[claimID] = len(terms)
+	for _, t := range terms {
+		p := s.Postings[t]
+		if p == nil {
+			p = map[string]int{}
+			s.Postings[t] = p
+		}
+		p[claimID]++
+	}
+}
+
+// remove drops claimID and reports whether it was indexed.
+func (s *shard) remove(claimID string) bool {
+	if _, ok := s.Docs[claimID]; !ok {
+		return false
+	}
+	delete(s.Docs, claimID)
+	for t, p := range s.Postings {
+		delete(p, claimID)
+		if len(p) == 0 {
+			delete(s.Postings, t)
+		}
+	}
+	return true
+}
+
+// search ranks the claims containing every term with BM25. Ties go to the newer claim
+// (claim IDs are ULIDs).
+func (s *shard) search(terms []string, limit int) []Hit {
+	if len(s.Docs) == 0 {
+		return nil
+	}
+	var total int
+	for _, n := range s.Docs {
+		total += n
+	}
+	avg := float64(total) / float64(len(s.Docs))
+	n := float64(len(s.Docs))
+
+	var scores map[string]float64
+	for _, t := range dedupe(terms) {
+		p := s.Postings[t]
+		if len(p) == 0 {
+			return nil // every term must match
+		}
+		df := float64(len(p))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		next := make(map[string]float64, len(p))
+		for id, tf := range p {
+			prev, ok := scores[id]
+			if scores != nil && !ok {
+				continue
+			}
+			f := float64(tf)
+			norm := 1 - bm25B + bm25B*float64(s.Docs[id])/avg
+			next[id] = prev + idf*f*(bm25K1+1)/(f+bm25K1*norm)
+		}
+		scores = next
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for id, sc := range scores {
+		hits = append(hits, Hit{ClaimID: id, Score: sc})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ClaimID > hits[j].ClaimID
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// dedupe returns terms without repeats, in order.
+func dedupe(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	out := terms[:0:0]
+	for _, t := range terms {
+		if !seen[t] {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	return out
+}
@@ -0,0 +1,112 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// maxAttempts bounds the retries of an update that keeps losing to concurrent writers.
+const maxAttempts = 5
+
+// errStale is returned by blobs.put when the shard changed since it was read.
+var errStale = errors.New("shard changed since read")
+
+// blobs stores serialized shards by user. tag identifies the version read by get ("" when
+// there is no shard yet); put writes only if the shard is still at that version.
+type blobs interface {
+	get(ctx context.Context, userID string) (data []byte, tag string, err error)
+	put(ctx context.Context, userID string, data []byte, tag string) error
+}
+
+// Store is an Index over shards kept in a blob backend (S3 or a local directory). Updates
+// are optimistic: read the shard, change it, write it back only if nobody else has, and
+// start over otherwise. It is safe for concurrent use, including across processes sharing
+// an S3 bucket.
+type Store struct {
+	blobs blobs
+}
+
+var _ Index = (*Store)(nil)
+
+// Add indexes d's text under d.ClaimID in d.UserID's shard.
+func (s *Store) Add(ctx context.Context, d Doc) error {
+	terms := Tokens(d.Text)
+	return s.update(ctx, d.UserID, func(sh *shard) bool {
+		sh.add(d.ClaimID, terms)
+		return true
+	})
+}
+
+// Remove drops claimID from userID's shard.
+func (s *Store) Remove(ctx context.Context, userID, claimID string) error {
+	return s.update(ctx, userID, func(sh *shard) bool { return sh.remove(claimID) })
+}
+
+// Search finds userID's claims containing every term of query. A user with nothing
+// indexed gets no hits.
+func (s *Store) Search(ctx context.Context, userID, query string, limit int) ([]Hit, error) {
+	terms := Tokens(query)
+	if len(terms) == 0 {
+		return nil, ErrEmptyQuery
+	}
+	sh, _, err := s.load(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return sh.search(terms, limit), nil
+}
+
+// update applies fn to userID's shard and saves it if fn reports a change, retrying from
+// a fresh read when a concurrent update wins.
+func (s *Store) update(ctx context.Context, userID string, fn func(*shard) bool) error {
+	for attempt := 1; ; attempt++ {
+		sh, tag, err := s.load(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if !fn(sh) {
+			return nil
+		}
+		data, err := json.Marshal(sh)
+		if err != nil {
+			return err
+		}
+		err = s.blobs.put(ctx, userID, data, tag)
+		if !errors.Is(err, errStale) || attempt == maxAttempts {
+			return err
+		}
+	}
+}
+
+// load reads and decodes userID's shard; a missing shard is empty.
+func (s *Store) load(ctx context.Context, userID string) (*shard, string, error) {
+	if err := checkUserID(userID); err != nil {
+		return nil, "", err
+	}
+	data, tag, err := s.blobs.get(ctx, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	sh := newShard()
+	if tag == "" || len(data) == 0 {
+		return sh, tag, nil // an empty object is an empty shard; keep its tag to replace it
+	}
+	if err := json.Unmarshal(data, sh); err != nil {
+		return nil, "", fmt.Errorf("search shard for %s: %w", userID, err)
+	}
+	if sh.Docs == nil || sh.Postings == nil {
+		sh = newShard()
+	}
+	return sh, tag, nil
+}
+
+// checkUserID rejects IDs that cannot name a shard: empty, or able to escape its directory.
+func checkUserID(userID string) error {
+	if userID == "" || strings.ContainsAny(userID, `/\`) || strings.HasPrefix(userID, ".") {
+		return fmt.Errorf("invalid user id %q", userID)
+	}
+	return nil
+}
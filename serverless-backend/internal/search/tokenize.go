@@ -0,0 +1,37 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// maxTokenLen drops runs that are not words (encoded blobs, long IDs pasted twice).
+const maxTokenLen = 64
+
+// stopwords are too common in claim letters to be worth a posting list.
+var stopwords = map[string]bool{
+	"an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"for": true, "from": true, "has": true, "have": true, "in": true, "is": true, "it": true,
+	"me": true, "my": true, "of": true, "on": true, "or": true, "that": true, "the": true,
+	"this": true, "to": true, "was": true, "were": true, "with": true,
+}
+
+// Tokens splits text into lowercased terms: runs of letters and digits, so "Austin, TX"
+// gives [austin tx] and "Policy #A12345" gives [policy a12345]. Single letters, stopwords
+// and over-long runs are dropped; single digits are kept. Queries are tokenized the same way.
+func Tokens(text string) []string {
+	var out []string
+	for _, f := range strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		t := strings.ToLower(f)
+		switch {
+		case len(t) > maxTokenLen, stopwords[t]:
+			continue
+		case len(t) == 1 && !unicode.IsDigit(rune(t[0])):
+			continue
+		}
+		out = append(out, t)
+	}
+	return out
+}
@@ -0,0 +1,135 @@
+package validate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Content types the portal knows how to accept and verify.
+const (
+	ContentTypeText = "text/plain"
+	ContentTypePDF  = "application/pdf"
+	ContentTypeJPEG = "image/jpeg"
+	ContentTypePNG  = "image/png"
+	ContentTypeDOCX = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+)
+
+// DefaultContentTypes is the allowlist used when none is configured.
+var DefaultContentTypes = []string{ContentTypeText, ContentTypePDF, ContentTypeJPEG, ContentTypePNG, ContentTypeDOCX}
+
+// SniffLen is how many leading bytes CheckMagic needs to identify a binary type.
+const SniffLen = 8
+
+// ErrTypeMismatch is returned when an object's leading bytes do not match its declared type.
+var ErrTypeMismatch = errors.New("content does not match declared type")
+
+// FileType describes an accepted upload type.
+type FileType struct {
+	ContentType string
+	Exts        []string // lowercase with dot; the first is used for S3 keys
+	Magic       [][]byte // accepted leading bytes; empty means the content is verified as plain text
+}
+
+// knownTypes lists every type that can appear in an allowlist.
+var knownTypes = map[string]FileType{
+	ContentTypeText: {ContentType: ContentTypeText, Exts: []string{".txt"}},
+	ContentTypePDF:  {ContentType: ContentTypePDF, Exts: []string{".pdf"}, Magic: [][]byte{[]byte("%PDF-")}},
+	ContentTypeJPEG: {ContentType: ContentTypeJPEG, Exts: []string{".jpg", ".jpeg"}, Magic: [][]byte{{0xFF, 0xD8, 0xFF}}},
+	ContentTypePNG:  {ContentType: ContentTypePNG, Exts: []string{".png"}, Magic: [][]byte{[]byte("\x89PNG\r\n\x1a\n")}},
+	// DOCX is a ZIP container; the local file header is as specific as a prefix check gets.
+	ContentTypeDOCX: {ContentType: ContentTypeDOCX, Exts: []string{".docx"}, Magic: [][]byte{[]byte("PK\x03\x04")}},
+}
+
+// Ext returns the canonical extension used when building S3 keys.
+func (t FileType) Ext() string { return t.Exts[0] }
+
+// IsText reports whether the type is verified by PlainText rather than magic bytes.
+func (t FileType) IsText() bool { return len(t.Magic) == 0 }
+
+// CheckMagic reads the first SniffLen bytes of r and checks them against the type's signatures.
+func (t FileType) CheckMagic(r io.Reader) error {
+	head := make([]byte, SniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return err
+	}
+	for _, m := range t.Magic {
+		if bytes.HasPrefix(head[:n], m) {
+			return nil
+		}
+	}
+	return ErrTypeMismatch
+}
+
+// Allowlist is the set of upload types accepted by presign and the indexer, keyed by content type.
+type Allowlist map[string]FileType
+
+// NewAllowlist builds an allowlist from a comma-separated list of content types.
+// An empty list means DefaultContentTypes; unknown types are an error.
+func NewAllowlist(csv string) (Allowlist, error) {
+	names := DefaultContentTypes
+	if strings.TrimSpace(csv) != "" {
+		names = strings.Split(csv, ",")
+	}
+	l := make(Allowlist, len(names))
+	for _, n := range names {
+		ct := normalizeContentType(n)
+		t, ok := knownTypes[ct]
+		if !ok {
+			return nil, fmt.Errorf("unsupported content type %q in allowlist", n)
+		}
+		l[ct] = t
+	}
+	return l, nil
+}
+
+// Check validates a filename and Content-Type pair and returns the matching type.
+// Content-Type parameters (e.g. "; charset=utf-8") are ignored.
+func (l Allowlist) Check(filename, contentType string) (FileType, error) {
+	t, ok := l[normalizeContentType(contentType)]
+	if !ok {
+		return FileType{}, errors.New("Content-Type must be one of " + strings.Join(l.contentTypes(), ", "))
+	}
+	ext := strings.ToLower(filepath.Ext(filename))
+	for _, e := range t.Exts {
+		if e == ext {
+			return t, nil
+		}
+	}
+	return FileType{}, fmt.Errorf("filename must end in %s for %s", strings.Join(t.Exts, " or "), t.ContentType)
+}
+
+// ByExt returns the allowed type for a lowercase extension such as ".pdf".
+func (l Allowlist) ByExt(ext string) (FileType, bool) {
+	for _, t := range l {
+		for _, e := range t.Exts {
+			if e == ext {
+				return t, true
+			}
+		}
+	}
+	return FileType{}, false
+}
+
+// contentTypes lists the allowed content types in a stable order for error messages.
+func (l Allowlist) contentTypes() []string {
+	out := make([]string, 0, len(l))
+	for _, ct := range DefaultContentTypes {
+		if _, ok := l[ct]; ok {
+			out = append(out, ct)
+		}
+	}
+	return out
+}
+
+// normalizeContentType lowercases ct and drops any parameters.
+func normalizeContentType(ct string) string {
+	if i := strings.IndexByte(ct, ';'); i >= 0 {
+		ct = ct[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(ct))
+}
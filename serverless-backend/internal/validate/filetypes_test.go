@@ -0,0 +1,155 @@
+package validate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestNewAllowlist(t *testing.T) {
+	tests := []struct {
+		csv     string
+		want    []string
+		wantErr bool
+	}{
+		{"", DefaultContentTypes, false},
+		{"  ", DefaultContentTypes, false},
+		{"text/plain", []string{ContentTypeText}, false},
+		{" Application/PDF ; charset=binary, image/png", []string{ContentTypePDF, ContentTypePNG}, false},
+		{"text/plain,image/gif", nil, true},
+		{"text/plain,", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.csv, func(t *testing.T) {
+			l, err := NewAllowlist(tt.csv)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if len(l) != len(tt.want) {
+				t.Fatalf("allowlist = %v, want %v", l.contentTypes(), tt.want)
+			}
+			for _, ct := range tt.want {
+				if l[ct].ContentType != ct {
+					t.Errorf("allowlist is missing %s", ct)
+				}
+			}
+		})
+	}
+}
+
+func TestAllowlistCheck(t *testing.T) {
+	all, err := NewAllowlist("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	textOnly, err := NewAllowlist(ContentTypeText)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name        string
+		list        Allowlist
+		filename    string
+		contentType string
+		want        string // content type of the match; "" for an error
+		wantErr     string
+	}{
+		{"text", all, "letter.txt", "text/plain", ContentTypeText, ""},
+		{"content type parameters", all, "letter.txt", "Text/Plain; charset=utf-8", ContentTypeText, ""},
+		{"uppercase extension", all, "SCAN.PDF", "application/pdf", ContentTypePDF, ""},
+		{"second extension", all, "photo.jpeg", "image/jpeg", ContentTypeJPEG, ""},
+		{"docx", all, "statement.docx", ContentTypeDOCX, ContentTypeDOCX, ""},
+		{"extension for another type", all, "photo.png", "image/jpeg", "", "filename must end in .jpg or .jpeg for image/jpeg"},
+		{"no extension", all, "letter", "text/plain", "", "filename must end in .txt"},
+		{"only the last extension counts", all, "letter.txt.exe", "text/plain", "", "filename must end in .txt"},
+		{"unknown content type", all, "anim.gif", "image/gif", "", "Content-Type must be one of text/plain, application/pdf"},
+		{"type outside the allowlist", textOnly, "scan.pdf", "application/pdf", "", "Content-Type must be one of text/plain"},
+		{"empty content type", all, "letter.txt", "", "", "Content-Type must be one of"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ft, err := tt.list.Check(tt.filename, tt.contentType)
+			if tt.wantErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ft.ContentType != tt.want {
+				t.Errorf("type = %s, want %s", ft.ContentType, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllowlistByExt(t *testing.T) {
+	l, err := NewAllowlist("text/plain,image/jpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		ext  string
+		want string
+		ok   bool
+	}{
+		{".txt", ContentTypeText, true},
+		{".jpg", ContentTypeJPEG, true},
+		{".jpeg", ContentTypeJPEG, true},
+		{".pdf", "", false}, // known, but not allowed here
+		{".TXT", "", false}, // callers lowercase first
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ext, func(t *testing.T) {
+			ft, ok := l.ByExt(tt.ext)
+			if ok != tt.ok || ft.ContentType != tt.want {
+				t.Errorf("ByExt(%q) = %s %v, want %s %v", tt.ext, ft.ContentType, ok, tt.want, tt.ok)
+			}
+		})
+	}
+	if ext := knownTypes[ContentTypeJPEG].Ext(); ext != ".jpg" {
+		t.Errorf("jpeg Ext = %s, want .jpg", ext)
+	}
+}
+
+func TestCheckMagic(t *testing.T) {
+	tests := []struct {
+		contentType string
+		head        string
+		want        error
+	}{
+		{ContentTypePDF, "%PDF-1.7\n...", nil},
+		{ContentTypePDF, "%PDF-", nil},
+		{ContentTypePDF, "%PDF", ErrTypeMismatch}, // short of the signature
+		{ContentTypePDF, "", ErrTypeMismatch},
+		{ContentTypeJPEG, "\xff\xd8\xff\xe0JFIF", nil},
+		{ContentTypeJPEG, "\x89PNG\r\n\x1a\n", ErrTypeMismatch},
+		{ContentTypePNG, "\x89PNG\r\n\x1a\n\x00\x00", nil},
+		{ContentTypePNG, "\x89PNG\r\n", ErrTypeMismatch},
+		{ContentTypeDOCX, "PK\x03\x04\x14\x00", nil},
+		{ContentTypeDOCX, "PK\x05\x06", ErrTypeMismatch}, // an empty ZIP
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentType+"/"+tt.head, func(t *testing.T) {
+			ft := knownTypes[tt.contentType]
+			if ft.IsText() {
+				t.Fatalf("%s has no magic bytes", tt.contentType)
+			}
+			if err := ft.CheckMagic(strings.NewReader(tt.head)); !errors.Is(err, tt.want) {
+				t.Errorf("CheckMagic = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	if !knownTypes[ContentTypeText].IsText() {
+		t.Error("text/plain is not verified as text")
+	}
+	readErr := errors.New("connection reset")
+	if err := knownTypes[ContentTypePDF].CheckMagic(iotest.ErrReader(readErr)); !errors.Is(err, readErr) {
+		t.Errorf("CheckMagic = %v, want the read error", err)
+	}
+}
@@ -2,29 +2,30 @@
 package validate
 
 import (
+	"bufio"
 	"errors"
-	"path/filepath"
+	"io"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/kylejryan/insurance-claim-upload-portal/internal/models"
+
+	"github.com/oklog/ulid/v2"
 )
 
-var tagRx = regexp.MustCompile(`^[a-zA-Z0-9 _\-]{1,32}$`)
+// MaxPageSize is the largest page a list endpoint will return.
+const MaxPageSize = 100
 
-// FilenameTxt checks that the filename has a .txt extension (case insensitive).
-func FilenameTxt(fn string) error {
-	if strings.ToLower(filepath.Ext(fn)) != ".txt" {
-		return errors.New("only .txt files allowed")
-	}
-	return nil
-}
+// Content verification errors returned by PlainText.
+var (
+	ErrNotUTF8       = errors.New("content is not valid UTF-8")
+	ErrBinaryContent = errors.New("content contains binary or control bytes")
+)
 
-// ContentTypeTextPlain checks that the Content-Type is exactly text/plain (case insensitive, trimmed).
-func ContentTypeTextPlain(ct string) error {
-	if strings.TrimSpace(strings.ToLower(ct)) != "text/plain" {
-		return errors.New("Content-Type must be text/plain")
-	}
-	return nil
-}
+var tagRx = regexp.MustCompile(`^[a-zA-Z0-9 _\-]{1,32}$`)
 
 // TagsOK checks that there is 1 to 10 tags, each matching the allowed pattern.
 func TagsOK(tags []string) error {
@@ -39,6 +40,14 @@ func TagsOK(tags []string) error {
 	return nil
 }
 
+// Tag checks a single tag, e.g. a ?tag= filter, against the pattern TagsOK enforces.
+func Tag(t string) error {
+	if !tagRx.MatchString(t) {
+		return errors.New("invalid tag: " + t)
+	}
+	return nil
+}
+
 // ClientOK checks that the client string is non-empty after trimming whitespace.
 func ClientOK(c string) error {
 	if strings.TrimSpace(c) == "" {
@@ -46,3 +55,171 @@ func ClientOK(c string) error {
 	}
 	return nil
 }
+
+// PageLimit parses a "limit" query parameter; empty means MaxPageSize.
+func PageLimit(raw string) (int32, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return MaxPageSize, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 || n > MaxPageSize {
+		return 0, errors.New("limit must be 1.." + strconv.Itoa(MaxPageSize))
+	}
+	return int32(n), nil
+}
+
+// MaxVendors caps the vendors assigned to one claim.
+const MaxVendors = 10
+
+// Vendors checks a claim's vendor assignment: at most MaxVendors distinct, non-empty user
+// subs (Cognito subs are UUIDs; anything with spaces or over 128 characters is not one).
+func Vendors(subs []string) error {
+	if len(subs) > MaxVendors {
+		return errors.New("at most " + strconv.Itoa(MaxVendors) + " vendors")
+	}
+	seen := make(map[string]bool, len(subs))
+	for _, s := range subs {
+		if s == "" || len(s) > 128 || strings.ContainsAny(s, " \t\r\n") {
+			return errors.New("invalid vendor: " + s)
+		}
+		if seen[s] {
+			return errors.New("duplicate vendor: " + s)
+		}
+		seen[s] = true
+	}
+	return nil
+}
+
+// maxClientFilter caps a ?client= filter; stored clients are free-form but short.
+const maxClientFilter = 64
+
+// ClientFilter checks a ?client= filter: non-empty plain text of at most 64 characters.
+func ClientFilter(c string) error {
+	if err := ClientOK(c); err != nil {
+		return err
+	}
+	if utf8.RuneCountInString(c) > maxClientFilter || PlainText(strings.NewReader(c)) != nil {
+		return errors.New("invalid client")
+	}
+	return nil
+}
+
+// ClaimStatus parses an upload status filter such as ?status=COMPLETE (case-insensitive).
+func ClaimStatus(raw string) (models.ClaimStatus, error) {
+	s := models.ClaimStatus(strings.ToUpper(strings.TrimSpace(raw)))
+	if !s.Valid() {
+		return "", errors.New("invalid status")
+	}
+	return s, nil
+}
+
+// MaxSearchQuery caps a ?q= search query, in characters.
+const MaxSearchQuery = 256
+
+// SearchQuery checks a ?q= search query: non-empty plain text of at most MaxSearchQuery characters.
+func SearchQuery(q string) error {
+	if strings.TrimSpace(q) == "" {
+		return errors.New("q is required")
+	}
+	if utf8.RuneCountInString(q) > MaxSearchQuery || PlainText(strings.NewReader(q)) != nil {
+		return errors.New("invalid q")
+	}
+	return nil
+}
+
+// DateLayout is the format of the ?from= and ?to= list filters.
+const DateLayout = "2006-01-02"
+
+// DateRange parses ?from= and ?to= dates (YYYY-MM-DD, UTC; either may be empty) into a
+// half-open interval [from, to): to is moved to the start of the following day so the
+// range includes the whole of that day. Unset ends are the zero time.
+func DateRange(rawFrom, rawTo string) (from, to time.Time, err error) {
+	parse := func(name, raw string) (time.Time, error) {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			return time.Time{}, nil
+		}
+		t, err := time.Parse(DateLayout, raw)
+		if err != nil || t.Year() < 1970 { // claim IDs are ULIDs, which start at the Unix epoch
+			return time.Time{}, errors.New(name + " must be a date (YYYY-MM-DD)")
+		}
+		return t, nil
+	}
+	if from, err = parse("from", rawFrom); err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if to, err = parse("to", rawTo); err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if !to.IsZero() {
+		to = to.AddDate(0, 0, 1)
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return time.Time{}, time.Time{}, errors.New("from must not be after to")
+	}
+	return from, to, nil
+}
+
+// MultipartSize checks a declared multipart upload size against the cap and S3's part limit.
+func MultipartSize(size, maxBytes, partSize int64) error {
+	if size <= 0 || size > maxBytes {
+		return errors.New("size_bytes must be 1.." + strconv.FormatInt(maxBytes, 10))
+	}
+	if (size+partSize-1)/partSize > 10000 {
+		return errors.New("size_bytes needs more than 10000 parts")
+	}
+	return nil
+}
+
+// ClaimID checks that id is a canonical ULID as issued by presign.
+func ClaimID(id string) error {
+	if _, err := ulid.ParseStrict(id); err != nil {
+		return errors.New("invalid claim id")
+	}
+	return nil
+}
+
+// MaxReviewNote caps the note an adjuster attaches to a review transition.
+const MaxReviewNote = 1000
+
+// ReviewNote checks that a review note is at most MaxReviewNote characters of text.
+func ReviewNote(note string) error {
+	if utf8.RuneCountInString(note) > MaxReviewNote {
+		return errors.New("note must be at most " + strconv.Itoa(MaxReviewNote) + " characters")
+	}
+	if PlainText(strings.NewReader(note)) != nil {
+		return errors.New("note must be plain text")
+	}
+	return nil
+}
+
+// PlainText streams r and checks that it is valid UTF-8 with no control characters
+// other than tab, newline, carriage return and form feed. A leading BOM is allowed.
+func PlainText(r io.Reader) error {
+	br := bufio.NewReader(r)
+	for {
+		c, size, err := br.ReadRune()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if c == utf8.RuneError && size == 1 {
+			return ErrNotUTF8
+		}
+		if isBinaryRune(c) {
+			return ErrBinaryContent
+		}
+	}
+}
+
+// isBinaryRune reports C0/C1 control characters that do not occur in text letters.
+func isBinaryRune(c rune) bool {
+	switch c {
+	case '\t', '\n', '\r', '\f':
+		return false
+	}
+	return c < 0x20 || (c >= 0x7f && c <= 0x9f)
+}
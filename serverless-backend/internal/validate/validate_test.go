@@ -0,0 +1,139 @@
+package validate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+func TestClaimID(t *testing.T) {
+	id := ulid.Make().String()
+	tests := []struct {
+		name string
+		id   string
+		ok   bool
+	}{
+		{"ulid", id, true},
+		{"zero ulid", "00000000000000000000000000", true},
+		{"empty", "", false},
+		{"too short", id[:25], false},
+		{"too long", id + "0", false},
+		{"invalid character", "0000000000000000000000000U", false},
+		{"overflow", "80000000000000000000000000", false},
+		{"path traversal", "../../../../../../../../..", false},
+		{"surrounding space", " " + id[1:], false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ClaimID(tt.id); (err == nil) != tt.ok {
+				t.Errorf("ClaimID(%q) = %v, want ok %v", tt.id, err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestPageLimit(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    int32
+		wantErr bool
+	}{
+		{"", MaxPageSize, false},
+		{"  ", MaxPageSize, false},
+		{"1", 1, false},
+		{" 25 ", 25, false},
+		{"100", 100, false},
+		{"0", 0, true},
+		{"-5", 0, true},
+		{"101", 0, true},
+		{"ten", 0, true},
+		{"2.5", 0, true},
+		{"99999999999999999999", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := PageLimit(tt.raw)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("PageLimit(%q) = %d, %v; want %d, error %v", tt.raw, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDateRange(t *testing.T) {
+	day := func(s string) time.Time {
+		d, err := time.Parse(DateLayout, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	tests := []struct {
+		name     string
+		from, to string
+		wantFrom time.Time
+		wantTo   time.Time
+		wantErr  string
+	}{
+		{"neither", "", "", time.Time{}, time.Time{}, ""},
+		{"from only", "2024-12-01", "", day("2024-12-01"), time.Time{}, ""},
+		{"to includes the whole day", "", "2024-12-31", time.Time{}, day("2025-01-01"), ""},
+		{"both", " 2024-12-01 ", "2024-12-31", day("2024-12-01"), day("2025-01-01"), ""},
+		{"one day", "2024-02-29", "2024-02-29", day("2024-02-29"), day("2024-03-01"), ""},
+		{"from after to", "2024-12-02", "2024-12-01", time.Time{}, time.Time{}, "from must not be after to"},
+		{"bad from", "12/01/2024", "", time.Time{}, time.Time{}, "from must be a date"},
+		{"bad to", "", "2024-13-01", time.Time{}, time.Time{}, "to must be a date"},
+		{"not a leap year", "2023-02-29", "", time.Time{}, time.Time{}, "from must be a date"},
+		{"timestamp", "2024-12-01T00:00:00Z", "", time.Time{}, time.Time{}, "from must be a date"},
+		{"before the epoch", "1969-12-31", "", time.Time{}, time.Time{}, "from must be a date"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, err := DateRange(tt.from, tt.to)
+			if tt.wantErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !from.Equal(tt.wantFrom) || !to.Equal(tt.wantTo) {
+				t.Errorf("DateRange = [%s, %s), want [%s, %s)", from, to, tt.wantFrom, tt.wantTo)
+			}
+			if !from.IsZero() && from.Location() != time.UTC {
+				t.Errorf("from is in %s, want UTC", from.Location())
+			}
+		})
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want error
+	}{
+		{"letter", "Dear Claims Adjuster,\r\n\tMy car was hit.\f", nil},
+		{"unicode", "café ☕ naïve", nil},
+		{"bom", "\ufeffDear Claims Adjuster", nil},
+		{"empty", "", nil},
+		{"nul", "Dear\x00Adjuster", ErrBinaryContent},
+		{"escape", "\x1b[31mred", ErrBinaryContent},
+		{"delete", "a\x7fb", ErrBinaryContent},
+		{"c1 control", "a\u0085b", ErrBinaryContent},
+		{"latin-1", "caf\xe9", ErrNotUTF8},
+		{"truncated sequence", "\xe2\x98", ErrNotUTF8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := PlainText(strings.NewReader(tt.text)); !errors.Is(err, tt.want) {
+				t.Errorf("PlainText(%q) = %v, want %v", tt.text, err, tt.want)
+			}
+		})
+	}
+}